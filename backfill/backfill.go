@@ -0,0 +1,219 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package backfill replays the historical series of a Prometheus remote
+// read endpoint or TSDB directory to Graphite, through the rules and
+// templates of the adapter.
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"golang.org/x/time/rate"
+)
+
+// Run runs the backfill command: it reads the series of the configured
+// source and writes them with the Graphite client of the config.
+func Run(ctx context.Context, cfg *config.Config, out io.Writer, logger *slog.Logger) error {
+	opts := cfg.Backfill
+	if cfg.Graphite.Write.CarbonAddress == "" {
+		return errors.New("backfill requires a carbon address")
+	}
+	c := graphite.NewClient(cfg, logger)
+	defer c.Shutdown()
+
+	var src Source
+	if opts.TSDBPath != "" {
+		var err error
+		src, err = NewTSDBSource(ctx, opts.TSDBPath, opts.Start.UnixMilli(), opts.End.UnixMilli())
+		if err != nil {
+			return fmt.Errorf("error opening TSDB %s: %w", opts.TSDBPath, err)
+		}
+	} else {
+		src = NewRemoteReadSource(opts.RemoteReadURL, cfg.Read.Timeout)
+	}
+	defer func() { _ = src.Close() }()
+
+	b, err := newBackfiller(cfg, src, c, out, logger)
+	if err != nil {
+		return err
+	}
+	return b.run(ctx)
+}
+
+// backfiller writes the series of a source window by window, recording the
+// next window in a checkpoint file once a window is written.
+type backfiller struct {
+	logger *slog.Logger
+	source Source
+	writer client.Writer
+	out    io.Writer
+
+	matchers       [][]*labels.Matcher
+	start          time.Time
+	end            time.Time
+	window         time.Duration
+	batchSize      int
+	limiter        *rate.Limiter
+	checkpointFile string
+	dryRun         bool
+}
+
+func newBackfiller(cfg *config.Config, src Source, w client.Writer, out io.Writer, logger *slog.Logger) (*backfiller, error) {
+	opts := cfg.Backfill
+	b := &backfiller{
+		logger:         logger,
+		source:         src,
+		writer:         w,
+		out:            out,
+		start:          opts.Start,
+		end:            opts.End,
+		window:         opts.Window,
+		batchSize:      opts.BatchSize,
+		checkpointFile: opts.CheckpointFile,
+		dryRun:         opts.DryRun,
+	}
+	for _, m := range opts.Match {
+		matchers, err := parser.ParseMetricSelector(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid series selector %q: %w", m, err)
+		}
+		b.matchers = append(b.matchers, matchers)
+	}
+	if opts.RateLimit > 0 {
+		b.limiter = rate.NewLimiter(rate.Limit(opts.RateLimit), opts.BatchSize)
+	}
+	return b, nil
+}
+
+// checkpoint records the start of the next window to backfill.
+type checkpoint struct {
+	Next time.Time `json:"next"`
+}
+
+func (b *backfiller) run(ctx context.Context) error {
+	start := b.start
+	if b.checkpointFile != "" {
+		cp, err := readCheckpoint(b.checkpointFile)
+		if err != nil {
+			return err
+		}
+		if cp != nil && cp.Next.After(start) {
+			b.logger.Info("Resuming backfill from checkpoint", "file", b.checkpointFile, "next", cp.Next)
+			start = cp.Next
+		}
+	}
+
+	// A request carrying the context of the writes, and the default prefix.
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/write", nil)
+	if err != nil {
+		return err
+	}
+
+	for ws := start; ws.Before(b.end); ws = ws.Add(b.window) {
+		we := ws.Add(b.window)
+		if we.After(b.end) {
+			we = b.end
+		}
+		n, err := b.backfillWindow(ctx, r, ws.UnixMilli(), we.UnixMilli()-1)
+		if err != nil {
+			return fmt.Errorf("error backfilling %s to %s: %w", ws, we, err)
+		}
+		b.logger.Info("Backfilled window", "start", ws, "end", we, "num_samples", n)
+		if b.checkpointFile != "" && !b.dryRun {
+			if err := writeCheckpoint(b.checkpointFile, checkpoint{Next: we}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backfillWindow writes the samples between mint and maxt included, and
+// returns their number.
+func (b *backfiller) backfillWindow(ctx context.Context, r *http.Request, mint, maxt int64) (int, error) {
+	var samples model.Samples
+	for _, matchers := range b.matchers {
+		s, err := b.source.Read(ctx, mint, maxt, matchers)
+		if err != nil {
+			return 0, err
+		}
+		samples = append(samples, s...)
+	}
+
+	for i := 0; i < len(samples); i += b.batchSize {
+		batch := samples[i:min(i+b.batchSize, len(samples))]
+		if b.limiter != nil {
+			if err := b.limiter.WaitN(ctx, len(batch)); err != nil {
+				return 0, err
+			}
+		}
+		msg, err := b.writer.Write(batch, 0, r, b.dryRun)
+		if err != nil {
+			return 0, err
+		}
+		if b.dryRun {
+			if _, err := b.out.Write(msg); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(samples), nil
+}
+
+func readCheckpoint(file string) (*checkpoint, error) {
+	content, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(content, &cp); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint %s: %w", file, err)
+	}
+	return &cp, nil
+}
+
+// writeCheckpoint replaces the checkpoint file, so that it's never left
+// partially written.
+func writeCheckpoint(file string, cp checkpoint) error {
+	content, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,179 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite"
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStart = time.UnixMilli(1700000000000)
+
+// fakeSource serves a sample of the "up" series every minute.
+type fakeSource struct {
+	reads [][2]int64
+}
+
+func (s *fakeSource) Read(_ context.Context, mint, maxt int64, _ []*labels.Matcher) (model.Samples, error) {
+	s.reads = append(s.reads, [2]int64{mint, maxt})
+	var samples model.Samples
+	for t := mint; t <= maxt; t += time.Minute.Milliseconds() {
+		samples = append(samples, &model.Sample{
+			Metric:    model.Metric{model.MetricNameLabel: "up", "job": "node"},
+			Value:     1,
+			Timestamp: model.Time(t),
+		})
+	}
+	return samples, nil
+}
+
+func (s *fakeSource) Close() error { return nil }
+
+type fakeWriter struct {
+	batches []int
+	fail    bool
+}
+
+func (w *fakeWriter) Name() string   { return "fake" }
+func (w *fakeWriter) Target() string { return "fake" }
+func (w *fakeWriter) String() string { return "fake" }
+func (w *fakeWriter) Shutdown()      {}
+
+func (w *fakeWriter) Write(samples model.Samples, _ int, _ *http.Request, _ bool) ([]byte, error) {
+	if w.fail {
+		return nil, errors.New("carbon unavailable")
+	}
+	w.batches = append(w.batches, len(samples))
+	return []byte("Done."), nil
+}
+
+func testConfig() *config.Config {
+	cfg := config.DefaultConfig
+	cfg.Backfill.Start = testStart
+	cfg.Backfill.End = testStart.Add(150 * time.Minute)
+	cfg.Backfill.Match = []string{"up"}
+	cfg.Backfill.Window = time.Hour
+	cfg.Backfill.BatchSize = 40
+	return &cfg
+}
+
+func TestBackfillWindowsAndCheckpoint(t *testing.T) {
+	cfg := testConfig()
+	cfg.Backfill.CheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+	src := &fakeSource{}
+	w := &fakeWriter{fail: true}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// The first window fails and nothing is recorded.
+	b, err := newBackfiller(cfg, src, w, io.Discard, logger)
+	require.NoError(t, err)
+	require.Error(t, b.run(context.Background()))
+	cp, err := readCheckpoint(cfg.Backfill.CheckpointFile)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	w.fail = false
+	src.reads = nil
+	require.NoError(t, b.run(context.Background()))
+	hour := time.Hour.Milliseconds()
+	start := testStart.UnixMilli()
+	assert.Equal(t, [][2]int64{
+		{start, start + hour - 1},
+		{start + hour, start + 2*hour - 1},
+		{start + 2*hour, start + 150*time.Minute.Milliseconds() - 1},
+	}, src.reads)
+	assert.Equal(t, []int{40, 20, 40, 20, 30}, w.batches)
+
+	cp, err = readCheckpoint(cfg.Backfill.CheckpointFile)
+	require.NoError(t, err)
+	assert.True(t, cp.Next.Equal(cfg.Backfill.End))
+
+	// A complete backfill is resumed after its end, so reads nothing.
+	src.reads = nil
+	require.NoError(t, b.run(context.Background()))
+	assert.Empty(t, src.reads)
+
+	require.NoError(t, writeCheckpoint(cfg.Backfill.CheckpointFile, checkpoint{Next: testStart.Add(2 * time.Hour)}))
+	require.NoError(t, b.run(context.Background()))
+	assert.Len(t, src.reads, 1)
+}
+
+func TestBackfillDryRun(t *testing.T) {
+	cfg := testConfig()
+	cfg.Backfill.End = testStart.Add(2 * time.Minute)
+	cfg.Backfill.DryRun = true
+	cfg.Graphite = graphiteCfg.DefaultConfig
+	cfg.Graphite.DefaultPrefix = "prom."
+	cfg.Graphite.Write.CarbonAddress = "localhost:2003"
+	var out bytes.Buffer
+
+	b, err := newBackfiller(cfg, &fakeSource{}, graphite.NewClient(cfg, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		&out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, b.run(context.Background()))
+	assert.Equal(t, "prom.up.job.node 1.000000 1700000000\nprom.up.job.node 1.000000 1700000060\n", out.String())
+}
+
+func TestBackfillInvalidSelector(t *testing.T) {
+	cfg := testConfig()
+	cfg.Backfill.Match = []string{"{"}
+	_, err := newBackfiller(cfg, &fakeSource{}, &fakeWriter{}, io.Discard, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Error(t, err)
+}
+
+func TestTSDBSource(t *testing.T) {
+	dir := t.TempDir()
+	db, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	app := db.Appender(context.Background())
+	for i := int64(0); i < 5; i++ {
+		_, err = app.Append(0, labels.FromStrings("__name__", "up", "job", "node"), 1000*i, float64(i))
+		require.NoError(t, err)
+		_, err = app.Append(0, labels.FromStrings("__name__", "other"), 1000*i, float64(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+	require.NoError(t, db.Close())
+
+	src, err := NewTSDBSource(context.Background(), dir, 0, 5000)
+	require.NoError(t, err)
+	defer func() { _ = src.Close() }()
+
+	samples, err := src.Read(context.Background(), 1000, 2999, []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, "__name__", "up"),
+	})
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, model.Time(1000), samples[0].Timestamp)
+	assert.Equal(t, model.SampleValue(2), samples[1].Value)
+	assert.Equal(t, model.LabelValue("node"), samples[1].Metric["job"])
+}
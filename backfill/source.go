@@ -0,0 +1,137 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package backfill
+
+import (
+	"context"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	promremote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb"
+)
+
+// Source reads historical series.
+type Source interface {
+	// Read returns the samples of the series matching the matchers, between
+	// mint and maxt included, in milliseconds.
+	Read(ctx context.Context, mint, maxt int64, matchers []*labels.Matcher) (model.Samples, error)
+	Close() error
+}
+
+// remoteReadSource reads the series from a Prometheus remote read endpoint.
+type remoteReadSource struct {
+	client *remote.ReadClient
+}
+
+// NewRemoteReadSource returns a Source reading the series from a Prometheus
+// remote read endpoint.
+func NewRemoteReadSource(url string, timeout time.Duration) Source {
+	return &remoteReadSource{client: remote.NewReadClient(url, timeout)}
+}
+
+func (s *remoteReadSource) Read(ctx context.Context, mint, maxt int64, matchers []*labels.Matcher) (model.Samples, error) {
+	query, err := promremote.ToQuery(mint, maxt, matchers, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Read(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples model.Samples
+	for _, ts := range res.Timeseries {
+		metric := labelsToMetric(ts.Labels)
+		for _, sample := range ts.Samples {
+			samples = append(samples, &model.Sample{
+				Metric:    metric,
+				Value:     model.SampleValue(sample.Value),
+				Timestamp: model.Time(sample.Timestamp),
+			})
+		}
+	}
+	return samples, nil
+}
+
+func (s *remoteReadSource) Close() error {
+	return nil
+}
+
+// tsdbSource reads the series from the blocks and the WAL of a Prometheus
+// TSDB directory.
+type tsdbSource struct {
+	db      *tsdb.DBReadOnly
+	querier storage.Querier
+}
+
+// NewTSDBSource returns a Source reading the series of a Prometheus TSDB
+// directory between mint and maxt, which must cover the reads.
+func NewTSDBSource(ctx context.Context, dir string, mint, maxt int64) (Source, error) {
+	db, err := tsdb.OpenDBReadOnly(dir, log.NewNopLogger())
+	if err != nil {
+		return nil, err
+	}
+	// A read only TSDB only supports a single querier.
+	querier, err := db.Querier(ctx, mint, maxt)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &tsdbSource{db: db, querier: querier}, nil
+}
+
+func (s *tsdbSource) Read(_ context.Context, mint, maxt int64, matchers []*labels.Matcher) (model.Samples, error) {
+	var samples model.Samples
+	set := s.querier.Select(false, &storage.SelectHints{Start: mint, End: maxt}, matchers...)
+	for set.Next() {
+		series := set.At()
+		metric := make(model.Metric, len(series.Labels()))
+		for _, l := range series.Labels() {
+			metric[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		it := series.Iterator()
+		for ok := it.Seek(mint); ok; ok = it.Next() {
+			t, v := it.At()
+			if t > maxt {
+				break
+			}
+			samples = append(samples, &model.Sample{Metric: metric, Value: model.SampleValue(v), Timestamp: model.Time(t)})
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return samples, set.Err()
+}
+
+func (s *tsdbSource) Close() error {
+	_ = s.querier.Close()
+	return s.db.Close()
+}
+
+func labelsToMetric(ls []prompb.Label) model.Metric {
+	metric := make(model.Metric, len(ls))
+	for _, l := range ls {
+		metric[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return metric
+}
@@ -0,0 +1,397 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package carbon implements a carbon plaintext listener, which converts the
+// received Graphite metrics to labeled series and forwards them to a
+// Prometheus remote write endpoint.
+package carbon
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const (
+	namespace = "remote_adapter"
+	subsystem = "carbon"
+
+	// udpMaxBytes is the maximum size of a received UDP packet.
+	udpMaxBytes = 65535
+	// maxRetryBackoff caps the exponential backoff between retries.
+	maxRetryBackoff = 1 * time.Minute
+)
+
+var (
+	receivedSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "received_samples_total",
+			Help:      "Total number of samples received by the carbon listener.",
+		},
+		[]string{"transport"},
+	)
+	invalidLines = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "invalid_lines_total",
+			Help:      "Total number of carbon lines which could not be parsed or mapped to labels.",
+		},
+		[]string{"transport"},
+	)
+	droppedSamples = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dropped_samples_total",
+			Help:      "Total number of samples dropped because the remote write queue was full.",
+		},
+	)
+	sentSamples = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "sent_samples_total",
+			Help:      "Total number of samples sent to the remote write endpoint.",
+		},
+	)
+	failedSamples = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "failed_samples_total",
+			Help:      "Total number of samples which could not be sent to the remote write endpoint.",
+		},
+	)
+	retriedBatches = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "retried_batches_total",
+			Help:      "Total number of retried remote write requests.",
+		},
+	)
+	queueLength = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "queue_length",
+			Help:      "Number of samples waiting to be sent to the remote write endpoint.",
+		},
+	)
+)
+
+// Storage stores batches of series.
+type Storage interface {
+	Store(ctx context.Context, req *prompb.WriteRequest) error
+}
+
+// Listener receives carbon plaintext metrics on TCP and UDP and forwards
+// them as batched Prometheus remote write requests.
+type Listener struct {
+	logger *slog.Logger
+
+	lock   sync.Mutex
+	server *server
+}
+
+// New returns a new Listener, started by ApplyConfig.
+func New(logger *slog.Logger) *Listener {
+	return &Listener{logger: logger}
+}
+
+// ApplyConfig stops the running listener, if any, and starts a new one if
+// a listen address is configured.
+func (l *Listener) ApplyConfig(cfg *config.Config) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.server != nil {
+		l.server.stop()
+		l.server = nil
+	}
+	opts := cfg.CarbonListener
+	if opts.ListenAddress == "" {
+		return nil
+	}
+	if opts.RemoteWriteURL == "" {
+		return fmt.Errorf("carbon listener requires a remote write URL")
+	}
+
+	s := newServer(cfg, remote.NewWriteClient(opts.RemoteWriteURL, opts.Timeout), l.logger)
+	if err := s.start(opts.ListenAddress); err != nil {
+		return err
+	}
+	l.server = s
+	return nil
+}
+
+// Stop stops the listener and flushes the queued samples.
+func (l *Listener) Stop() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.server != nil {
+		l.server.stop()
+		l.server = nil
+	}
+}
+
+// server is a running carbon listener.
+type server struct {
+	logger  *slog.Logger
+	storage Storage
+	mapper  *mapper
+	now     func() time.Time
+
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+
+	queue chan prompb.TimeSeries
+	// done is closed to interrupt the retries of the sender.
+	done chan struct{}
+
+	tcp     net.Listener
+	udp     net.PacketConn
+	conns   map[net.Conn]struct{}
+	connsMu sync.Mutex
+
+	readers sync.WaitGroup
+	sender  sync.WaitGroup
+}
+
+func newServer(cfg *config.Config, storage Storage, logger *slog.Logger) *server {
+	opts := cfg.CarbonListener
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = config.DefaultConfig.CarbonListener.BatchSize
+	}
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = config.DefaultConfig.CarbonListener.FlushInterval
+	}
+	return &server{
+		logger:  logger,
+		storage: storage,
+		mapper: &mapper{
+			prefix:       opts.Prefix,
+			labelMapping: opts.LabelMapping,
+			mappings:     opts.Mappings,
+		},
+		now:           time.Now,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxRetries:    opts.MaxRetries,
+		retryBackoff:  opts.RetryBackoff,
+		queue:         make(chan prompb.TimeSeries, max(opts.QueueSize, 0)),
+		done:          make(chan struct{}),
+		conns:         make(map[net.Conn]struct{}),
+	}
+}
+
+// start listens on address and starts forwarding the received samples.
+func (s *server) start(address string) error {
+	var err error
+	if s.tcp, err = net.Listen("tcp", address); err != nil {
+		return err
+	}
+	if s.udp, err = net.ListenPacket("udp", address); err != nil {
+		_ = s.tcp.Close()
+		return err
+	}
+	s.logger.Info("Listening for carbon metrics", "address", address)
+
+	s.readers.Add(2)
+	go s.serveTCP()
+	go s.serveUDP()
+	s.sender.Add(1)
+	go s.send()
+	return nil
+}
+
+// stop closes the listeners and connections, then flushes the queue.
+func (s *server) stop() {
+	_ = s.tcp.Close()
+	_ = s.udp.Close()
+	s.connsMu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMu.Unlock()
+	s.readers.Wait()
+
+	close(s.done)
+	close(s.queue)
+	s.sender.Wait()
+}
+
+func (s *server) serveTCP() {
+	defer s.readers.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Error accepting carbon connection", "err", err)
+			}
+			return
+		}
+		s.connsMu.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMu.Unlock()
+
+		s.readers.Add(1)
+		go func() {
+			defer s.readers.Done()
+			s.handleConn(conn)
+			s.connsMu.Lock()
+			delete(s.conns, conn)
+			s.connsMu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (s *server) handleConn(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text(), "tcp")
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		s.logger.Debug("Error reading carbon connection", "remote", conn.RemoteAddr(), "err", err)
+	}
+}
+
+func (s *server) serveUDP() {
+	defer s.readers.Done()
+	buf := make([]byte, udpMaxBytes)
+	for {
+		n, _, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Error("Error reading carbon packet", "err", err)
+			}
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			s.handleLine(line, "udp")
+		}
+	}
+}
+
+// handleLine parses a line and queues its sample.
+func (s *server) handleLine(line string, transport string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	path, sample, err := parseLine(line, s.now())
+	if err == nil {
+		var labels []prompb.Label
+		if labels, err = s.mapper.labels(path); err == nil {
+			receivedSamples.WithLabelValues(transport).Inc()
+			s.enqueue(prompb.TimeSeries{Labels: labels, Samples: []prompb.Sample{sample}})
+			return
+		}
+	}
+	invalidLines.WithLabelValues(transport).Inc()
+	s.logger.Debug("Invalid carbon line", "line", line, "err", err)
+}
+
+func (s *server) enqueue(ts prompb.TimeSeries) {
+	select {
+	case s.queue <- ts:
+		queueLength.Inc()
+	default:
+		droppedSamples.Inc()
+	}
+}
+
+// send batches the queued series until the queue is closed.
+func (s *server) send() {
+	defer s.sender.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]prompb.TimeSeries, 0, s.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.store(batch)
+			queueLength.Sub(float64(len(batch)))
+			batch = make([]prompb.TimeSeries, 0, s.batchSize)
+		}
+	}
+	for {
+		select {
+		case ts, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ts)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// store sends a batch, retrying on recoverable errors with an exponential
+// backoff. Retries stop when the server is stopped.
+func (s *server) store(batch []prompb.TimeSeries) {
+	req := &prompb.WriteRequest{Timeseries: batch}
+	backoff := s.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.storage.Store(context.Background(), req)
+		if err == nil {
+			sentSamples.Add(float64(len(batch)))
+			return
+		}
+		if !remote.IsRecoverable(err) || attempt >= s.maxRetries {
+			s.logger.Warn("Error sending carbon samples", "num_samples", len(batch), "attempts", attempt+1, "err", err)
+			failedSamples.Add(float64(len(batch)))
+			return
+		}
+
+		retriedBatches.Inc()
+		s.logger.Debug("Retrying to send carbon samples", "num_samples", len(batch), "backoff", backoff, "err", err)
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			s.logger.Warn("Carbon listener stopped, dropping samples", "num_samples", len(batch), "err", err)
+			failedSamples.Add(float64(len(batch)))
+			return
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package carbon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStorage struct {
+	lock     sync.Mutex
+	failures int
+	requests int
+	series   []prompb.TimeSeries
+}
+
+func (s *fakeStorage) Store(_ context.Context, req *prompb.WriteRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests++
+	if s.failures > 0 {
+		s.failures--
+		return remote.RecoverableError{Err: errors.New("unavailable")}
+	}
+	s.series = append(s.series, req.Timeseries...)
+	return nil
+}
+
+func (s *fakeStorage) received() []prompb.TimeSeries {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.series
+}
+
+func testServer(t *testing.T, storage Storage) *server {
+	cfg := config.DefaultConfig
+	cfg.CarbonListener.BatchSize = 2
+	cfg.CarbonListener.FlushInterval = 10 * time.Millisecond
+	cfg.CarbonListener.RetryBackoff = time.Millisecond
+	s := newServer(&cfg, storage, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, s.start("127.0.0.1:0"))
+	return s
+}
+
+func TestServerTCPAndUDP(t *testing.T) {
+	storage := &fakeStorage{failures: 1}
+	s := testServer(t, storage)
+
+	conn, err := net.Dial("tcp", s.tcp.Addr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprint(conn, "a.b 1 1600000000\ninvalid\nc.d;host=x 2 1600000000\n")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	udp, err := net.Dial("udp", s.udp.LocalAddr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprint(udp, "e.f 3 1600000000\n")
+	require.NoError(t, err)
+	require.NoError(t, udp.Close())
+
+	assert.Eventually(t, func() bool { return len(storage.received()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	s.stop()
+
+	byName := make(map[string]prompb.TimeSeries)
+	for _, ts := range storage.received() {
+		byName[ts.Labels[0].Value] = ts
+	}
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "c_d"}, {Name: "host", Value: "x"}}, byName["c_d"].Labels)
+	assert.Equal(t, []prompb.Sample{{Value: 3, Timestamp: 1600000000000}}, byName["e_f"].Samples)
+	assert.Contains(t, byName, "a_b")
+	assert.Greater(t, storage.requests, 1)
+}
+
+func TestServerStopFlushes(t *testing.T) {
+	storage := &fakeStorage{}
+	s := testServer(t, storage)
+	s.handleLine("a.b 1 1600000000", "tcp")
+	s.stop()
+	assert.Len(t, storage.received(), 1)
+}
+
+func TestListenerRequiresRemoteWriteURL(t *testing.T) {
+	cfg := config.DefaultConfig
+	cfg.CarbonListener.ListenAddress = "127.0.0.1:0"
+	l := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Error(t, l.ApplyConfig(&cfg))
+
+	cfg.CarbonListener.RemoteWriteURL = "http://localhost/api/v1/write"
+	require.NoError(t, l.ApplyConfig(&cfg))
+	assert.NoError(t, l.ApplyConfig(&config.DefaultConfig))
+	l.Stop()
+}
@@ -0,0 +1,128 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package carbon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// mapper translates carbon paths to labels.
+type mapper struct {
+	prefix       string
+	labelMapping string
+	mappings     []*config.CarbonMapping
+}
+
+// labels returns the sorted labels of a carbon path. Mapping rules apply
+// first, to the name of the path without prefix. Otherwise tags are read
+// with the tag parser, and plain paths with the configured label mapping.
+func (m *mapper) labels(path string) ([]prompb.Label, error) {
+	name, tags, err := splitTags(path)
+	if err != nil {
+		return nil, err
+	}
+	if m.prefix != "" && !strings.HasPrefix(name, m.prefix) {
+		return nil, fmt.Errorf("path doesn't start with prefix %q", m.prefix)
+	}
+
+	var labels []prompb.Label
+	if mapped, ok := m.mapRules(strings.TrimPrefix(name, m.prefix)); ok {
+		for k, v := range tags {
+			if k != "name" {
+				mapped[model.LabelName(k)] = model.LabelValue(v)
+			}
+		}
+		labels = metricToLabels(mapped)
+	} else if tags != nil {
+		tags["name"] = name
+		if labels, err = paths.MetricLabelsFromTags(tags, m.prefix, paths.FormatCarbonTags); err != nil {
+			return nil, err
+		}
+		if m.labelMapping == config.CarbonLabelMappingName {
+			labels = escapeNames(labels)
+		}
+	} else if m.labelMapping == config.CarbonLabelMappingPath {
+		if labels, err = paths.MetricLabelsFromPath(name, m.prefix); err != nil {
+			return nil, err
+		}
+	} else {
+		labels = []prompb.Label{{Name: model.MetricNameLabel, Value: strings.TrimPrefix(name, m.prefix)}}
+		labels = escapeNames(labels)
+	}
+
+	for _, l := range labels {
+		if l.Value == "" {
+			return nil, fmt.Errorf("empty value for label %s", l.Name)
+		}
+	}
+	return sortLabels(labels), nil
+}
+
+// mapRules returns the metric of the first mapping rule matching name.
+func (m *mapper) mapRules(name string) (model.Metric, bool) {
+	for _, rule := range m.mappings {
+		submatches := rule.Regex.FindStringSubmatchIndex(name)
+		if submatches == nil {
+			continue
+		}
+		expand := func(tmpl string) string {
+			return string(rule.Regex.ExpandString(nil, tmpl, name, submatches))
+		}
+		metricName := model.EscapeName(expand(rule.Name), model.UnderscoreEscaping)
+		metric := model.Metric{model.MetricNameLabel: model.LabelValue(metricName)}
+		for ln, lv := range rule.Labels {
+			metric[model.LabelName(ln)] = model.LabelValue(expand(lv))
+		}
+		return metric, true
+	}
+	return nil, false
+}
+
+// escapeNames replaces the characters of the metric and label names which
+// are invalid in the legacy Prometheus charset by '_'.
+func escapeNames(labels []prompb.Label) []prompb.Label {
+	for i, l := range labels {
+		if l.Name == model.MetricNameLabel {
+			labels[i].Value = model.EscapeName(l.Value, model.UnderscoreEscaping)
+		} else {
+			labels[i].Name = model.EscapeName(l.Name, model.UnderscoreEscaping)
+		}
+	}
+	return labels
+}
+
+func metricToLabels(m model.Metric) []prompb.Label {
+	labels := make([]prompb.Label, 0, len(m))
+	for ln, lv := range m {
+		if lv == "" {
+			continue
+		}
+		labels = append(labels, prompb.Label{Name: string(ln), Value: string(lv)})
+	}
+	return labels
+}
+
+func sortLabels(labels []prompb.Label) []prompb.Label {
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
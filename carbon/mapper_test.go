@@ -0,0 +1,109 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package carbon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	path, sample, err := parseLine("servers.a.load 1.5 1600000000", now)
+	require.NoError(t, err)
+	assert.Equal(t, "servers.a.load", path)
+	assert.Equal(t, prompb.Sample{Value: 1.5, Timestamp: 1600000000000}, sample)
+
+	_, sample, err = parseLine("servers.a.load 2 -1", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.UnixMilli(), sample.Timestamp)
+
+	for _, line := range []string{"servers.a.load 1", "servers.a.load x 1600000000", "servers.a.load 1 y"} {
+		_, _, err = parseLine(line, now)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestMapperLabels(t *testing.T) {
+	var mappings []*config.CarbonMapping
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- match: servers.*.cpu.*
+  name: cpu_${2}
+  labels:
+    host: $1
+- match: 'app\.(\w+)\.requests'
+  match_type: regex
+  name: requests_total
+  labels:
+    app: $1
+`), &mappings))
+
+	tests := []struct {
+		path         string
+		labelMapping string
+		expected     []prompb.Label
+	}{
+		{
+			path:         "prefix.servers.a-1.cpu.idle",
+			labelMapping: config.CarbonLabelMappingName,
+			expected:     []prompb.Label{{Name: "__name__", Value: "cpu_idle"}, {Name: "host", Value: "a-1"}},
+		},
+		{
+			path:         "prefix.servers.a.cpu.user;dc=eu",
+			labelMapping: config.CarbonLabelMappingName,
+			expected:     []prompb.Label{{Name: "__name__", Value: "cpu_user"}, {Name: "dc", Value: "eu"}, {Name: "host", Value: "a"}},
+		},
+		{
+			path:         "prefix.app.web.requests",
+			labelMapping: config.CarbonLabelMappingName,
+			expected:     []prompb.Label{{Name: "__name__", Value: "requests_total"}, {Name: "app", Value: "web"}},
+		},
+		{
+			path:         "prefix.collectd.host-1.memory.used",
+			labelMapping: config.CarbonLabelMappingName,
+			expected:     []prompb.Label{{Name: "__name__", Value: "collectd_host_1_memory_used"}},
+		},
+		{
+			path:         "prefix.disk.used;host=a;mount=/",
+			labelMapping: config.CarbonLabelMappingName,
+			expected:     []prompb.Label{{Name: "__name__", Value: "disk_used"}, {Name: "host", Value: "a"}, {Name: "mount", Value: "/"}},
+		},
+		{
+			path:         "prefix.up.job.node.instance.localhost:9100",
+			labelMapping: config.CarbonLabelMappingPath,
+			expected:     []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "instance", Value: "localhost:9100"}, {Name: "job", Value: "node"}},
+		},
+	}
+	for _, test := range tests {
+		m := &mapper{prefix: "prefix.", labelMapping: test.labelMapping, mappings: mappings}
+		labels, err := m.labels(test.path)
+		require.NoError(t, err, test.path)
+		assert.Equal(t, test.expected, labels, test.path)
+	}
+
+	m := &mapper{prefix: "prefix.", labelMapping: config.CarbonLabelMappingPath}
+	for _, path := range []string{"other.up", "prefix.up.job", "prefix.up;job"} {
+		_, err := m.labels(path)
+		assert.Error(t, err, path)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package carbon
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// parseLine parses a carbon plaintext line: <path> <value> <timestamp>.
+// The timestamp is in seconds, a negative one means now.
+func parseLine(line string, now time.Time) (string, prompb.Sample, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return "", prompb.Sample{}, fmt.Errorf("expected <path> <value> <timestamp>, got %d fields", len(fields))
+	}
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return "", prompb.Sample{}, fmt.Errorf("invalid value %q: %w", fields[1], err)
+	}
+	ts, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return "", prompb.Sample{}, fmt.Errorf("invalid timestamp %q", fields[2])
+	}
+	tsMs := int64(ts * 1000)
+	if ts < 0 {
+		tsMs = now.UnixMilli()
+	}
+	return fields[0], prompb.Sample{Value: value, Timestamp: tsMs}, nil
+}
+
+// splitTags splits a tagged path <name>;<tag>=<value>... into its name and
+// tags. tags is nil for a plain path.
+func splitTags(path string) (string, map[string]string, error) {
+	parts := strings.Split(path, ";")
+	if len(parts) == 1 {
+		return path, nil, nil
+	}
+	tags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || k == "" || v == "" {
+			return "", nil, fmt.Errorf("invalid tag %q", part)
+		}
+		tags[k] = v
+	}
+	return parts[0], tags, nil
+}
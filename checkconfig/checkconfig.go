@@ -0,0 +1,225 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package checkconfig checks config files beyond their loading: it executes
+// the templates of the write rules against label sets, and finds the rules
+// which never match or are shadowed by earlier ones.
+package checkconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Severities of the problems.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Kinds of problems.
+const (
+	// KindLoad is a config file which doesn't load, like a template or a
+	// match_re which doesn't compile.
+	KindLoad = "load"
+	// KindTemplate is a template failing to execute.
+	KindTemplate = "template"
+	// KindEmptyPath is a template rendering an empty path.
+	KindEmptyPath = "empty_path"
+	// KindRejectedPath is a path rejected by on_missing_label: drop or by
+	// the path validation.
+	KindRejectedPath = "rejected_path"
+	// KindMissingLabel is a label used by a template, not set in a label
+	// set given on the command line which the rule matches.
+	KindMissingLabel = "missing_label"
+	// KindUntested is a rule whose template isn't executed, no label set
+	// matching it being found.
+	KindUntested = "untested"
+	// KindNeverMatches is a rule which no metric can match.
+	KindNeverMatches = "never_matches"
+	// KindShadowed is a rule which only matches metrics stopped by an
+	// earlier rule.
+	KindShadowed = "shadowed"
+)
+
+// Problem is a problem found in a config file. Rule is the index of the
+// write rule, and Labels the label set its template is executed with.
+type Problem struct {
+	Severity string       `json:"severity"`
+	Kind     string       `json:"kind"`
+	Rule     *int         `json:"rule,omitempty"`
+	Labels   model.Metric `json:"labels,omitempty"`
+	Message  string       `json:"message"`
+}
+
+// Report lists the problems of a config file. It is valid without errors.
+type Report struct {
+	File     string    `json:"file"`
+	Valid    bool      `json:"valid"`
+	Rules    int       `json:"rules"`
+	Problems []Problem `json:"problems"`
+}
+
+// Run checks the config files of the command line, and prints their
+// reports to out as JSON. It fails if a config file has errors, or
+// warnings too in strict mode.
+func Run(cfg *config.Config, out io.Writer, logger *slog.Logger) error {
+	opts := cfg.CheckConfig
+	files := opts.Files
+	if len(files) == 0 && cfg.ConfigFile != "" {
+		files = []string{cfg.ConfigFile}
+	}
+	if len(files) == 0 {
+		return errors.New("no config file to check")
+	}
+	labelSets := make([]model.Metric, 0, len(opts.Series))
+	for _, s := range opts.Series {
+		lset, err := parser.ParseMetric(s)
+		if err != nil {
+			return fmt.Errorf("invalid series %q: %w", s, err)
+		}
+		m := make(model.Metric, len(lset))
+		for _, l := range lset {
+			m[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		labelSets = append(labelSets, m)
+	}
+
+	reports := make([]*Report, 0, len(files))
+	failed := 0
+	for _, file := range files {
+		r := Check(file, labelSets, logger)
+		reports = append(reports, r)
+		if !r.Valid || (opts.Strict && len(r.Problems) > 0) {
+			failed++
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(reports); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d config files failed the check", failed)
+	}
+	return nil
+}
+
+// Check loads a config file and checks its write rules, executing their
+// templates with a label set built for each of them, and with the given
+// label sets they match.
+func Check(file string, labelSets []model.Metric, logger *slog.Logger) *Report {
+	r := &Report{File: file, Valid: true, Problems: []Problem{}}
+	cfg, err := config.LoadFile(logger, file)
+	if err != nil {
+		r.add(Problem{Severity: SeverityError, Kind: KindLoad, Message: err.Error()})
+		return r
+	}
+	g := &cfg.Graphite
+	rules := g.Write.Rules
+	r.Rules = len(rules)
+	prefix := g.DefaultPrefix
+	format := paths.FormatForPrefix(g, prefix)
+
+	for i, rule := range rules {
+		if reason := neverMatches(rule); reason != "" {
+			r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindNeverMatches, Message: reason})
+			continue
+		}
+		for j, earlier := range rules[:i] {
+			if !earlier.Continue && shadows(earlier, rule) {
+				r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindShadowed,
+					Message: fmt.Sprintf("every metric matching the rule is stopped by rule %d", j)})
+				break
+			}
+		}
+		if rule.Tmpl == (graphiteCfg.Template{}) {
+			// Dropping the metrics, or continuing without a path.
+			continue
+		}
+
+		executed := false
+		if m, ok := representativeLabels(rule); ok {
+			executed = r.execute(i, g, rules[i:i+1], m, format, prefix)
+		}
+		for _, m := range labelSets {
+			if g.NameEscaping == graphiteCfg.NameEscapingUnderscores {
+				m = paths.UnderscoreNames(m)
+			}
+			if !r.execute(i, g, rules[i:i+1], m, format, prefix) {
+				continue
+			}
+			executed = true
+			if rule.OnMissingLabel == graphiteCfg.OnMissingLabelDrop || rule.OnMissingLabel == graphiteCfg.OnMissingLabelDefault {
+				// The missing labels are handled by the rule.
+				continue
+			}
+			for _, ln := range rule.Labels() {
+				if _, ok := m[model.LabelName(ln)]; !ok {
+					r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindMissingLabel, Labels: m,
+						Message: fmt.Sprintf("the template uses label %s, which isn't set", ln)})
+				}
+			}
+		}
+		if !executed {
+			r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindUntested,
+				Message: "no label set matching the rule was found to execute its template"})
+		}
+	}
+	return r
+}
+
+// execute executes the template of a single rule with m, like the writes
+// do, and reports whether the rule matches m.
+func (r *Report) execute(i int, g *graphiteCfg.Config, rule []*graphiteCfg.Rule, m model.Metric, format paths.Format, prefix string) bool {
+	trace := paths.TraceMetric(m, format, prefix, rule, g.Write.TemplateData)
+	rt := trace.Rules[0]
+	switch {
+	case !rt.Matched:
+		return false
+	case rt.Error != "":
+		r.addRule(i, Problem{Severity: SeverityError, Kind: KindTemplate, Labels: m, Message: rt.Error})
+	case rt.Rejected != "":
+		r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindRejectedPath, Labels: m,
+			Message: "the rendered path is rejected: " + rt.Rejected})
+	case rt.Path == "":
+		r.addRule(i, Problem{Severity: SeverityWarning, Kind: KindEmptyPath, Labels: m,
+			Message: "the template renders an empty path"})
+	}
+	return true
+}
+
+func (r *Report) addRule(i int, p Problem) {
+	p.Rule = &i
+	r.add(p)
+}
+
+func (r *Report) add(p Problem) {
+	r.Problems = append(r.Problems, p)
+	if p.Severity == SeverityError {
+		r.Valid = false
+	}
+}
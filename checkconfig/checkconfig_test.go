@@ -0,0 +1,178 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package checkconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o644))
+	return filename
+}
+
+// kinds returns the kinds of the problems of each rule.
+func kinds(r *Report) map[int][]string {
+	k := map[int][]string{}
+	for _, p := range r.Problems {
+		rule := -1
+		if p.Rule != nil {
+			rule = *p.Rule
+		}
+		k[rule] = append(k[rule], p.Kind)
+	}
+	return k
+}
+
+func TestCheck(t *testing.T) {
+	file := writeConfig(t, `
+graphite:
+  write:
+    rules:
+      - match:
+          job: node
+        match_re:
+          instance: 'host-[0-9]+:\d+'
+        template: 'nodes.{{ replace .labels.instance ":" "_" }}.{{ .labels.__name__ }}'
+        continue: true
+      - match:
+          job: api
+        template: '{{ replace (split .labels.path "/") "/" "." }}'
+      - match:
+          job: api
+          code: "500"
+        template: 'errors.{{ .labels.path }}'
+      - match:
+          job: db
+        match_re:
+          job: 'cache|queue'
+        template: 'db.{{ .labels.instance }}'
+      - match_re:
+          instance: '(?:a|b)\b(?:c)'
+        template: 'odd.{{ .labels.instance }}'
+      - match:
+          job: empty
+        template: '{{ replace .labels.nothing "value" "" }}'
+      - match:
+          job: blackhole
+`)
+	r := Check(file, nil, slog.New(slog.DiscardHandler))
+	assert.False(t, r.Valid)
+	assert.Equal(t, 7, r.Rules)
+	assert.Equal(t, map[int][]string{
+		1: {KindTemplate},
+		2: {KindShadowed},
+		3: {KindNeverMatches},
+		4: {KindUntested},
+		5: {KindEmptyPath},
+	}, kinds(r))
+	assert.Equal(t, model.Metric{"__name__": "metric", "job": "api", "path": "value"}, r.Problems[0].Labels)
+	assert.Contains(t, r.Problems[0].Message, "error calling replace")
+	assert.Equal(t, "every metric matching the rule is stopped by rule 1", r.Problems[1].Message)
+}
+
+func TestCheckLabelSets(t *testing.T) {
+	file := writeConfig(t, `
+graphite:
+  write:
+    rules:
+      - match:
+          job: node
+        template: 'nodes.{{ .labels.instance }}.{{ .labels.__name__ }}'
+`)
+	labelSets := []model.Metric{
+		{"__name__": "up", "job": "node", "instance": "a"},
+		{"__name__": "up", "job": "node"},
+		{"__name__": "up", "job": "api"},
+	}
+	r := Check(file, labelSets, slog.New(slog.DiscardHandler))
+	assert.True(t, r.Valid)
+	require.Len(t, r.Problems, 1)
+	assert.Equal(t, KindMissingLabel, r.Problems[0].Kind)
+	assert.Equal(t, labelSets[1], r.Problems[0].Labels)
+}
+
+func TestCheckRejectedPaths(t *testing.T) {
+	file := writeConfig(t, `
+graphite:
+  write:
+    path_validation:
+      action: reject
+    rules:
+      - match:
+          job: node
+        template: 'nodes.{{ .labels.instance }}'
+        on_missing_label: drop
+      - match:
+          job: api
+        template: 'api.{{ .labels.path }}'
+`)
+	labelSets := []model.Metric{
+		{"__name__": "up", "job": "node"},
+		{"__name__": "up", "job": "api", "path": "/users"},
+	}
+	r := Check(file, labelSets, slog.New(slog.DiscardHandler))
+	assert.True(t, r.Valid)
+	assert.Equal(t, map[int][]string{0: {KindRejectedPath}, 1: {KindRejectedPath}}, kinds(r))
+	assert.Equal(t, "the rendered path is rejected: missing_label", r.Problems[0].Message)
+	assert.Equal(t, "the rendered path is rejected: illegal_character", r.Problems[1].Message)
+}
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.CheckConfig.Files = []string{
+		writeConfig(t, "graphite:\n  write:\n    rules:\n      - match: {job: node}\n        template: '{{ .labels.job'\n"),
+		writeConfig(t, "graphite:\n  write:\n    rules:\n      - match_re: {job: '['}\n"),
+		writeConfig(t, "graphite:\n  write:\n    rules:\n      - template: 'all'\n      - template: 'never'\n"),
+	}
+	var out bytes.Buffer
+	assert.EqualError(t, Run(cfg, &out, slog.New(slog.DiscardHandler)), "2 config files failed the check")
+
+	var reports []Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &reports))
+	require.Len(t, reports, 3)
+	for i, kinds := range [][]string{{KindLoad}, {KindLoad}, {KindShadowed}} {
+		require.Len(t, reports[i].Problems, len(kinds))
+		assert.Equal(t, kinds[0], reports[i].Problems[0].Kind)
+	}
+	assert.True(t, reports[2].Valid)
+
+	cfg.CheckConfig.Files = cfg.CheckConfig.Files[2:]
+	assert.NoError(t, Run(cfg, &out, slog.New(slog.DiscardHandler)))
+	cfg.CheckConfig.Strict = true
+	assert.Error(t, Run(cfg, &out, slog.New(slog.DiscardHandler)))
+}
+
+func TestExample(t *testing.T) {
+	for _, expr := range []string{"^(?:host-[0-9]+:\\d+)$", "^(?:a|b)$", "^(?:x{3}[^a-c]?)$", "^(?:[A-Z]+)$", "^(?:)$"} {
+		s, ok := example(expr)
+		require.True(t, ok, expr)
+		assert.Regexp(t, expr, s)
+	}
+	_, ok := example("^(?:[^\\x00-\\x{10FFFF}])$")
+	assert.False(t, ok)
+}
@@ -0,0 +1,164 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package checkconfig
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/common/model"
+)
+
+// placeholderValue is the value of the labels of the representative label
+// sets which the rules don't constrain.
+const placeholderValue = "value"
+
+// matchAll is a compiled match_re matching any value.
+const matchAll = "^(?:.*)$"
+
+// neverMatches tells why no metric can match a rule, or returns an empty
+// string if some may.
+func neverMatches(rule *graphiteCfg.Rule) string {
+	for ln, re := range rule.MatchRE {
+		if lv, ok := rule.Match[ln]; ok && !re.MatchString(string(lv)) {
+			return fmt.Sprintf("label %s must be %q and match %s", ln, lv, re.String())
+		}
+		if r, err := syntax.Parse(re.String(), syntax.Perl); err == nil && r.Simplify().Op == syntax.OpNoMatch {
+			return fmt.Sprintf("label %s must match %s, which matches nothing", ln, re.String())
+		}
+	}
+	return ""
+}
+
+// shadows reports whether every metric matching rule also matches earlier,
+// judging from their matchers only. It may miss some shadowed rules, but
+// doesn't report rules which aren't.
+func shadows(earlier, rule *graphiteCfg.Rule) bool {
+	for ln, lv := range earlier.Match {
+		if v, ok := rule.Match[ln]; !ok || v != lv {
+			return false
+		}
+	}
+	for ln, re := range earlier.MatchRE {
+		if lv, ok := rule.Match[ln]; ok && re.MatchString(string(lv)) {
+			continue
+		}
+		if other, ok := rule.MatchRE[ln]; ok && other.String() == re.String() {
+			continue
+		}
+		if re.String() == matchAll {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// representativeLabels returns a label set matching rule, with the labels
+// its template uses, or false if none is found.
+func representativeLabels(rule *graphiteCfg.Rule) (model.Metric, bool) {
+	m := model.Metric{model.MetricNameLabel: "metric"}
+	for _, ln := range rule.Labels() {
+		m[model.LabelName(ln)] = placeholderValue
+	}
+	for ln, re := range rule.MatchRE {
+		v, ok := example(re.String())
+		if !ok || !re.MatchString(v) {
+			return nil, false
+		}
+		m[ln] = model.LabelValue(v)
+	}
+	for ln, lv := range rule.Match {
+		m[ln] = lv
+	}
+	// The empty value stands for an absent label.
+	for ln, lv := range m {
+		if lv == "" {
+			delete(m, ln)
+		}
+	}
+	return m, true
+}
+
+// example returns a string matching the regular expression, or false if
+// none is found. The caller checks it matches, as anchors in the middle of
+// the expression aren't taken into account.
+func example(expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	if !writeExample(&b, re.Simplify()) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func writeExample(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		b.WriteRune(classExample(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('x')
+	case syntax.OpCapture, syntax.OpPlus:
+		return writeExample(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for range re.Min {
+			if !writeExample(b, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeExample(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			var alt strings.Builder
+			if writeExample(&alt, sub) {
+				b.WriteString(alt.String())
+				return true
+			}
+		}
+		return false
+	}
+	// Empty matches, anchors, stars and quests need no character.
+	return true
+}
+
+// classExample picks a readable rune of a character class, given as ranges.
+func classExample(ranges []rune) rune {
+	for _, r := range []rune{'a', '0', '_', '-'} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return r
+			}
+		}
+	}
+	return ranges[0]
+}
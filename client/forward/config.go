@@ -0,0 +1,139 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package forward
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
+	"github.com/prometheus/prometheus/pkg/relabel"
+)
+
+// DefaultConfig is the default configuration of a remote_write client.
+var DefaultConfig = Config{
+	Timeout: 30 * time.Second,
+	QueueConfig: QueueConfig{
+		Capacity:          10000,
+		Shards:            4,
+		MaxSamplesPerSend: 500,
+		BatchSendDeadline: 5 * time.Second,
+		MaxRetries:        10,
+		MinBackoff:        30 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+	},
+}
+
+// Config is the settings of a remote_write client, named like the ones of
+// the remote_write section of Prometheus.
+type Config struct {
+	URL     string        `yaml:"url" json:"url"`
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// BasicAuth and the bearer token are exclusive.
+	BasicAuth       *BasicAuth `yaml:"basic_auth,omitempty" json:"basic_auth,omitempty"`
+	BearerToken     string     `yaml:"bearer_token,omitempty" json:"-"`
+	BearerTokenFile string     `yaml:"bearer_token_file,omitempty" json:"bearer_token_file,omitempty"`
+	// WriteRelabelConfigs are applied to the series before they are queued,
+	// the dropped series aren't forwarded.
+	WriteRelabelConfigs []*relabel.Config `yaml:"write_relabel_configs,omitempty" json:"write_relabel_configs,omitempty"`
+	QueueConfig         QueueConfig       `yaml:"queue_config,omitempty" json:"queue_config,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+	type plain Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if u, err := url.Parse(c.URL); err != nil || u.Host == "" {
+		return fmt.Errorf("invalid remote write URL %q", c.URL)
+	}
+	if c.BearerToken != "" && c.BearerTokenFile != "" {
+		return fmt.Errorf("at most one of bearer_token and bearer_token_file must be set")
+	}
+	if c.BasicAuth != nil && (c.BearerToken != "" || c.BearerTokenFile != "") {
+		return fmt.Errorf("at most one of basic_auth and a bearer token must be set")
+	}
+
+	return utils.CheckOverflow(c.XXX, "remote write config")
+}
+
+// BasicAuth are the credentials of the HTTP basic authentication.
+type BasicAuth struct {
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password,omitempty" json:"-"`
+	// PasswordFile is read on each request, to follow its rotation.
+	PasswordFile string `yaml:"password_file,omitempty" json:"password_file,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (a *BasicAuth) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BasicAuth
+	if err := unmarshal((*plain)(a)); err != nil {
+		return err
+	}
+	if a.Password != "" && a.PasswordFile != "" {
+		return fmt.Errorf("at most one of basic_auth password and password_file must be set")
+	}
+
+	return utils.CheckOverflow(a.XXX, "basic auth")
+}
+
+// QueueConfig configures the queue of the forwarded samples. Series are
+// spread over the shards by the hash of their labels, so the samples of a
+// series are sent in order.
+type QueueConfig struct {
+	// Capacity is the number of samples queued per shard, the samples
+	// written to a full shard are dropped.
+	Capacity          int           `yaml:"capacity,omitempty" json:"capacity,omitempty"`
+	Shards            int           `yaml:"shards,omitempty" json:"shards,omitempty"`
+	MaxSamplesPerSend int           `yaml:"max_samples_per_send,omitempty" json:"max_samples_per_send,omitempty"`
+	BatchSendDeadline time.Duration `yaml:"batch_send_deadline,omitempty" json:"batch_send_deadline,omitempty"`
+	// MaxRetries bounds the retries of the requests failing with a
+	// recoverable error, with an exponential backoff from MinBackoff to
+	// MaxBackoff.
+	MaxRetries int           `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	MinBackoff time.Duration `yaml:"min_backoff,omitempty" json:"min_backoff,omitempty"`
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty" json:"max_backoff,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (q *QueueConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*q = DefaultConfig.QueueConfig
+	type plain QueueConfig
+	if err := unmarshal((*plain)(q)); err != nil {
+		return err
+	}
+	if q.Capacity <= 0 || q.Shards <= 0 || q.MaxSamplesPerSend <= 0 || q.BatchSendDeadline <= 0 {
+		return fmt.Errorf("queue capacity, shards, max_samples_per_send and batch_send_deadline must be positive")
+	}
+	if q.MinBackoff > q.MaxBackoff {
+		return fmt.Errorf("queue min_backoff %s greater than max_backoff %s", q.MinBackoff, q.MaxBackoff)
+	}
+
+	return utils.CheckOverflow(q.XXX, "queue config")
+}
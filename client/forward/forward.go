@@ -0,0 +1,363 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package forward forwards the written samples to a Prometheus remote write
+// endpoint, like Mimir, VictoriaMetrics or Thanos Receive, in addition to
+// Graphite.
+package forward
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const (
+	namespace = "remote_adapter"
+	subsystem = "forward"
+)
+
+var (
+	sentSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "sent_samples_total",
+			Help:      "Total number of samples forwarded to the remote write endpoint.",
+		},
+		[]string{"remote"},
+	)
+	failedSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "failed_samples_total",
+			Help:      "Total number of samples which could not be forwarded to the remote write endpoint.",
+		},
+		[]string{"remote"},
+	)
+	droppedSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dropped_samples_total",
+			Help:      "Total number of samples dropped because the queue of their shard was full.",
+		},
+		[]string{"remote"},
+	)
+	retriedBatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "retried_batches_total",
+			Help:      "Total number of retried remote write requests.",
+		},
+		[]string{"remote"},
+	)
+	queueLength = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "queue_length",
+			Help:      "Number of samples waiting to be forwarded to the remote write endpoint.",
+		},
+		[]string{"remote"},
+	)
+)
+
+func init() {
+	client.Register("remote_write", newSettings, newFromSettings)
+}
+
+// Storage stores batches of series.
+type Storage interface {
+	Store(ctx context.Context, req *prompb.WriteRequest) error
+}
+
+// Client queues the written samples, and sends them in batches to a remote
+// write endpoint from its shards.
+type Client struct {
+	cfg     *Config
+	logger  *slog.Logger
+	storage Storage
+
+	// lock guards the shards against writes once closed.
+	lock   sync.RWMutex
+	closed bool
+	shards []chan prompb.TimeSeries
+	// done is closed on shutdown, after which a shard failing to send a batch
+	// drops the rest of its samples.
+	done    chan struct{}
+	senders sync.WaitGroup
+}
+
+// newSettings returns the settings of the entries of the clients list.
+func newSettings() interface{} {
+	c := DefaultConfig
+	return &c
+}
+
+// newFromSettings builds a Client from the settings of an entry of the
+// clients list.
+func newFromSettings(settings client.Settings, _ *config.Config, logger *slog.Logger) (client.Writer, client.Reader, error) {
+	c := DefaultConfig
+	if err := settings.Decode(&c); err != nil {
+		return nil, nil, err
+	}
+	rt := &authRoundTripper{cfg: &c, next: http.DefaultTransport}
+	return NewClient(&c, remote.NewWriteClientWithTransport(c.URL, c.Timeout, rt), logger), nil, nil
+}
+
+// NewClient returns a new Client sending the samples to storage, and starts
+// its shards.
+func NewClient(cfg *Config, storage Storage, logger *slog.Logger) *Client {
+	c := &Client{
+		cfg:     cfg,
+		logger:  logger,
+		storage: storage,
+		shards:  make([]chan prompb.TimeSeries, cfg.QueueConfig.Shards),
+		done:    make(chan struct{}),
+	}
+	for i := range c.shards {
+		c.shards[i] = make(chan prompb.TimeSeries, cfg.QueueConfig.Capacity)
+		c.senders.Add(1)
+		go c.send(c.shards[i])
+	}
+	return c
+}
+
+// Name implements the client.Client interface.
+func (c *Client) Name() string {
+	return "remote_write"
+}
+
+// Target implements the client.Client interface.
+func (c *Client) Target() string {
+	return c.cfg.URL
+}
+
+// String implements the client.Client interface. The settings are left out,
+// as they may hold credentials.
+func (c *Client) String() string {
+	return c.cfg.URL
+}
+
+// Shutdown implements the client.Client interface. The queued samples are
+// sent with their retries, until a batch of a shard fails after them and the
+// rest of the shard is dropped.
+func (c *Client) Shutdown() {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return
+	}
+	c.closed = true
+	close(c.done)
+	for _, shard := range c.shards {
+		close(shard)
+	}
+	c.lock.Unlock()
+	c.senders.Wait()
+}
+
+// Write implements the client.Writer interface. The samples are relabeled
+// and queued, the ones of full shards are dropped and counted. The write fails
+// with a recoverable error when none could be queued, so that it is retried,
+// and only the other drops are lossy. A dry run returns the relabeled series
+// instead.
+func (c *Client) Write(samples model.Samples, _ int, _ *http.Request, dryRun bool) ([]byte, error) {
+	var out bytes.Buffer
+	queued, dropped := 0, 0
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.closed && !dryRun {
+		return nil, fmt.Errorf("client of %s is shut down", c.cfg.URL)
+	}
+	for _, s := range samples {
+		ls := relabel.Process(metricLabels(s.Metric), c.cfg.WriteRelabelConfigs...)
+		if len(ls) == 0 {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(&out, "%s %s %d\n", ls, s.Value, s.Timestamp)
+			continue
+		}
+
+		ts := prompb.TimeSeries{Samples: []prompb.Sample{{Value: float64(s.Value), Timestamp: int64(s.Timestamp)}}}
+		for _, l := range ls {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		}
+		select {
+		case c.shards[ls.Hash()%uint64(len(c.shards))] <- ts:
+			queueLength.WithLabelValues(c.cfg.URL).Inc()
+			queued++
+		default:
+			dropped++
+		}
+	}
+	if dryRun {
+		return out.Bytes(), nil
+	}
+	if dropped > 0 {
+		droppedSamples.WithLabelValues(c.cfg.URL).Add(float64(dropped))
+		if queued == 0 {
+			return nil, remote.RecoverableError{Err: fmt.Errorf("queue full, dropped %d samples", dropped)}
+		}
+		return []byte(fmt.Sprintf("Queued, dropped %d samples, queue full.", dropped)), nil
+	}
+	return []byte("Queued."), nil
+}
+
+func metricLabels(m model.Metric) labels.Labels {
+	ls := make(labels.Labels, 0, len(m))
+	for ln, lv := range m {
+		ls = append(ls, labels.Label{Name: string(ln), Value: string(lv)})
+	}
+	return labels.New(ls...)
+}
+
+// send batches the series of a shard until it is closed.
+func (c *Client) send(shard chan prompb.TimeSeries) {
+	defer c.senders.Done()
+	ticker := time.NewTicker(c.cfg.QueueConfig.BatchSendDeadline)
+	defer ticker.Stop()
+
+	maxSamples := c.cfg.QueueConfig.MaxSamplesPerSend
+	batch := make([]prompb.TimeSeries, 0, maxSamples)
+	// Once shut down, a batch failing after its retries drops the rest of the
+	// shard rather than retrying each of its batches in turn.
+	dropping := false
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		switch {
+		case dropping:
+			failedSamples.WithLabelValues(c.cfg.URL).Add(float64(len(batch)))
+		case !c.store(batch) && c.isDone():
+			c.logger.Warn("Client shut down, dropping the queued samples", "url", c.cfg.URL)
+			dropping = true
+		}
+		queueLength.WithLabelValues(c.cfg.URL).Sub(float64(len(batch)))
+		batch = make([]prompb.TimeSeries, 0, maxSamples)
+	}
+	for {
+		select {
+		case ts, ok := <-shard:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ts)
+			if len(batch) >= maxSamples {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// isDone reports whether the client is shut down.
+func (c *Client) isDone() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// store sends a batch, retrying on recoverable errors with an exponential
+// backoff. It returns whether the batch was sent.
+func (c *Client) store(batch []prompb.TimeSeries) bool {
+	req := &prompb.WriteRequest{Timeseries: batch}
+	backoff := c.cfg.QueueConfig.MinBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.storage.Store(context.Background(), req)
+		if err == nil {
+			sentSamples.WithLabelValues(c.cfg.URL).Add(float64(len(batch)))
+			return true
+		}
+		if !remote.IsRecoverable(err) || attempt >= c.cfg.QueueConfig.MaxRetries {
+			c.logger.Warn("Error forwarding samples", "url", c.cfg.URL, "num_samples", len(batch), "attempts", attempt+1, "err", err)
+			failedSamples.WithLabelValues(c.cfg.URL).Add(float64(len(batch)))
+			return false
+		}
+
+		retriedBatches.WithLabelValues(c.cfg.URL).Inc()
+		c.logger.Debug("Retrying to forward samples", "url", c.cfg.URL, "num_samples", len(batch), "backoff", backoff, "err", err)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, c.cfg.QueueConfig.MaxBackoff)
+	}
+}
+
+// authRoundTripper authenticates the requests with the basic auth or the
+// bearer token of the configuration. Secret files are read on each request.
+type authRoundTripper struct {
+	cfg  *Config
+	next http.RoundTripper
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := rt.cfg
+	switch {
+	case cfg.BasicAuth != nil:
+		password, err := secret(cfg.BasicAuth.Password, cfg.BasicAuth.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(cfg.BasicAuth.Username, password)
+	case cfg.BearerToken != "" || cfg.BearerTokenFile != "":
+		token, err := secret(cfg.BearerToken, cfg.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// secret returns value, or the content of file if set.
+func secret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package forward
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	promremote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var testSamples = model.Samples{
+	{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 1, Timestamp: 1700000000000},
+	{Metric: model.Metric{"__name__": "up", "job": "api"}, Value: 0, Timestamp: 1700000000000},
+	{Metric: model.Metric{"__name__": "go_goroutines", "job": "api"}, Value: 42, Timestamp: 1700000000000},
+}
+
+func settings(t *testing.T, s string) *yaml.Node {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(s), &node))
+	return node.Content[0]
+}
+
+func TestClientForwards(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	var lock sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "adapter" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		req, err := promremote.DecodeWriteRequest(r.Body)
+		require.NoError(t, err)
+		lock.Lock()
+		defer lock.Unlock()
+		for _, ts := range req.Timeseries {
+			received = append(received, ts.String())
+		}
+	}))
+	defer server.Close()
+
+	w, r, err := newFromSettings(settings(t, `
+url: `+server.URL+`
+basic_auth:
+  username: adapter
+  password_file: `+passwordFile+`
+write_relabel_configs:
+  - source_labels: [__name__]
+    regex: go_.*
+    action: drop
+  - target_label: cluster
+    replacement: eu
+queue_config:
+  shards: 2
+`), &config.DefaultConfig, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	msg, err := w.Write(testSamples, 0, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, "{__name__=\"up\", cluster=\"eu\", job=\"node\"} 1 1700000000000\n"+
+		"{__name__=\"up\", cluster=\"eu\", job=\"api\"} 0 1700000000000\n", string(msg))
+
+	_, err = w.Write(testSamples, 0, nil, false)
+	require.NoError(t, err)
+	// The queued samples are sent on shutdown.
+	w.Shutdown()
+	_, err = w.Write(testSamples, 0, nil, false)
+	assert.Error(t, err)
+
+	sort.Strings(received)
+	assert.Equal(t, []string{
+		(&prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "cluster", Value: "eu"}, {Name: "job", Value: "api"}},
+			Samples: []prompb.Sample{{Value: 0, Timestamp: 1700000000000}},
+		}).String(),
+		(&prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "cluster", Value: "eu"}, {Name: "job", Value: "node"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+		}).String(),
+	}, received)
+}
+
+type fakeStorage struct {
+	lock  sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (s *fakeStorage) Calls() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.calls
+}
+
+func (s *fakeStorage) Store(_ context.Context, _ *prompb.WriteRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+func TestClientRetries(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.QueueConfig.Shards = 1
+	cfg.QueueConfig.MaxRetries = 2
+	cfg.QueueConfig.MinBackoff = 0
+	cfg.QueueConfig.BatchSendDeadline = 10 * time.Millisecond
+
+	for _, tc := range []struct {
+		name  string
+		errs  []error
+		calls int
+	}{
+		{"recoverable", []error{remote.RecoverableError{Err: errors.New("503")}}, 2},
+		{"unrecoverable", []error{errors.New("400")}, 1},
+		{"too many retries", []error{
+			remote.RecoverableError{Err: errors.New("503")},
+			remote.RecoverableError{Err: errors.New("503")},
+			remote.RecoverableError{Err: errors.New("503")},
+		}, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			storage := &fakeStorage{errs: tc.errs}
+			c := NewClient(&cfg, storage, slog.New(slog.DiscardHandler))
+			_, err := c.Write(testSamples, 0, nil, false)
+			require.NoError(t, err)
+			// Shutdown waits for the retries of the queued samples.
+			c.Shutdown()
+			assert.Equal(t, tc.calls, storage.Calls())
+		})
+	}
+}
+
+func TestClientDropsWhenFull(t *testing.T) {
+	cfg := DefaultConfig
+	// Without senders, the only shard fills up.
+	c := &Client{cfg: &cfg, shards: []chan prompb.TimeSeries{make(chan prompb.TimeSeries, 2)}}
+	before := testutil.ToFloat64(droppedSamples.WithLabelValues(cfg.URL))
+	msg, err := c.Write(testSamples, 0, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Queued, dropped 1 samples, queue full.", string(msg))
+	assert.Len(t, c.shards[0], 2)
+	assert.Equal(t, before+1, testutil.ToFloat64(droppedSamples.WithLabelValues(cfg.URL)))
+
+	// Nothing could be queued, the write is to be retried.
+	_, err = c.Write(testSamples, 0, nil, false)
+	assert.True(t, remote.IsRecoverable(err))
+	assert.Equal(t, before+4, testutil.ToFloat64(droppedSamples.WithLabelValues(cfg.URL)))
+}
+
+func TestClientShutdownDropsAfterFailedBatch(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.QueueConfig.MaxSamplesPerSend = 1
+	cfg.QueueConfig.MaxRetries = 0
+	storage := &fakeStorage{errs: []error{remote.RecoverableError{Err: errors.New("503")}}}
+	// The shard is sent by the test once shut down.
+	shard := make(chan prompb.TimeSeries, len(testSamples))
+	c := &Client{cfg: &cfg, logger: slog.New(slog.DiscardHandler), storage: storage,
+		shards: []chan prompb.TimeSeries{shard}, done: make(chan struct{})}
+	_, err := c.Write(testSamples, 0, nil, false)
+	require.NoError(t, err)
+
+	before := testutil.ToFloat64(failedSamples.WithLabelValues(cfg.URL))
+	close(c.done)
+	close(shard)
+	c.senders.Add(1)
+	c.send(shard)
+	assert.Equal(t, 1, storage.Calls())
+	assert.Equal(t, before+3, testutil.ToFloat64(failedSamples.WithLabelValues(cfg.URL)))
+}
+
+func TestConfigAuth(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte("url: http://mimir/api/v1/push\nbearer_token: token"), &cfg))
+	assert.Equal(t, DefaultConfig.QueueConfig, cfg.QueueConfig)
+
+	for _, s := range []string{
+		"url: mimir",
+		"url: http://mimir\nbearer_token: a\nbearer_token_file: b",
+		"url: http://mimir\nbearer_token: a\nbasic_auth: {username: a}",
+		"url: http://mimir\nqueue_config: {shards: 0}",
+	} {
+		assert.Error(t, yaml.Unmarshal([]byte(s), &cfg), s)
+	}
+}
@@ -18,14 +18,18 @@
 package graphite
 
 import (
+	"errors"
 	"net"
 	"sync"
 	"time"
 
 	"log/slog"
 
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
 	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/index"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/tagdb"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -36,6 +40,10 @@ const (
 	maxFetchWorkers = 10
 )
 
+func init() {
+	client.Register("graphite", newSettings, newFromSettings)
+}
+
 // Client allows sending batches of Prometheus samples to Graphite.
 type Client struct {
 	//lock           sync.RWMutex
@@ -45,6 +53,11 @@ type Client struct {
 	readDelay      time.Duration
 	ignoredSamples prometheus.Counter
 	format         paths.Format
+	// index is nil if the index is disabled, see seriesIndex.
+	index      *index.Index
+	indexOnce  sync.Once
+	pathsCache *paths.Cache
+	tags       *tagdb.Registrar
 
 	carbonCon               net.Conn
 	carbonLastReconnectTime time.Time
@@ -58,12 +71,15 @@ func NewClient(cfg *config.Config, logger *slog.Logger) *Client {
 	if cfg.Graphite.Write.CarbonAddress == "" && cfg.Graphite.Read.URL == "" {
 		return nil
 	}
-	if cfg.Graphite.Write.EnablePathsCache {
-		paths.InitPathsCache(cfg.Graphite.Write.PathsCacheTTL,
-			cfg.Graphite.Write.PathsCachePurgeInterval)
+	// Each client has its own cache, so the paths of a reloaded
+	// configuration are rendered again.
+	pathsCache := paths.NewCacheFromConfig(&cfg.Graphite.Write)
+	if pathsCache != nil {
 		logger.Debug("Paths cache initialized",
 			"PathsCacheTTL", cfg.Graphite.Write.PathsCacheTTL,
-			"PathsCachePurgeInterval", cfg.Graphite.Write.PathsCachePurgeInterval)
+			"PathsCachePurgeInterval", cfg.Graphite.Write.PathsCachePurgeInterval,
+			"PathsCacheMaxEntries", cfg.Graphite.Write.PathsCacheMaxEntries,
+			"PathsCacheMaxBytes", cfg.Graphite.Write.PathsCacheMaxBytes)
 	}
 
 	// Which format are we using to write points?
@@ -76,9 +92,26 @@ func NewClient(cfg *config.Config, logger *slog.Logger) *Client {
 		}
 	}
 
+	// Only the paths of the carbon tags format are registered, see
+	// registerTags.
+	var tags *tagdb.Registrar
+	if cfg.Graphite.TagRegistration.Enabled && format == paths.FormatCarbonTags {
+		tagsURL := cfg.Graphite.TagRegistration.URL
+		if tagsURL == "" {
+			tagsURL = cfg.Graphite.Read.URL
+		}
+		if tagsURL == "" {
+			logger.Error("No graphite-web URL to register tagged paths with, tag registration disabled")
+		} else {
+			tags = tagdb.New(tagsURL, cfg.Graphite.TagRegistration, cfg.Write.Timeout, logger)
+		}
+	}
+
 	return &Client{
 		logger:       logger,
 		cfg:          &cfg.Graphite,
+		pathsCache:   pathsCache,
+		tags:         tags,
 		writeTimeout: cfg.Write.Timeout,
 		format:       format,
 		readTimeout:  cfg.Read.Timeout,
@@ -96,6 +129,38 @@ func NewClient(cfg *config.Config, logger *slog.Logger) *Client {
 	}
 }
 
+// newSettings returns the settings of the entries of the clients list, a
+// graphite block.
+func newSettings() interface{} {
+	c := graphiteCfg.DefaultConfig
+	return &c
+}
+
+// newFromSettings builds a Client from the settings of an entry of the
+// clients list, which are a graphite block. It writes if a carbon address
+// is set, and reads if a graphite-web URL is set.
+func newFromSettings(settings client.Settings, cfg *config.Config, logger *slog.Logger) (client.Writer, client.Reader, error) {
+	entryCfg := *cfg
+	entryCfg.Graphite = graphiteCfg.DefaultConfig
+	if err := settings.Decode(&entryCfg.Graphite); err != nil {
+		return nil, nil, err
+	}
+	c := NewClient(&entryCfg, logger)
+	if c == nil {
+		return nil, nil, errors.New("a carbon address or a graphite-web URL is required")
+	}
+
+	var w client.Writer
+	var r client.Reader
+	if entryCfg.Graphite.Write.CarbonAddress != "" {
+		w = c
+	}
+	if entryCfg.Graphite.Read.URL != "" {
+		r = c
+	}
+	return w, r, nil
+}
+
 // NewClient returns a new Client.
 func NewClientGraphiteCfg(cfg *graphiteCfg.Config, logger *slog.Logger) *Client {
 	return &Client{
@@ -104,11 +169,53 @@ func NewClientGraphiteCfg(cfg *graphiteCfg.Config, logger *slog.Logger) *Client
 	}
 }
 
+// seriesIndex returns the series index, or nil if it is disabled. It is
+// loaded on first use rather than by NewClient: on a reload, the new client
+// is built before the one it replaces saves the index on shutdown.
+func (client *Client) seriesIndex() *index.Index {
+	client.indexOnce.Do(func() {
+		// The index only serves the paths read back with /metrics/expand.
+		if client.index != nil || client.cfg == nil || !client.cfg.Index.Enabled || client.format == paths.FormatCarbonTags {
+			return
+		}
+		cfg := client.cfg.Index
+		idx, err := index.New(cfg.Path, cfg.Retention, cfg.FlushInterval, client.logger)
+		if err != nil {
+			client.logger.Error("Error loading series index, reads will expand paths", "file", cfg.Path, "err", err)
+			return
+		}
+		client.index = idx
+	})
+	return client.index
+}
+
+// formatForPrefix returns the format of the paths written with a storage prefix.
+func (client *Client) formatForPrefix(prefix string) paths.Format {
+	if client.format == paths.FormatCarbonTags && client.cfg != nil &&
+		client.cfg.TagEscapingForPrefix(prefix) == graphiteCfg.TagEscapingPercent {
+		return paths.FormatCarbonTagsPercent
+	}
+	return client.format
+}
+
 // Shutdown the client.
 func (client *Client) Shutdown() {
 	client.carbonConLock.Lock()
 	defer client.carbonConLock.Unlock()
 	client.disconnectFromCarbon()
+	if client.pathsCache != nil {
+		client.pathsCache.Close()
+	}
+	if client.tags != nil {
+		client.tags.Close()
+	}
+	// An index not loaded yet is left to the client replacing this one.
+	client.indexOnce.Do(func() {})
+	if client.index != nil {
+		if err := client.index.Close(); err != nil {
+			client.logger.Warn("Error saving series index", "err", err)
+		}
+	}
 }
 
 // Name implements the client.Client interface.
@@ -186,6 +186,39 @@ func TestQueryToTargetsWithTags(t *testing.T) {
 	assert.Contains(t, targets[0], "\"env!=~^(prod)$\"")
 }
 
+func TestQueryToTargetsWithTagsEscaping(t *testing.T) {
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			EnableTags: true,
+			TagEscapingPrefixes: map[string]graphiteCfg.TagEscaping{
+				"new.": graphiteCfg.TagEscapingPercent,
+			},
+		},
+		format: paths.FormatCarbonTags,
+	}
+	query := &prompb.Query{
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+			{Name: "owner", Type: prompb.LabelMatcher_EQ, Value: "team X"},
+			{Name: "region", Type: prompb.LabelMatcher_RE, Value: "a;b|c d"},
+			{Name: "quote", Type: prompb.LabelMatcher_NEQ, Value: `say "hi"`},
+		},
+	}
+
+	// The values of the underscore escaping are sent as they were.
+	targets, err := client.QueryToTargetsWithTags(context.Background(), query, "old.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`seriesByTag("name=old.test","owner=team X","region=~^(a;b|c d)$","quote!=~^(say\x20\x22hi\x22)$")`}, targets)
+
+	targets, err = client.QueryToTargetsWithTags(context.Background(), query, "new.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`seriesByTag("name=new.test","owner=team%20X","region=~^((?:a%3Bb|c%20d))$","quote!=~^(say\x2520\x22hi\x22)$")`}, targets)
+
+	query.Matchers = []*prompb.LabelMatcher{{Name: "region", Type: prompb.LabelMatcher_RE, Value: "[а-я]+"}}
+	_, err = client.QueryToTargetsWithTags(context.Background(), query, "new.")
+	assert.Error(t, err)
+}
+
 func TestFilterTargets(t *testing.T) {
 	client := &Client{
 		logger: slog.New(slog.DiscardHandler),
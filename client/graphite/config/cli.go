@@ -54,7 +54,55 @@ func AddCommandLine(app *kgp.Application, cfg *Config) {
 		"Duration between purges for expired items in the paths cache.").
 		DurationVar(&cfg.Write.PathsCachePurgeInterval)
 
+	app.Flag("graphite.write.paths-cache-max-entries",
+		"Maximum number of entries of the paths cache, unbounded if zero.").
+		IntVar(&cfg.Write.PathsCacheMaxEntries)
+
+	app.Flag("graphite.write.paths-cache-max-bytes",
+		"Maximum size in bytes of the entries of the paths cache, unbounded if zero.").
+		Int64Var(&cfg.Write.PathsCacheMaxBytes)
+
+	app.Flag("graphite.whisper.root-dir",
+		"Directory to write whisper files to directly, without carbon. Disabled if empty.").
+		StringVar(&cfg.Whisper.RootDir)
+
+	app.Flag("graphite.whisper.mode",
+		"Whether whisper files are written, read instead of graphite-web, or both: write, read or read_write.").
+		EnumVar(&cfg.Whisper.Mode, WhisperModeWrite, WhisperModeRead, WhisperModeReadWrite)
+
+	app.Flag("graphite.index.enabled",
+		"Keep a local index of written series to resolve reads without /metrics/expand.").
+		BoolVar(&cfg.Index.Enabled)
+
+	app.Flag("graphite.index.path",
+		"File the series index is saved to.").
+		StringVar(&cfg.Index.Path)
+
+	app.Flag("graphite.index.retention",
+		"Duration after which series not written are dropped from the index.").
+		DurationVar(&cfg.Index.Retention)
+
+	app.Flag("graphite.index.flush-interval",
+		"Duration between saves of the series index.").
+		DurationVar(&cfg.Index.FlushInterval)
+
+	app.Flag("graphite.tag-registration.enabled",
+		"Register the written tagged paths with the TagDB of graphite-web.").
+		BoolVar(&cfg.TagRegistration.Enabled)
+
+	app.Flag("graphite.tag-registration.url",
+		"The URL of the graphite-web server to register tagged paths with, the read URL if empty.").
+		StringVar(&cfg.TagRegistration.URL)
+
 	app.Flag("graphite.enable-tags",
 		"Use Graphite tags.").
 		BoolVar(&cfg.EnableTags)
+
+	app.Flag("graphite.tag-escaping",
+		"Escaping of label values in tagged paths: underscore (lossy) or percent (reversible).").
+		EnumVar((*string)(&cfg.TagEscaping), string(TagEscapingUnderscore), string(TagEscapingPercent))
+
+	app.Flag("graphite.name-escaping",
+		"Handling of UTF-8 metric and label names: utf8 (escaped in paths) or underscores (legacy translation).").
+		EnumVar((*string)(&cfg.NameEscaping), string(NameEscapingUTF8), string(NameEscapingUnderscores))
 }
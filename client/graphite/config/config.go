@@ -40,11 +40,45 @@ const (
 	LZ4                     CompressType  = "lz4"
 	Plain                   CompressType  = "plain"
 	LZ4CompressLevelDefault               = 9
+	TagEscapingUnderscore   TagEscaping   = "underscore"
+	TagEscapingPercent      TagEscaping   = "percent"
+	NameEscapingUTF8        NameEscaping  = "utf8"
+	NameEscapingUnderscores NameEscaping  = "underscores"
+)
+
+const (
+	OnMissingLabelError   MissingLabelPolicy   = "error"
+	OnMissingLabelDrop    MissingLabelPolicy   = "drop"
+	OnMissingLabelDefault MissingLabelPolicy   = "default"
+	PathValidationRepair  PathValidationAction = "repair"
+	PathValidationReject  PathValidationAction = "reject"
 )
 
 type CompressType string
 type LZ4FBlockSize string
 
+// TagEscaping is the scheme used to escape label values in tagged paths.
+// Underscore (the default) replaces reserved characters and can't be read
+// back losslessly, percent encodes them in a reversible way.
+type TagEscaping string
+
+// NameEscaping is the handling of metric and label names which are not valid
+// in the legacy Prometheus charset. UTF8 (the default) keeps them and escapes
+// them in paths like label values, Underscores translates the invalid
+// characters to '_' as Prometheus does for legacy systems, on write and on read.
+type NameEscaping string
+
+// MissingLabelPolicy is the handling of the labels a rule template uses but
+// a metric doesn't have. Empty (the default) renders them as "<no value>",
+// Error fails the template, Drop skips the path of the rule and Default
+// renders them as the missing_label_value of the rule.
+type MissingLabelPolicy string
+
+// PathValidationAction is the handling of the invalid paths rendered by the
+// rules. Empty (the default) writes them as is, Repair fixes them when
+// possible and Reject skips them.
+type PathValidationAction string
+
 func (ct *CompressType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type compressionTypeDef CompressType
 	ctDef := (*compressionTypeDef)(ct)
@@ -78,6 +112,62 @@ func (bs *LZ4FBlockSize) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return nil
 }
 
+func (te *TagEscaping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	switch TagEscaping(s) {
+	case "", TagEscapingUnderscore, TagEscapingPercent:
+		*te = TagEscaping(s)
+	default:
+		return fmt.Errorf("unknown tag escaping %q", s)
+	}
+	return nil
+}
+
+func (ne *NameEscaping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	switch NameEscaping(s) {
+	case "", NameEscapingUTF8, NameEscapingUnderscores:
+		*ne = NameEscaping(s)
+	default:
+		return fmt.Errorf("unknown name escaping %q", s)
+	}
+	return nil
+}
+
+func (p *MissingLabelPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	switch MissingLabelPolicy(s) {
+	case "", OnMissingLabelError, OnMissingLabelDrop, OnMissingLabelDefault:
+		*p = MissingLabelPolicy(s)
+	default:
+		return fmt.Errorf("unknown missing label policy %q", s)
+	}
+	return nil
+}
+
+func (a *PathValidationAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	switch PathValidationAction(s) {
+	case "", PathValidationRepair, PathValidationReject:
+		*a = PathValidationAction(s)
+	default:
+		return fmt.Errorf("unknown path validation action %q", s)
+	}
+	return nil
+}
+
 // DefaultConfig is the default graphite configuration.
 var DefaultConfig = Config{
 	DefaultPrefix:        "",
@@ -90,11 +180,28 @@ var DefaultConfig = Config{
 		EnablePathsCache:        true,
 		PathsCacheTTL:           7 * time.Minute,
 		PathsCachePurgeInterval: 8 * time.Minute,
+		PathsCacheMaxEntries:    1000000,
 	},
 	Read: ReadConfig{
 		URL:           "",
 		MaxPointDelta: time.Duration(0),
 	},
+	Index: IndexConfig{
+		Enabled:       false,
+		Retention:     7 * 24 * time.Hour,
+		FlushInterval: 1 * time.Minute,
+	},
+	Whisper: WhisperConfig{
+		Mode:         WhisperModeWrite,
+		MaxOpenFiles: 1024,
+	},
+	TagRegistration: TagRegistrationConfig{
+		Enabled:       false,
+		BatchSize:     100,
+		FlushInterval: 10 * time.Second,
+		TTL:           1 * time.Hour,
+		QueueSize:     10000,
+	},
 }
 
 // Config is the graphite configuration.
@@ -104,6 +211,14 @@ type Config struct {
 	DefaultPrefix        string      `yaml:"default_prefix,omitempty" json:"default_prefix,omitempty"`
 	EnableTags           bool        `yaml:"enable_tags,omitempty" json:"enable_tags,omitempty"`
 	UseOpenMetricsFormat bool        `yaml:"openmetrics,omitempty" json:"openmetrics,omitempty"`
+	TagEscaping          TagEscaping `yaml:"tag_escaping,omitempty" json:"tag_escaping,omitempty"`
+	// TagEscapingPrefixes overrides TagEscaping for the given storage prefixes,
+	// so that existing data keeps its encoding.
+	TagEscapingPrefixes map[string]TagEscaping `yaml:"tag_escaping_prefixes,omitempty" json:"tag_escaping_prefixes,omitempty"`
+	NameEscaping        NameEscaping           `yaml:"name_escaping,omitempty" json:"name_escaping,omitempty"`
+	Index               IndexConfig            `yaml:"index,omitempty" json:"index,omitempty"`
+	Whisper             WhisperConfig          `yaml:"whisper,omitempty" json:"whisper,omitempty"`
+	TagRegistration     TagRegistrationConfig  `yaml:"tag_registration,omitempty" json:"tag_registration,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
@@ -136,12 +251,25 @@ func (c *Config) StoragePrefixFromRequest(r *http.Request) string {
 	return p
 }
 
+// TagEscapingForPrefix returns the tag escaping scheme used for a storage prefix.
+func (c *Config) TagEscapingForPrefix(prefix string) TagEscaping {
+	if te, ok := c.TagEscapingPrefixes[prefix]; ok && te != "" {
+		return te
+	}
+	if c.TagEscaping == "" {
+		return TagEscapingUnderscore
+	}
+	return c.TagEscaping
+}
+
 // ReadConfig is the read graphite configuration.
 type ReadConfig struct {
 	URL string `yaml:"url,omitempty" json:"url,omitempty"`
 	// If set, MaxPointDelta is used to linearly interpolate intermediate points.
 	// It helps support prom1.x reading metrics with larger retention than staleness delta.
 	MaxPointDelta time.Duration `yaml:"max_point_delta,omitempty" json:"max_point_delta,omitempty"`
+	// Rules map paths written by templating rules back to labels.
+	Rules []*ReadRule `yaml:"rules,omitempty" json:"rules,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
@@ -157,23 +285,115 @@ func (c *ReadConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return utils.CheckOverflow(c.XXX, "readConfig")
 }
 
+// IndexConfig is the configuration of the local index of written series,
+// used in plain-path mode to resolve reads without /metrics/expand.
+type IndexConfig struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// Path of the file the index is saved to. The index is kept in memory only if empty.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Series not written for longer than Retention are dropped from the index.
+	Retention     time.Duration `yaml:"retention,omitempty" json:"retention,omitempty"`
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty" json:"flush_interval,omitempty"`
+	// Exclusive tells the adapter is the only writer of its prefixes, so
+	// that reads covered by the index don't look for the series written by
+	// other replicas in Graphite. It is off by default as the adapter can't
+	// tell whether other replicas or agents write the same prefixes, and
+	// their series would silently be missing from the reads.
+	Exclusive bool `yaml:"exclusive,omitempty" json:"exclusive,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *IndexConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain IndexConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return utils.CheckOverflow(c.XXX, "indexConfig")
+}
+
+// TagRegistrationConfig is the configuration of the registration of the
+// written tagged paths with the TagDB of graphite-web, through
+// /tags/tagMultiSeries, for setups where carbon doesn't register them.
+type TagRegistrationConfig struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// URL of graphite-web, the read URL if empty.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Paths are registered in batches of BatchSize, at least every
+	// FlushInterval. QueueSize paths wait for their batch, the new ones
+	// are registered on a later write once the queue is full.
+	BatchSize     int           `yaml:"batch_size,omitempty" json:"batch_size,omitempty"`
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty" json:"flush_interval,omitempty"`
+	QueueSize     int           `yaml:"queue_size,omitempty" json:"queue_size,omitempty"`
+	// A path is registered again once TTL elapsed since its registration.
+	TTL time.Duration `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TagRegistrationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TagRegistrationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.BatchSize <= 0 || c.FlushInterval <= 0 || c.QueueSize <= 0 || c.TTL <= 0 {
+		return fmt.Errorf("tag registration batch_size, flush_interval, queue_size and ttl must be positive")
+	}
+
+	return utils.CheckOverflow(c.XXX, "tagRegistrationConfig")
+}
+
 // WriteConfig is the write graphite configuration.
 type WriteConfig struct {
-	CarbonAddress           string                 `yaml:"carbon_address,omitempty" json:"carbon_address,omitempty"`
-	CarbonTransport         string                 `yaml:"carbon_transport,omitempty" json:"carbon_transport,omitempty"`
-	CompressType            CompressType           `yaml:"compress_type,omitempty" json:"compress_type,omitempty"`
-	CompressLZ4Preferences  *LZ4Preferences        `yaml:"lz4_preferences,omitempty" json:"lz4_preferences,omitempty"`
-	CarbonReconnectInterval time.Duration          `yaml:"carbon_reconnect_interval,omitempty" json:"carbon_reconnect_interval,omitempty"`
-	EnablePathsCache        bool                   `yaml:"enable_paths_cache,omitempty" json:"enable_paths_cache,omitempty"`
-	PathsCacheTTL           time.Duration          `yaml:"paths_cache_ttl,omitempty" json:"paths_cache_ttl,omitempty"`
-	PathsCachePurgeInterval time.Duration          `yaml:"paths_cache_purge_interval,omitempty" json:"paths_cache_purge_interval,omitempty"`
-	TemplateData            map[string]interface{} `yaml:"template_data,omitempty" json:"template_data,omitempty"`
-	Rules                   []*Rule                `yaml:"rules,omitempty" json:"rules,omitempty"`
+	CarbonAddress           string          `yaml:"carbon_address,omitempty" json:"carbon_address,omitempty"`
+	CarbonTransport         string          `yaml:"carbon_transport,omitempty" json:"carbon_transport,omitempty"`
+	CompressType            CompressType    `yaml:"compress_type,omitempty" json:"compress_type,omitempty"`
+	CompressLZ4Preferences  *LZ4Preferences `yaml:"lz4_preferences,omitempty" json:"lz4_preferences,omitempty"`
+	CarbonReconnectInterval time.Duration   `yaml:"carbon_reconnect_interval,omitempty" json:"carbon_reconnect_interval,omitempty"`
+	EnablePathsCache        bool            `yaml:"enable_paths_cache,omitempty" json:"enable_paths_cache,omitempty"`
+	PathsCacheTTL           time.Duration   `yaml:"paths_cache_ttl,omitempty" json:"paths_cache_ttl,omitempty"`
+	PathsCachePurgeInterval time.Duration   `yaml:"paths_cache_purge_interval,omitempty" json:"paths_cache_purge_interval,omitempty"`
+	// The paths cache evicts the least recently used paths beyond
+	// PathsCacheMaxEntries entries or PathsCacheMaxBytes bytes, unless zero.
+	PathsCacheMaxEntries int                    `yaml:"paths_cache_max_entries,omitempty" json:"paths_cache_max_entries,omitempty"`
+	PathsCacheMaxBytes   int64                  `yaml:"paths_cache_max_bytes,omitempty" json:"paths_cache_max_bytes,omitempty"`
+	TemplateData         map[string]interface{} `yaml:"template_data,omitempty" json:"template_data,omitempty"`
+	Rules                []*Rule                `yaml:"rules,omitempty" json:"rules,omitempty"`
+	PathValidation       PathValidationConfig   `yaml:"path_validation,omitempty" json:"path_validation,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
 }
 
+// PathValidationConfig is the validation of the paths rendered by the rules:
+// empty nodes, leading or trailing dots, whitespace, control characters and
+// slashes, and paths longer than MaxLength bytes unless zero.
+type PathValidationConfig struct {
+	Action    PathValidationAction `yaml:"action,omitempty" json:"action,omitempty"`
+	MaxLength int                  `yaml:"max_length,omitempty" json:"max_length,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *PathValidationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PathValidationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxLength < 0 {
+		return fmt.Errorf("path validation max_length must not be negative")
+	}
+
+	return utils.CheckOverflow(c.XXX, "pathValidationConfig")
+}
+
 // LZ4FrameInfo makes it possible to set or read frame parameters.
 type LZ4FrameInfo struct {
 	// The larger the block size, the (slightly) better the compression ratio.
@@ -205,6 +425,9 @@ func (c *WriteConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
 	}
+	for _, rule := range c.Rules {
+		rule.PathValidation = c.PathValidation
+	}
 
 	return utils.CheckOverflow(c.XXX, "writeConfig")
 }
@@ -218,10 +441,19 @@ type LabelSetRE map[model.LabelName]Regexp
 // Rule defines a templating rule that customize graphite path using the
 // Tmpl if a metric matching the labels exists.
 type Rule struct {
-	Tmpl     Template   `yaml:"template,omitempty" json:"template,omitempty"`
-	Match    LabelSet   `yaml:"match,omitempty" json:"match,omitempty"`
-	MatchRE  LabelSetRE `yaml:"match_re,omitempty" json:"match_re,omitempty"`
-	Continue bool       `yaml:"continue,omitempty" json:"continue,omitempty"`
+	Tmpl              Template           `yaml:"template,omitempty" json:"template,omitempty"`
+	Match             LabelSet           `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE           LabelSetRE         `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+	Continue          bool               `yaml:"continue,omitempty" json:"continue,omitempty"`
+	OnMissingLabel    MissingLabelPolicy `yaml:"on_missing_label,omitempty" json:"on_missing_label,omitempty"`
+	MissingLabelValue string             `yaml:"missing_label_value,omitempty" json:"missing_label_value,omitempty"`
+
+	// PathValidation is the path_validation of the write config, set on
+	// load.
+	PathValidation PathValidationConfig `yaml:"-" json:"-"`
+
+	// labels are the labels used by Tmpl.
+	labels []string
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
@@ -233,10 +465,57 @@ func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(r)); err != nil {
 		return err
 	}
+	if r.MissingLabelValue != "" && r.OnMissingLabel != OnMissingLabelDefault {
+		return fmt.Errorf("rule missing_label_value requires on_missing_label: default")
+	}
+	if r.Tmpl.Template != nil {
+		switch r.OnMissingLabel {
+		case OnMissingLabelError, OnMissingLabelDrop:
+			r.Tmpl.Option("missingkey=error")
+		case OnMissingLabelDefault:
+			r.Tmpl.Option("missingkey=zero")
+		}
+		r.labels = r.Tmpl.Labels()
+	}
 
 	return utils.CheckOverflow(r.XXX, "rule")
 }
 
+// Labels returns the sorted names of the labels used by the template of the
+// rule.
+func (r *Rule) Labels() []string {
+	return r.labels
+}
+
+// ReadRule defines how to find and parse back the Graphite paths written by
+// a templating Rule. Query is a Graphite glob rendered with the values of the
+// equality matchers of a read query, or "*" for every label not constrained.
+// Regex extracts labels from the expanded paths using named capture groups.
+type ReadRule struct {
+	Labels LabelSet `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Query  Template `yaml:"query,omitempty" json:"query,omitempty"`
+	Regex  Regexp   `yaml:"regex,omitempty" json:"regex,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *ReadRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ReadRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.Query.Template == nil {
+		return fmt.Errorf("read rule requires a query")
+	}
+	if r.Regex.Regexp == nil {
+		return fmt.Errorf("read rule requires a regex")
+	}
+
+	return utils.CheckOverflow(r.XXX, "read rule")
+}
+
 // Template is a parsable template.
 type Template struct {
 	*template.Template
@@ -36,6 +36,54 @@ var (
 		Read: ReadConfig{
 			URL:           "greatGraphiteWebURL",
 			MaxPointDelta: 5 * time.Minute,
+			Rules: []*ReadRule{
+				{
+					Labels: LabelSet{
+						"__name__": "host_service",
+					},
+					Query: prepareExpectedTemplate("great.graphite.path.host.{{.labels.owner}}.{{.labels.service}}"),
+					Regex: prepareExpectedRegexp("great\\.graphite\\.path\\.host\\.(?P<owner>[^.]+)\\.(?P<service>[^.]+)"),
+				},
+			},
+		},
+		Index: IndexConfig{
+			Enabled:       true,
+			Path:          "/var/lib/graphite-remote-adapter/index.json",
+			Retention:     48 * time.Hour,
+			FlushInterval: 1 * time.Minute,
+		},
+		TagRegistration: TagRegistrationConfig{
+			Enabled:       true,
+			URL:           "greatGraphiteWebURL",
+			BatchSize:     50,
+			FlushInterval: 10 * time.Second,
+			QueueSize:     10000,
+			TTL:           30 * time.Minute,
+		},
+		Whisper: WhisperConfig{
+			RootDir: "/var/lib/graphite/whisper",
+			Mode:    WhisperModeReadWrite,
+			StorageSchemas: []*StorageSchema{
+				{
+					Name:       "carbon",
+					Pattern:    SearchRegexp{regexp.MustCompile("^carbon\\.")},
+					Retentions: Retentions{{SecondsPerPoint: 60, Points: 129600}},
+				},
+				{
+					Name:       "default",
+					Pattern:    SearchRegexp{regexp.MustCompile(".*")},
+					Retentions: Retentions{{SecondsPerPoint: 10, Points: 2160}, {SecondsPerPoint: 60, Points: 10080}},
+				},
+			},
+			StorageAggregation: []*StorageAggregation{
+				{
+					Name:              "sum",
+					Pattern:           SearchRegexp{regexp.MustCompile("\\.count$")},
+					XFilesFactor:      0,
+					AggregationMethod: AggregationSum,
+				},
+			},
+			MaxOpenFiles: 1024,
 		},
 		Write: WriteConfig{
 			CarbonAddress:           "greatCarbonAddress",
@@ -44,6 +92,8 @@ var (
 			CarbonReconnectInterval: 2 * time.Minute,
 			PathsCacheTTL:           18 * time.Minute,
 			PathsCachePurgeInterval: 42 * time.Minute,
+			PathsCacheMaxEntries:    50000,
+			PathsCacheMaxBytes:      64 << 20,
 			TemplateData: map[string]interface{}{
 				"site_mapping": map[string]string{"eu-par": "fr_eqx"},
 			},
@@ -84,6 +134,20 @@ var (
 			URL:           "greatGraphiteWebURL",
 			MaxPointDelta: 5 * time.Minute,
 		},
+		Index: IndexConfig{
+			Retention:     7 * 24 * time.Hour,
+			FlushInterval: 1 * time.Minute,
+		},
+		Whisper: WhisperConfig{
+			Mode:         WhisperModeWrite,
+			MaxOpenFiles: 1024,
+		},
+		TagRegistration: TagRegistrationConfig{
+			BatchSize:     100,
+			FlushInterval: 10 * time.Second,
+			QueueSize:     10000,
+			TTL:           1 * time.Hour,
+		},
 		Write: WriteConfig{
 			CarbonAddress:           "greatCarbonAddress",
 			CarbonTransport:         "tcp",
@@ -92,6 +156,7 @@ var (
 			CarbonReconnectInterval: 2 * time.Minute,
 			PathsCacheTTL:           18 * time.Minute,
 			PathsCachePurgeInterval: 42 * time.Minute,
+			PathsCacheMaxEntries:    1000000,
 			TemplateData: map[string]interface{}{
 				"site_mapping": map[string]string{"eu-par": "fr_eqx"},
 			},
@@ -132,6 +197,20 @@ var (
 			URL:           "greatGraphiteWebURL",
 			MaxPointDelta: 5 * time.Minute,
 		},
+		Index: IndexConfig{
+			Retention:     7 * 24 * time.Hour,
+			FlushInterval: 1 * time.Minute,
+		},
+		Whisper: WhisperConfig{
+			Mode:         WhisperModeWrite,
+			MaxOpenFiles: 1024,
+		},
+		TagRegistration: TagRegistrationConfig{
+			BatchSize:     100,
+			FlushInterval: 10 * time.Second,
+			QueueSize:     10000,
+			TTL:           1 * time.Hour,
+		},
 		Write: WriteConfig{
 			CarbonAddress:   "greatCarbonAddress",
 			CarbonTransport: "tcp",
@@ -151,6 +230,7 @@ var (
 			CarbonReconnectInterval: 2 * time.Minute,
 			PathsCacheTTL:           18 * time.Minute,
 			PathsCachePurgeInterval: 42 * time.Minute,
+			PathsCacheMaxEntries:    1000000,
 			TemplateData: map[string]interface{}{
 				"site_mapping": map[string]string{"eu-par": "fr_eqx"},
 			},
@@ -238,3 +318,125 @@ func TestUnmarshalLZ4Config(t *testing.T) {
 			"testdata/graphite.good.lz4.yml", cfg.String(), expectedConf.String())
 	}
 }
+
+func TestUnmarshalReadRuleWithoutRegex(t *testing.T) {
+	rule := &ReadRule{}
+	err := yaml.Unmarshal([]byte("query: 'foo.*'"), rule)
+	if err == nil {
+		t.Fatalf("expected an error for a read rule without regex")
+	}
+}
+
+func TestTagEscapingForPrefix(t *testing.T) {
+	cfg := &Config{}
+	err := yaml.Unmarshal([]byte(`
+tag_escaping_prefixes:
+  new.prefix.: percent
+`), cfg)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	if te := cfg.TagEscapingForPrefix("old.prefix."); te != TagEscapingUnderscore {
+		t.Errorf("Expected %s, got %s", TagEscapingUnderscore, te)
+	}
+	if te := cfg.TagEscapingForPrefix("new.prefix."); te != TagEscapingPercent {
+		t.Errorf("Expected %s, got %s", TagEscapingPercent, te)
+	}
+
+	if err := yaml.Unmarshal([]byte("tag_escaping: base64"), &Config{}); err == nil {
+		t.Errorf("Expected an error for an unknown tag escaping")
+	}
+}
+
+func TestUnmarshalMissingLabelAndPathValidation(t *testing.T) {
+	cfg := &Config{}
+	err := yaml.Unmarshal([]byte(`
+write:
+  path_validation:
+    action: repair
+    max_length: 255
+  rules:
+  - template: 'nodes.{{ .labels.instance }}.{{ index .labels "job" }}'
+    on_missing_label: default
+    missing_label_value: unknown
+`), cfg)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	rule := cfg.Write.Rules[0]
+	if rule.PathValidation.Action != PathValidationRepair || rule.PathValidation.MaxLength != 255 {
+		t.Errorf("Expected the path validation of the write config, got %+v", rule.PathValidation)
+	}
+	if labels := rule.Labels(); len(labels) != 2 || labels[0] != "instance" || labels[1] != "job" {
+		t.Errorf("Expected the labels instance and job, got %v", labels)
+	}
+
+	for _, s := range []string{
+		"on_missing_label: ignore",
+		"on_missing_label: drop\nmissing_label_value: unknown",
+		"missing_label_value: unknown",
+	} {
+		if err := yaml.Unmarshal([]byte(s), &Rule{}); err == nil {
+			t.Errorf("Expected an error for the rule %q", s)
+		}
+	}
+	for _, s := range []string{"action: fix", "max_length: -1"} {
+		if err := yaml.Unmarshal([]byte(s), &PathValidationConfig{}); err == nil {
+			t.Errorf("Expected an error for the path validation %q", s)
+		}
+	}
+}
+
+func TestParseRetentions(t *testing.T) {
+	r, err := ParseRetentions("10s:6h, 1min:7d,3600:8760")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "10s:2160,60s:10080,3600s:8760" {
+		t.Errorf("unexpected retentions %s", r)
+	}
+
+	for _, s := range []string{"10s", "1m:1d,10s:6h", "7s:1h,10s:1d", "10s:30s,1m:1h", "1x:1d"} {
+		if _, err := ParseRetentions(s); err == nil {
+			t.Errorf("expected an error for retentions %q", s)
+		}
+	}
+}
+
+func TestWhisperPatternsAreSearched(t *testing.T) {
+	cfg := &WhisperConfig{}
+	err := yaml.Unmarshal([]byte(`
+storage_schemas:
+- name: carbon
+  pattern: '^carbon\.'
+  retentions: 60s:90d
+- name: stats
+  pattern: 'stats'
+  retentions: 10s:1d
+storage_aggregation:
+- name: count
+  pattern: '\.count$'
+  aggregation_method: sum
+`), cfg)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+
+	for path, expected := range map[string]string{
+		"carbon.agents.host.cpuUsage": "60s:129600",
+		"prom.carbon.up":              DefaultRetentions.String(),
+		"prom.stats.up":               "10s:8640",
+	} {
+		if r := cfg.Retentions(path); r.String() != expected {
+			t.Errorf("Expected the retentions %s for %s, got %s", expected, path, r)
+		}
+	}
+	for path, expected := range map[string]string{
+		"prom.requests.count":      AggregationSum,
+		"prom.requests.count.rate": AggregationAverage,
+	} {
+		if method, _ := cfg.Aggregation(path); method != expected {
+			t.Errorf("Expected the aggregation %s for %s, got %s", expected, path, method)
+		}
+	}
+}
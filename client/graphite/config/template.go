@@ -0,0 +1,93 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"sort"
+	"text/template/parse"
+)
+
+// Labels returns the sorted names of the labels the template gets with
+// .labels.name or index .labels "name".
+func (tmpl Template) Labels() []string {
+	if tmpl.Template == nil {
+		return nil
+	}
+	names := map[string]struct{}{}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walkLabels(t.Tree.Root, names)
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func walkLabels(node parse.Node, names map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, sub := range n.Nodes {
+				walkLabels(sub, names)
+			}
+		}
+	case *parse.ActionNode:
+		walkLabels(n.Pipe, names)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, names)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, names)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, names)
+	case *parse.TemplateNode:
+		walkLabels(n.Pipe, names)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, cmd := range n.Cmds {
+				walkLabels(cmd, names)
+			}
+		}
+	case *parse.CommandNode:
+		for i, arg := range n.Args {
+			if ident, ok := arg.(*parse.IdentifierNode); ok && ident.Ident == "index" && i+2 < len(n.Args) {
+				field, isField := n.Args[i+1].(*parse.FieldNode)
+				name, isString := n.Args[i+2].(*parse.StringNode)
+				if isField && isString && len(field.Ident) == 1 && field.Ident[0] == "labels" {
+					names[name.Text] = struct{}{}
+				}
+			}
+			walkLabels(arg, names)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) >= 2 && n.Ident[0] == "labels" {
+			names[n.Ident[1]] = struct{}{}
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) >= 3 && n.Ident[0] == "$" && n.Ident[1] == "labels" {
+			names[n.Ident[2]] = struct{}{}
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, names map[string]struct{}) {
+	walkLabels(n.Pipe, names)
+	walkLabels(n.List, names)
+	walkLabels(n.ElseList, names)
+}
@@ -0,0 +1,336 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
+)
+
+// Aggregation methods of whisper files.
+const (
+	AggregationAverage = "average"
+	AggregationSum     = "sum"
+	AggregationLast    = "last"
+	AggregationMax     = "max"
+	AggregationMin     = "min"
+	AggregationAvgZero = "avg_zero"
+	AggregationAbsMax  = "absmax"
+	AggregationAbsMin  = "absmin"
+)
+
+// Modes of the whisper files: written only, read only, e.g. beside
+// carbon-cache, or both.
+const (
+	WhisperModeWrite     = "write"
+	WhisperModeRead      = "read"
+	WhisperModeReadWrite = "read_write"
+)
+
+// DefaultRetentions are the retentions of the paths no storage schema
+// matches, like the default storage schema of carbon.
+var DefaultRetentions = Retentions{{SecondsPerPoint: 60, Points: 1440}}
+
+// WhisperConfig is the configuration of the whisper files written directly,
+// instead of sending the samples to carbon, and optionally read back without
+// graphite-web.
+type WhisperConfig struct {
+	// RootDir is the directory of the whisper files, writing them is disabled if empty.
+	RootDir string `yaml:"root_dir,omitempty" json:"root_dir,omitempty"`
+	// Mode tells whether the whisper files are written, or read instead of
+	// graphite-web, or both.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// StorageSchemas and StorageAggregation are the carbon rules used to
+	// create the whisper files, the first one matching a path applies.
+	StorageSchemas     []*StorageSchema      `yaml:"storage_schemas,omitempty" json:"storage_schemas,omitempty"`
+	StorageAggregation []*StorageAggregation `yaml:"storage_aggregation,omitempty" json:"storage_aggregation,omitempty"`
+	// MaxOpenFiles bounds the whisper files kept open between writes.
+	MaxOpenFiles int `yaml:"max_open_files,omitempty" json:"max_open_files,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *WhisperConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig.Whisper
+	type plain WhisperConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxOpenFiles <= 0 {
+		return fmt.Errorf("whisper max_open_files must be positive")
+	}
+	switch c.Mode {
+	case WhisperModeWrite, WhisperModeRead, WhisperModeReadWrite:
+	default:
+		return fmt.Errorf("unknown whisper mode %q", c.Mode)
+	}
+
+	return utils.CheckOverflow(c.XXX, "whisperConfig")
+}
+
+// Writes reports whether samples are written to the whisper files.
+func (c *WhisperConfig) Writes() bool {
+	return c.Mode != WhisperModeRead
+}
+
+// Reads reports whether remote reads are served from the whisper files.
+func (c *WhisperConfig) Reads() bool {
+	return c.Mode == WhisperModeRead || c.Mode == WhisperModeReadWrite
+}
+
+// Retentions returns the retentions of the whisper file of a path.
+func (c *WhisperConfig) Retentions(path string) Retentions {
+	for _, s := range c.StorageSchemas {
+		if s.Pattern.MatchString(path) {
+			return s.Retentions
+		}
+	}
+	return DefaultRetentions
+}
+
+// Aggregation returns the aggregation method and the xFilesFactor of the
+// whisper file of a path.
+func (c *WhisperConfig) Aggregation(path string) (string, float64) {
+	for _, a := range c.StorageAggregation {
+		if a.Pattern.MatchString(path) {
+			return a.AggregationMethod, a.XFilesFactor
+		}
+	}
+	return AggregationAverage, 0.5
+}
+
+// SearchRegexp is a regular expression matching anywhere in the string,
+// unlike Regexp: the patterns of carbon's configuration files are searched,
+// so that '^carbon\.' matches the paths starting with "carbon.".
+type SearchRegexp struct {
+	*regexp.Regexp
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (re *SearchRegexp) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	regex, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	re.Regexp = regex
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (re SearchRegexp) MarshalYAML() (interface{}, error) {
+	return re.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (re SearchRegexp) MarshalJSON() ([]byte, error) {
+	if re.Regexp != nil {
+		return json.Marshal(re.String())
+	}
+	return nil, nil
+}
+
+// StorageSchema sets the retentions of the whisper files of the paths
+// matching its pattern, like a section of carbon's storage-schemas.conf.
+type StorageSchema struct {
+	Name       string       `yaml:"name,omitempty" json:"name,omitempty"`
+	Pattern    SearchRegexp `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Retentions Retentions   `yaml:"retentions,omitempty" json:"retentions,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *StorageSchema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain StorageSchema
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.Pattern.Regexp == nil {
+		s.Pattern = SearchRegexp{regexp.MustCompile(".*")}
+	}
+	if len(s.Retentions) == 0 {
+		return fmt.Errorf("storage schema %q requires retentions", s.Name)
+	}
+
+	return utils.CheckOverflow(s.XXX, "storage schema")
+}
+
+// StorageAggregation sets how the whisper files of the paths matching its
+// pattern are downsampled, like a section of carbon's
+// storage-aggregation.conf.
+type StorageAggregation struct {
+	Name              string       `yaml:"name,omitempty" json:"name,omitempty"`
+	Pattern           SearchRegexp `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	XFilesFactor      float64      `yaml:"xfiles_factor" json:"xfiles_factor"`
+	AggregationMethod string       `yaml:"aggregation_method,omitempty" json:"aggregation_method,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (a *StorageAggregation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*a = StorageAggregation{XFilesFactor: 0.5, AggregationMethod: AggregationAverage}
+	type plain StorageAggregation
+	if err := unmarshal((*plain)(a)); err != nil {
+		return err
+	}
+	if a.Pattern.Regexp == nil {
+		a.Pattern = SearchRegexp{regexp.MustCompile(".*")}
+	}
+	if a.XFilesFactor < 0 || a.XFilesFactor > 1 {
+		return fmt.Errorf("storage aggregation %q xfiles_factor must be between 0 and 1", a.Name)
+	}
+	switch a.AggregationMethod {
+	case AggregationAverage, AggregationSum, AggregationLast, AggregationMax, AggregationMin,
+		AggregationAvgZero, AggregationAbsMax, AggregationAbsMin:
+	default:
+		return fmt.Errorf("storage aggregation %q has unknown aggregation method %q", a.Name, a.AggregationMethod)
+	}
+
+	return utils.CheckOverflow(a.XXX, "storage aggregation")
+}
+
+// Retention is the precision and number of points of a whisper archive.
+type Retention struct {
+	SecondsPerPoint int
+	Points          int
+}
+
+// Retentions are the archives of a whisper file, from the most precise.
+type Retentions []Retention
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *Retentions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	retentions, err := ParseRetentions(s)
+	if err != nil {
+		return err
+	}
+	*r = retentions
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r Retentions) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+func (r Retentions) String() string {
+	defs := make([]string, 0, len(r))
+	for _, a := range r {
+		defs = append(defs, fmt.Sprintf("%ds:%d", a.SecondsPerPoint, a.Points))
+	}
+	return strings.Join(defs, ",")
+}
+
+// ParseRetentions parses retentions written like in carbon's
+// storage-schemas.conf, as comma separated precision:retention pairs, e.g.
+// "10s:6h,1m:7d". A precision or retention without unit is a number of
+// seconds or of points respectively. The archives are validated like
+// whisper does when creating a file.
+func ParseRetentions(s string) (Retentions, error) {
+	var r Retentions
+	for _, def := range strings.Split(s, ",") {
+		precision, retention, ok := strings.Cut(strings.TrimSpace(def), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid retention %q: expected precision:retention", def)
+		}
+		spp, err := parseRetentionDuration(precision)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention %q: %w", def, err)
+		}
+		if spp <= 0 {
+			return nil, fmt.Errorf("invalid retention %q: precision must be positive", def)
+		}
+		var points int
+		if n, err := strconv.Atoi(retention); err == nil {
+			points = n
+		} else {
+			seconds, err := parseRetentionDuration(retention)
+			if err != nil {
+				return nil, fmt.Errorf("invalid retention %q: %w", def, err)
+			}
+			points = seconds / spp
+		}
+		if points <= 0 {
+			return nil, fmt.Errorf("invalid retention %q: no points", def)
+		}
+		r = append(r, Retention{SecondsPerPoint: spp, Points: points})
+	}
+
+	for i := 1; i < len(r); i++ {
+		prev, cur := r[i-1], r[i]
+		switch {
+		case cur.SecondsPerPoint <= prev.SecondsPerPoint:
+			return nil, fmt.Errorf("invalid retentions %q: archives must be sorted by increasing precision", s)
+		case cur.SecondsPerPoint%prev.SecondsPerPoint != 0:
+			return nil, fmt.Errorf("invalid retentions %q: precision %ds doesn't divide %ds", s, prev.SecondsPerPoint, cur.SecondsPerPoint)
+		case cur.SecondsPerPoint*cur.Points <= prev.SecondsPerPoint*prev.Points:
+			return nil, fmt.Errorf("invalid retentions %q: lower precision archives must cover longer time ranges", s)
+		case prev.Points < cur.SecondsPerPoint/prev.SecondsPerPoint:
+			return nil, fmt.Errorf("invalid retentions %q: archive %d has too few points to consolidate", s, i-1)
+		}
+	}
+	return r, nil
+}
+
+var retentionUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour, "year": 365 * 24 * time.Hour, "years": 365 * 24 * time.Hour,
+}
+
+var retentionDurationRE = regexp.MustCompile(`^(\d+)([a-z]*)$`)
+
+// parseRetentionDuration parses a number of seconds, with an optional unit.
+func parseRetentionDuration(s string) (int, error) {
+	m := retentionDurationRE.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, err
+	}
+	if m[2] == "" {
+		return n, nil
+	}
+	unit, ok := retentionUnits[m[2]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", m[2])
+	}
+	return n * int(unit/time.Second), nil
+}
@@ -0,0 +1,400 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"log/slog"
+
+	"github.com/prometheus/common/model"
+	plabels "github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Entry is a written series and its Graphite path.
+type Entry struct {
+	Path   string       `json:"path"`
+	Prefix string       `json:"prefix"`
+	Labels model.Metric `json:"labels"`
+	// LastSeen is the unix timestamp in seconds of the last write.
+	LastSeen int64 `json:"last_seen"`
+}
+
+type seriesKey struct {
+	prefix string
+	fp     model.Fingerprint
+}
+
+type nameKey struct {
+	prefix string
+	name   model.LabelValue
+}
+
+// snapshot is the content of the index file. Since is the unix timestamp
+// in seconds the index was created at.
+type snapshot struct {
+	Since int64 `json:"since,omitempty"`
+	// Clean is only set by Close: the series written after the last flush
+	// of an index which wasn't closed are missing from its snapshot.
+	Clean   bool     `json:"clean,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Index keeps the paths of the series written to Graphite with the last time
+// they were written, so that reads can resolve matchers without expanding the
+// whole tree. It is persisted to a file and entries not written for longer
+// than the retention are dropped.
+type Index struct {
+	lock   sync.RWMutex
+	series map[seriesKey][]*Entry
+	names  map[nameKey]map[*Entry]struct{}
+
+	file      string
+	retention time.Duration
+	now       func() time.Time
+	// since is the unix timestamp in seconds from which every written
+	// series is recorded.
+	since int64
+
+	stop   chan struct{}
+	done   chan struct{}
+	logger *slog.Logger
+}
+
+// New returns an Index loaded from file, if it exists. Unless flushInterval
+// is zero, the index is saved and expired entries are dropped every
+// flushInterval until Close is called.
+func New(file string, retention time.Duration, flushInterval time.Duration, logger *slog.Logger) (*Index, error) {
+	idx := &Index{
+		series:    make(map[seriesKey][]*Entry),
+		names:     make(map[nameKey]map[*Entry]struct{}),
+		file:      file,
+		retention: retention,
+		now:       time.Now,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		logger:    logger,
+	}
+	idx.since = idx.now().Unix()
+	if err := idx.load(); err != nil {
+		return nil, err
+	}
+
+	if flushInterval > 0 {
+		go idx.run(flushInterval)
+	} else {
+		close(idx.done)
+	}
+	return idx, nil
+}
+
+func (idx *Index) run(flushInterval time.Duration) {
+	defer close(idx.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := idx.Flush(); err != nil {
+				idx.logger.Warn("Error saving series index", "file", idx.file, "err", err)
+			}
+		case <-idx.stop:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush and saves the index.
+func (idx *Index) Close() error {
+	select {
+	case <-idx.stop:
+	default:
+		close(idx.stop)
+	}
+	<-idx.done
+	return idx.save(true)
+}
+
+// Touch records that m was written with the given prefix. path is only
+// called for series not indexed yet, and may return an empty string for
+// series not written to a path the index can serve.
+func (idx *Index) Touch(m model.Metric, prefix string, path func() string) {
+	now := idx.now().Unix()
+	key := seriesKey{prefix: prefix, fp: m.Fingerprint()}
+
+	idx.lock.RLock()
+	entry := idx.find(key, m)
+	idx.lock.RUnlock()
+	if entry != nil {
+		atomic.StoreInt64(&entry.LastSeen, now)
+		return
+	}
+
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	if entry = idx.find(key, m); entry != nil {
+		atomic.StoreInt64(&entry.LastSeen, now)
+		return
+	}
+	idx.add(&Entry{Path: path(), Prefix: prefix, Labels: m.Clone(), LastSeen: now})
+}
+
+// Lookup returns the paths of the indexed series matching all the matchers.
+// The returned bool is false if the index knows no series with the metric
+// name of the query, in which case the caller should fall back to Graphite.
+func (idx *Index) Lookup(prefix string, matchers []*prompb.LabelMatcher) ([]string, bool, error) {
+	var name string
+	for _, m := range matchers {
+		if m.Name == model.MetricNameLabel && m.Type == prompb.LabelMatcher_EQ {
+			name = m.Value
+		}
+	}
+	if name == "" {
+		return nil, false, nil
+	}
+	labelMatchers, err := toLabelMatchers(matchers)
+	if err != nil {
+		return nil, false, err
+	}
+
+	minLastSeen := idx.minLastSeen()
+
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	entries, ok := idx.names[nameKey{prefix: prefix, name: model.LabelValue(name)}]
+	if !ok {
+		return nil, false, nil
+	}
+
+	var result []string
+	for entry := range entries {
+		if entry.Path == "" || atomic.LoadInt64(&entry.LastSeen) < minLastSeen {
+			continue
+		}
+		if matches(entry.Labels, labelMatchers) {
+			result = append(result, entry.Path)
+		}
+	}
+	return result, true, nil
+}
+
+// Covers reports whether the index recorded every series written since
+// start, which is within the retention and after the index was created, or
+// last loaded without having been closed. The series of an earlier start may
+// only be known to Graphite.
+func (idx *Index) Covers(start time.Time) bool {
+	return start.Unix() >= idx.minLastSeen() && start.Unix() >= idx.since
+}
+
+// Series returns the labels of the indexed series matching all the matchers,
+// including the ones written to templated paths only.
+func (idx *Index) Series(prefix string, matchers []*prompb.LabelMatcher) ([]model.Metric, error) {
+	labelMatchers, err := toLabelMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	minLastSeen := idx.minLastSeen()
+
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	var result []model.Metric
+	for nk, entries := range idx.names {
+		if nk.prefix != prefix {
+			continue
+		}
+		for entry := range entries {
+			if atomic.LoadInt64(&entry.LastSeen) < minLastSeen {
+				continue
+			}
+			if matches(entry.Labels, labelMatchers) {
+				result = append(result, entry.Labels)
+			}
+		}
+	}
+	return result, nil
+}
+
+func toLabelMatchers(matchers []*prompb.LabelMatcher) ([]*plabels.Matcher, error) {
+	labelMatchers := make([]*plabels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		matcher, err := plabels.NewMatcher(plabels.MatchType(m.Type), m.Name, m.Value)
+		if err != nil {
+			return nil, err
+		}
+		labelMatchers = append(labelMatchers, matcher)
+	}
+	return labelMatchers, nil
+}
+
+func matches(m model.Metric, matchers []*plabels.Matcher) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(string(m[model.LabelName(matcher.Name)])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of indexed series.
+func (idx *Index) Len() int {
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+	var n int
+	for _, entries := range idx.series {
+		n += len(entries)
+	}
+	return n
+}
+
+// Flush drops the expired entries and saves the index to its file.
+func (idx *Index) Flush() error {
+	return idx.save(false)
+}
+
+// save drops the expired entries and saves the index to its file, clean if
+// it is closed.
+func (idx *Index) save(clean bool) error {
+	minLastSeen := idx.minLastSeen()
+
+	idx.lock.Lock()
+	snap := snapshot{Since: idx.since, Clean: clean}
+	for key, entries := range idx.series {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if atomic.LoadInt64(&entry.LastSeen) < minLastSeen {
+				idx.removeName(entry)
+				continue
+			}
+			kept = append(kept, entry)
+			snap.Entries = append(snap.Entries, &Entry{
+				Path:     entry.Path,
+				Prefix:   entry.Prefix,
+				Labels:   entry.Labels,
+				LastSeen: atomic.LoadInt64(&entry.LastSeen),
+			})
+		}
+		if len(kept) == 0 {
+			delete(idx.series, key)
+		} else {
+			idx.series[key] = kept
+		}
+	}
+	idx.lock.Unlock()
+
+	if idx.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that a crash never leaves a truncated index.
+	tmp := idx.file + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(idx.file), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.file)
+}
+
+func (idx *Index) load() error {
+	if idx.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(idx.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snap := snapshot{}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	minLastSeen := idx.minLastSeen()
+	for _, entry := range snap.Entries {
+		if entry.LastSeen < minLastSeen || entry.Labels == nil {
+			continue
+		}
+		idx.add(entry)
+	}
+	idx.logger.Info("Loaded series index", "file", idx.file, "series", idx.Len(), "clean", snap.Clean)
+
+	// The index only covers the series written before it was loaded if it
+	// was closed, and until it is closed again: the snapshot is marked as
+	// not clean first, in case the adapter is killed.
+	if snap.Clean && snap.Since > 0 {
+		if err := idx.save(false); err != nil {
+			idx.logger.Warn("Error saving series index", "file", idx.file, "err", err)
+			return nil
+		}
+		idx.since = snap.Since
+	}
+	return nil
+}
+
+func (idx *Index) minLastSeen() int64 {
+	if idx.retention <= 0 {
+		return 0
+	}
+	return idx.now().Add(-idx.retention).Unix()
+}
+
+// find must be called with the lock held. Fingerprints may collide, so the
+// labels are compared as well.
+func (idx *Index) find(key seriesKey, m model.Metric) *Entry {
+	for _, entry := range idx.series[key] {
+		if entry.Labels.Equal(m) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// add must be called with the write lock held.
+func (idx *Index) add(entry *Entry) {
+	key := seriesKey{prefix: entry.Prefix, fp: entry.Labels.Fingerprint()}
+	idx.series[key] = append(idx.series[key], entry)
+
+	nk := nameKey{prefix: entry.Prefix, name: entry.Labels[model.MetricNameLabel]}
+	entries, ok := idx.names[nk]
+	if !ok {
+		entries = make(map[*Entry]struct{})
+		idx.names[nk] = entries
+	}
+	entries[entry] = struct{}{}
+}
+
+// removeName must be called with the write lock held.
+func (idx *Index) removeName(entry *Entry) {
+	nk := nameKey{prefix: entry.Prefix, name: entry.Labels[model.MetricNameLabel]}
+	delete(idx.names[nk], entry)
+	if len(idx.names[nk]) == 0 {
+		delete(idx.names, nk)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package index
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMatchers = []*prompb.LabelMatcher{
+	{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+	{Name: "owner", Type: prompb.LabelMatcher_RE, Value: "team-.*"},
+}
+
+func testMetric(owner string) model.Metric {
+	return model.Metric{model.MetricNameLabel: "test", "owner": model.LabelValue(owner)}
+}
+
+func TestTouchAndLookup(t *testing.T) {
+	idx, err := New("", time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	calls := 0
+	path := func(p string) func() string {
+		return func() string { calls++; return p }
+	}
+	idx.Touch(testMetric("team-X"), "prefix.", path("prefix.test.owner.team-X"))
+	idx.Touch(testMetric("team-X"), "prefix.", path("prefix.test.owner.team-X"))
+	idx.Touch(testMetric("other"), "prefix.", path("prefix.test.owner.other"))
+	idx.Touch(testMetric("team-Y"), "prefix.", path(""))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 3, idx.Len())
+
+	paths, ok, err := idx.Lookup("prefix.", testMatchers)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"prefix.test.owner.team-X"}, paths)
+
+	_, ok, err = idx.Lookup("other.", testMatchers)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = idx.Lookup("prefix.", testMatchers[1:])
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFlushExpiresAndPersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "index.json")
+	idx, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	now := time.Now()
+	idx.now = func() time.Time { return now.Add(-2 * time.Hour) }
+	idx.Touch(testMetric("team-Y"), "prefix.", func() string { return "prefix.test.owner.team-Y" })
+	idx.now = func() time.Time { return now }
+	idx.Touch(testMetric("team-X"), "prefix.", func() string { return "prefix.test.owner.team-X" })
+
+	paths, _, err := idx.Lookup("prefix.", testMatchers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefix.test.owner.team-X"}, paths)
+
+	require.NoError(t, idx.Close())
+	assert.Equal(t, 1, idx.Len())
+
+	reloaded, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.Equal(t, 1, reloaded.Len())
+	paths, ok, err := reloaded.Lookup("prefix.", testMatchers)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"prefix.test.owner.team-X"}, paths)
+}
+
+func TestCovers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "index.json")
+	idx, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	created := time.Now()
+	assert.True(t, idx.Covers(created.Add(time.Second)))
+	// Series written before the index was created are unknown.
+	assert.False(t, idx.Covers(created.Add(-time.Minute)))
+	require.NoError(t, idx.Close())
+
+	reloaded, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	reloaded.now = func() time.Time { return created.Add(3 * time.Hour) }
+	assert.False(t, reloaded.Covers(created.Add(time.Hour)), "before the retention")
+	assert.True(t, reloaded.Covers(created.Add(2*time.Hour+time.Minute)))
+	assert.Equal(t, idx.since, reloaded.since)
+
+	// Killed without Close, the series written after the last flush are
+	// missing, so the index only covers the series written after it is
+	// loaded again.
+	reloaded.since -= 600
+	require.NoError(t, reloaded.Flush())
+	killed, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.False(t, killed.Covers(time.Unix(reloaded.since+1, 0)))
+	assert.True(t, killed.Covers(time.Now().Add(time.Second)))
+
+	// Loading a clean snapshot marks it as not clean until the next Close.
+	killed.since -= 600
+	require.NoError(t, killed.Close())
+	closed, err := New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.True(t, closed.Covers(time.Unix(killed.since+1, 0)))
+	killed, err = New(file, time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.False(t, killed.Covers(time.Unix(closed.since+1, 0)))
+}
@@ -0,0 +1,297 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
+	"github.com/prometheus/common/model"
+	plabels "github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const (
+	autoCompleteTagsEndpoint   = "/tags/autoComplete/tags"
+	autoCompleteValuesEndpoint = "/tags/autoComplete/values"
+)
+
+// LabelNames implements the client.LabelReader interface.
+func (client *Client) LabelNames(ctx context.Context, start int64, matchers []*prompb.LabelMatcher, r *http.Request) ([]string, error) {
+	if client.cfg.Read.URL == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, client.readTimeout)
+	defer cancel()
+
+	graphitePrefix := client.cfg.StoragePrefixFromRequest(r)
+	matchers = client.translateMatchers(matchers)
+	names := make(map[string]struct{})
+
+	if client.readsTags() {
+		format := client.formatForPrefix(graphitePrefix)
+		tags, err := client.autoComplete(ctx, autoCompleteTagsEndpoint, nil, matchers, graphitePrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if tag == "name" {
+				names[model.MetricNameLabel] = struct{}{}
+			} else {
+				names[paths.UnescapeTagValue(tag, format)] = struct{}{}
+			}
+		}
+		// Graphite leaves out the tags of the expressions.
+		for _, m := range matchers {
+			if m.Type == prompb.LabelMatcher_EQ && m.Value != "" {
+				names[m.Name] = struct{}{}
+			}
+		}
+		return sortedKeys(names), nil
+	}
+
+	// Label names can't be listed without expanding the whole tree, the
+	// ones of the indexed series are only added to the metric name.
+	names[model.MetricNameLabel] = struct{}{}
+	series, _, err := client.seriesLabels(ctx, start, matchers, graphitePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range series {
+		for name := range m {
+			names[string(name)] = struct{}{}
+		}
+	}
+	return sortedKeys(names), nil
+}
+
+// LabelValues implements the client.LabelReader interface.
+func (client *Client) LabelValues(ctx context.Context, start int64, name string, matchers []*prompb.LabelMatcher, r *http.Request) ([]string, error) {
+	if client.cfg.Read.URL == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, client.readTimeout)
+	defer cancel()
+
+	graphitePrefix := client.cfg.StoragePrefixFromRequest(r)
+	matchers = client.translateMatchers(matchers)
+	if client.cfg.NameEscaping == config.NameEscapingUnderscores {
+		name = model.EscapeName(name, model.UnderscoreEscaping)
+	}
+	values := make(map[string]struct{})
+
+	if client.readsTags() {
+		format := client.formatForPrefix(graphitePrefix)
+		tag := "name"
+		if name != model.MetricNameLabel {
+			tag = paths.EscapeTagValue(name, format)
+		}
+		result, err := client.autoComplete(ctx, autoCompleteValuesEndpoint, map[string]string{"tag": tag}, matchers, graphitePrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range result {
+			if name == model.MetricNameLabel {
+				if !strings.HasPrefix(value, graphitePrefix) {
+					continue
+				}
+				value = graphite_tmpl.Unescape(strings.TrimPrefix(value, graphitePrefix))
+			} else {
+				value = paths.UnescapeTagValue(value, format)
+			}
+			values[value] = struct{}{}
+		}
+		return sortedKeys(values), nil
+	}
+
+	series, complete, err := client.seriesLabels(ctx, start, matchers, graphitePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range series {
+		if value, ok := m[model.LabelName(name)]; ok {
+			values[string(value)] = struct{}{}
+		}
+	}
+	if !complete && name == model.MetricNameLabel {
+		names, err := client.metricNames(ctx, matchers, graphitePrefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			values[n] = struct{}{}
+		}
+	}
+	return sortedKeys(values), nil
+}
+
+func (client *Client) translateMatchers(matchers []*prompb.LabelMatcher) []*prompb.LabelMatcher {
+	if client.cfg.NameEscaping != config.NameEscapingUnderscores {
+		return matchers
+	}
+	return paths.UnderscoreQueryNames(&prompb.Query{Matchers: matchers}).Matchers
+}
+
+// autoComplete calls a Graphite tags autocomplete endpoint for the series
+// matching matchers.
+func (client *Client) autoComplete(ctx context.Context, endpoint string, params map[string]string, matchers []*prompb.LabelMatcher, graphitePrefix string) ([]string, error) {
+	exprs, err := client.tagExpressions(matchers, graphitePrefix)
+	if err != nil {
+		return nil, err
+	}
+	hasName := false
+	for _, m := range matchers {
+		hasName = hasName || m.Name == model.MetricNameLabel
+	}
+	if !hasName && graphitePrefix != "" {
+		// Only look at the series of this prefix.
+		exprs = append(exprs, "name=~^"+regexp.QuoteMeta(graphitePrefix))
+	}
+
+	autoCompleteURL, err := PrepareURL(client.cfg.Read.URL, endpoint, params)
+	if err != nil {
+		client.logger.Warn("Error preparing URL", "graphite_web", client.cfg.Read.URL, "path", endpoint, "err", err)
+		return nil, err
+	}
+	query := autoCompleteURL.Query()
+	for _, expr := range exprs {
+		query.Add("expr", expr)
+	}
+	autoCompleteURL.RawQuery = query.Encode()
+
+	body, err := FetchURL(ctx, client.logger, autoCompleteURL)
+	if err != nil {
+		client.logger.Warn("Error fetching URL", "url", autoCompleteURL, "body", utils.TruncateString(string(body), 140)+"...", "err", err)
+		return nil, err
+	}
+
+	var result []string
+	if err := json.Unmarshal(body, &result); err != nil {
+		client.logger.Warn("Error parsing autocomplete endpoint response body", "url", autoCompleteURL, "body", utils.TruncateString(string(body), 140)+"...", "err", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// seriesLabels returns the labels of the series written since start matching
+// matchers, from the index and the expanded paths. The index alone is used
+// if it has all the series since start. The returned bool is false if only
+// the indexed series are listed, the others not being found without a
+// metric name.
+func (client *Client) seriesLabels(ctx context.Context, start int64, matchers []*prompb.LabelMatcher, graphitePrefix string) ([]model.Metric, bool, error) {
+	var series []model.Metric
+	if idx := client.seriesIndex(); idx != nil {
+		var err error
+		series, err = idx.Series(graphitePrefix, matchers)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(series) > 0 && client.indexCovers(start) {
+			return series, true, nil
+		}
+	}
+
+	hasName := false
+	for _, m := range matchers {
+		hasName = hasName || (m.Name == model.MetricNameLabel && m.Type == prompb.LabelMatcher_EQ)
+	}
+	if !hasName {
+		return series, false, nil
+	}
+
+	targets, err := client.QueryToTargets(ctx, &prompb.Query{StartTimestampMs: start, Matchers: matchers}, graphitePrefix)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, target := range targets {
+		labels, err := client.labelsFromPath(target, graphitePrefix)
+		if err != nil {
+			continue
+		}
+		m := make(model.Metric, len(labels))
+		for _, l := range labels {
+			m[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		series = append(series, m)
+	}
+	return series, true, nil
+}
+
+// metricNames returns the metric names found under the prefix and in the
+// read rules, filtered by the matchers on the name.
+func (client *Client) metricNames(ctx context.Context, matchers []*prompb.LabelMatcher, graphitePrefix string) ([]string, error) {
+	nodes, err := client.expand(ctx, graphitePrefix+"*", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var nameMatchers []*plabels.Matcher
+	for _, m := range matchers {
+		if m.Name != model.MetricNameLabel {
+			continue
+		}
+		matcher, err := plabels.NewMatcher(plabels.MatchType(m.Type), m.Name, m.Value)
+		if err != nil {
+			return nil, err
+		}
+		nameMatchers = append(nameMatchers, matcher)
+	}
+
+	candidates := make([]string, 0, len(nodes)+len(client.cfg.Read.Rules))
+	for _, node := range nodes {
+		name := strings.TrimPrefix(node, graphitePrefix)
+		if client.openMetricsPaths() {
+			name, _, _ = strings.Cut(name, "{")
+		}
+		candidates = append(candidates, graphite_tmpl.Unescape(name))
+	}
+	for _, rule := range client.cfg.Read.Rules {
+		if name, ok := rule.Labels[model.MetricNameLabel]; ok {
+			candidates = append(candidates, string(name))
+		}
+	}
+
+	names := make(map[string]struct{})
+	for _, name := range candidates {
+		match := true
+		for _, matcher := range nameMatchers {
+			match = match && matcher.Matches(name)
+		}
+		if match {
+			names[name] = struct{}{}
+		}
+	}
+	return sortedKeys(names), nil
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,208 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/index"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelDiscoveryWithTags(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	var exprs []string
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		exprs = u.Query()["expr"]
+		switch u.Path {
+		case autoCompleteTagsEndpoint:
+			return []byte(`["name","owner"]`), nil
+		case autoCompleteValuesEndpoint:
+			if u.Query().Get("tag") == "name" {
+				return []byte(`["other.test","prefix.test","prefix.test%2Eutf8"]`), nil
+			}
+			return []byte(`["team-X","team-Y"]`), nil
+		default:
+			return nil, fmt.Errorf("unexpected path %s", u.Path)
+		}
+	}
+
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			DefaultPrefix: "prefix.",
+			EnableTags:    true,
+			Read:          graphiteCfg.ReadConfig{URL: "http://localhost"},
+		},
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbonTags,
+		readTimeout: time.Minute,
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	names, err := client.LabelNames(context.Background(), 0, nil, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.MetricNameLabel, "owner"}, names)
+	assert.Equal(t, []string{`name=~^prefix\.`}, exprs)
+
+	values, err := client.LabelValues(context.Background(), 0, model.MetricNameLabel, nil, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test", "test.utf8"}, values)
+
+	values, err = client.LabelValues(context.Background(), 0, "owner", []*prompb.LabelMatcher{
+		{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+	}, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-X", "team-Y"}, values)
+	assert.Equal(t, []string{"name=prefix.test"}, exprs)
+}
+
+func TestLabelDiscoveryWithPaths(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		switch u.Query().Get("query") {
+		case "prefix.*":
+			return []byte(`{"results":["prefix.test","prefix.up"]}`), nil
+		case "prefix.test.**":
+			return []byte(`{"results":["prefix.test.owner.team-X","prefix.test.owner.team-Y"]}`), nil
+		default:
+			return nil, fmt.Errorf("unexpected query %s", u.Query().Get("query"))
+		}
+	}
+
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			DefaultPrefix: "prefix.",
+			Read:          graphiteCfg.ReadConfig{URL: "http://localhost"},
+		},
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbon,
+		readTimeout: time.Minute,
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	nameMatcher := []*prompb.LabelMatcher{
+		{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+	}
+
+	values, err := client.LabelValues(context.Background(), 0, model.MetricNameLabel, nil, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test", "up"}, values)
+
+	names, err := client.LabelNames(context.Background(), 0, nil, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.MetricNameLabel}, names)
+
+	names, err = client.LabelNames(context.Background(), 0, nameMatcher, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.MetricNameLabel, "owner"}, names)
+
+	values, err = client.LabelValues(context.Background(), 0, "owner", nameMatcher, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-X", "team-Y"}, values)
+}
+
+func TestLabelDiscoveryWithIndex(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	expanded := 0
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		switch u.Query().Get("query") {
+		case "prefix.*":
+			return []byte(`{"results":["prefix.test","prefix.old"]}`), nil
+		case "prefix.test.**":
+			expanded++
+			return []byte(`{"results":["prefix.test.owner.team-Y"]}`), nil
+		default:
+			return nil, fmt.Errorf("unexpected query %s", u.Query().Get("query"))
+		}
+	}
+
+	idx, err := index.New("", time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	idx.Touch(model.Metric{model.MetricNameLabel: "test", "owner": "team-X"}, "prefix.", func() string { return "prefix.test.owner.team-X" })
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			DefaultPrefix: "prefix.",
+			Read:          graphiteCfg.ReadConfig{URL: "http://localhost"},
+			Index:         graphiteCfg.IndexConfig{Exclusive: true},
+		},
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbon,
+		readTimeout: time.Minute,
+		index:       idx,
+	}
+	r := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	nameMatcher := []*prompb.LabelMatcher{
+		{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+	}
+
+	// The index has all the series written since now.
+	now := time.Now().UnixMilli()
+	values, err := client.LabelValues(context.Background(), now, "owner", nameMatcher, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-X"}, values)
+	assert.Zero(t, expanded)
+
+	// Older series are only known to Graphite.
+	values, err = client.LabelValues(context.Background(), 0, "owner", nameMatcher, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-X", "team-Y"}, values)
+	assert.Equal(t, 1, expanded)
+
+	values, err = client.LabelValues(context.Background(), 0, model.MetricNameLabel, nil, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "test"}, values)
+}
+
+func TestReadUsesRequestContext(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		return nil, ctx.Err()
+	}
+	client := &Client{
+		cfg:         &graphiteCfg.Config{Read: graphiteCfg.ReadConfig{URL: "http://localhost"}},
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbon,
+		readTimeout: time.Minute,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodPost, "http://example.com", nil).WithContext(ctx)
+	_, err := client.Read(&prompb.ReadRequest{Queries: []*prompb.Query{{
+		EndTimestampMs: time.Now().UnixMilli(),
+		Matchers:       []*prompb.LabelMatcher{{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"}},
+	}}}, r)
+	assert.ErrorIs(t, err, context.Canceled)
+}
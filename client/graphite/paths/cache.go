@@ -1,5 +1,5 @@
 // Copyright 2018 Thibault Chataigner <thibault.chataigner@gmail.com>
-// Copyright 2024-2025 NetCracker Technology Corporation
+// Copyright 2024-2026 NetCracker Technology Corporation
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -17,18 +17,214 @@
 package paths
 
 import (
+	"container/list"
+	"sync"
 	"time"
 
-	"github.com/patrickmn/go-cache"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 )
 
 var (
-	pathsCache        *cache.Cache
-	pathsCacheEnabled = false
+	cacheHits = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "paths_cache_hits_total",
+			Help:      "Total number of metrics whose paths were found in the paths cache.",
+		},
+	)
+	cacheMisses = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "paths_cache_misses_total",
+			Help:      "Total number of metrics whose paths were rendered, not being in the paths cache.",
+		},
+	)
+	cacheEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "paths_cache_evictions_total",
+			Help:      "Total number of entries evicted from the paths cache, by reason.",
+		},
+		[]string{"reason"},
+	)
 )
 
-// InitPathsCache inits cache for the paths.
-func InitPathsCache(pathsCacheTTL time.Duration, pathsCachePurgeInterval time.Duration) {
-	pathsCache = cache.New(pathsCacheTTL, pathsCachePurgeInterval)
-	pathsCacheEnabled = true
+type cacheKey struct {
+	prefix string
+	fp     model.Fingerprint
+}
+
+type cacheEntry struct {
+	key cacheKey
+	// metric is checked on hits, as fingerprints may collide.
+	metric  model.Metric
+	paths   [][]byte
+	reasons PathReasons
+	size    int64
+	expires time.Time
+}
+
+// Cache keeps the paths of the most recently written metrics, for the rules
+// of a client. It is bounded by a number of entries and an estimate of their
+// size in bytes, evicting the least recently used entries, and entries
+// expire after a TTL. Zero disables a bound or the TTL.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	now        func() time.Time
+
+	lock    sync.Mutex
+	lru     *list.List
+	entries map[cacheKey]*list.Element
+	bytes   int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCache returns a new Cache. Unless purgeInterval is zero, expired
+// entries are dropped every purgeInterval until Close is called.
+func NewCache(ttl, purgeInterval time.Duration, maxEntries int, maxBytes int64) *Cache {
+	c := &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+		lru:        list.New(),
+		entries:    make(map[cacheKey]*list.Element),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if purgeInterval > 0 && ttl > 0 {
+		go c.run(purgeInterval)
+	} else {
+		close(c.done)
+	}
+	return c
+}
+
+// NewCacheFromConfig returns the Cache configured by the write
+// configuration of a client, or nil if the paths cache is disabled.
+func NewCacheFromConfig(cfg *config.WriteConfig) *Cache {
+	if !cfg.EnablePathsCache {
+		return nil
+	}
+	return NewCache(cfg.PathsCacheTTL, cfg.PathsCachePurgeInterval, cfg.PathsCacheMaxEntries, cfg.PathsCacheMaxBytes)
+}
+
+func (c *Cache) run(purgeInterval time.Duration) {
+	defer close(c.done)
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.purgeExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close stops purging expired entries. It may be called more than once.
+func (c *Cache) Close() {
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.done
+}
+
+// Len returns the number of entries.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// Purge drops all the entries.
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.Init()
+	c.entries = make(map[cacheKey]*list.Element)
+	c.bytes = 0
+}
+
+// get returns the paths of m written with prefix and the reasons of the
+// rejected and repaired ones, if cached.
+func (c *Cache) get(m model.Metric, prefix string) ([][]byte, PathReasons, bool) {
+	key := cacheKey{prefix: prefix, fp: m.FastFingerprint()}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		cacheMisses.Inc()
+		return nil, PathReasons{}, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(e, "expired")
+		cacheMisses.Inc()
+		return nil, PathReasons{}, false
+	}
+	if !entry.metric.Equal(m) {
+		cacheMisses.Inc()
+		return nil, PathReasons{}, false
+	}
+	c.lru.MoveToFront(e)
+	cacheHits.Inc()
+	return entry.paths, entry.reasons, true
+}
+
+// set caches the paths of m written with prefix and the reasons of the
+// rejected and repaired ones, evicting the least recently used entries
+// beyond the bounds.
+func (c *Cache) set(m model.Metric, prefix string, paths [][]byte, reasons PathReasons) {
+	key := cacheKey{prefix: prefix, fp: m.FastFingerprint()}
+	entry := &cacheEntry{key: key, metric: m.Clone(), paths: paths, reasons: reasons, expires: c.now().Add(c.ttl)}
+	entry.size = int64(len(prefix))
+	for ln, lv := range m {
+		entry.size += int64(len(ln) + len(lv))
+	}
+	for _, path := range paths {
+		entry.size += int64(len(path))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		// An expired entry, or a collision replaced by the latest metric.
+		c.remove(e, "replaced")
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+	for (c.maxEntries > 0 && c.lru.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.lru.Back(), "size")
+	}
+}
+
+func (c *Cache) purgeExpired() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	for e := c.lru.Back(); e != nil; {
+		prev := e.Prev()
+		if !now.Before(e.Value.(*cacheEntry).expires) {
+			c.remove(e, "expired")
+		}
+		e = prev
+	}
+}
+
+func (c *Cache) remove(e *list.Element, reason string) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+	cacheEvictions.WithLabelValues(reason).Inc()
 }
@@ -0,0 +1,128 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cacheMetric(job string) model.Metric {
+	return model.Metric{model.MetricNameLabel: "up", "job": model.LabelValue(job)}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache(0, 0, 2, 0)
+	defer c.Close()
+
+	c.set(cacheMetric("a"), "prefix.", [][]byte{[]byte("a")}, PathReasons{})
+	c.set(cacheMetric("b"), "prefix.", [][]byte{[]byte("b")}, PathReasons{})
+	_, _, ok := c.get(cacheMetric("a"), "prefix.")
+	require.True(t, ok)
+	c.set(cacheMetric("c"), "prefix.", [][]byte{[]byte("c")}, PathReasons{})
+
+	assert.Equal(t, 2, c.Len())
+	_, _, ok = c.get(cacheMetric("b"), "prefix.")
+	assert.False(t, ok)
+	paths, _, ok := c.get(cacheMetric("a"), "prefix.")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("a")}, paths)
+	// The prefix is part of the key.
+	_, _, ok = c.get(cacheMetric("a"), "other.")
+	assert.False(t, ok)
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	// The labels, the prefix and the path: 31 bytes per entry.
+	c := NewCache(0, 0, 0, 70)
+	defer c.Close()
+
+	for _, job := range []string{"a", "b", "c"} {
+		c.set(cacheMetric(job), "prefix.", [][]byte{[]byte("0123456789")}, PathReasons{})
+	}
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int64(62), c.bytes)
+
+	// An entry over the limit isn't kept.
+	c.set(cacheMetric("d"), "prefix.", [][]byte{make([]byte, 100)}, PathReasons{})
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, int64(0), c.bytes)
+}
+
+func TestCacheExpires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewCache(time.Minute, 0, 0, 0)
+	c.now = func() time.Time { return now }
+	defer c.Close()
+
+	c.set(cacheMetric("a"), "prefix.", [][]byte{[]byte("a")}, PathReasons{})
+	now = now.Add(30 * time.Second)
+	c.set(cacheMetric("b"), "prefix.", [][]byte{[]byte("b")}, PathReasons{})
+	_, _, ok := c.get(cacheMetric("a"), "prefix.")
+	assert.True(t, ok)
+
+	now = now.Add(30 * time.Second)
+	_, _, ok = c.get(cacheMetric("a"), "prefix.")
+	assert.False(t, ok)
+	now = now.Add(30 * time.Second)
+	c.purgeExpired()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCacheChecksLabels(t *testing.T) {
+	c := NewCache(0, 0, 0, 0)
+	defer c.Close()
+
+	// A colliding fingerprint, simulated by storing another metric under the
+	// key of the one looked up.
+	c.set(cacheMetric("a"), "prefix.", [][]byte{[]byte("a")}, PathReasons{})
+	e := c.entries[cacheKey{prefix: "prefix.", fp: cacheMetric("a").FastFingerprint()}]
+	delete(c.entries, e.Value.(*cacheEntry).key)
+	e.Value.(*cacheEntry).key = cacheKey{prefix: "prefix.", fp: cacheMetric("b").FastFingerprint()}
+	c.entries[e.Value.(*cacheEntry).key] = e
+
+	_, _, ok := c.get(cacheMetric("b"), "prefix.")
+	assert.False(t, ok)
+	paths, _, err := pathsFromMetric(cacheMetric("b"), FormatCarbon, "prefix.", nil, nil, c)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("prefix.up.job.b")}, paths)
+	paths, _, ok = c.get(cacheMetric("b"), "prefix.")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{[]byte("prefix.up.job.b")}, paths)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	c := NewCache(0, 0, 0, 0)
+	defer c.Close()
+
+	cfg := loadTestConfig(`
+write:
+  rules:
+  - match:
+      job: a
+    template: '{{ replace .labels.missing "a" "b" }}'
+    continue: true`)
+	_, _, err := pathsFromMetric(cacheMetric("a"), FormatCarbon, "prefix.", cfg.Write.Rules, nil, c)
+	require.Error(t, err)
+	assert.Equal(t, 0, c.Len())
+}
@@ -16,6 +16,8 @@
 
 package paths
 
+import "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+
 // Format describes carbon format.
 type Format int
 
@@ -24,4 +26,22 @@ const (
 	FormatCarbon Format = iota + 1
 	FormatCarbonTags
 	FormatCarbonOpenMetrics
+	// FormatCarbonTagsPercent is FormatCarbonTags with reversible
+	// percent-encoding of the tag values.
+	FormatCarbonTagsPercent
 )
+
+// FormatForPrefix returns the format of the paths written with a storage
+// prefix and the config.
+func FormatForPrefix(cfg *config.Config, prefix string) Format {
+	switch {
+	case cfg.EnableTags && cfg.UseOpenMetricsFormat:
+		return FormatCarbonOpenMetrics
+	case cfg.EnableTags && cfg.TagEscapingForPrefix(prefix) == config.TagEscapingPercent:
+		return FormatCarbonTagsPercent
+	case cfg.EnableTags:
+		return FormatCarbonTags
+	default:
+		return FormatCarbon
+	}
+}
@@ -17,17 +17,24 @@
 package paths
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
 	"github.com/prometheus/common/model"
+	plabels "github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/prompb"
 )
 
+// wildcard is the value given to the labels of a read rule query which are
+// not fixed by an equality matcher.
+const wildcard = "*"
+
 // MetricLabelsFromTags provides labels for given tags.
-func MetricLabelsFromTags(tags map[string]string, prefix string) ([]prompb.Label, error) {
+func MetricLabelsFromTags(tags map[string]string, prefix string, format Format) ([]prompb.Label, error) {
 	// It translates Graphite tags directly into label and values.
 	var labels []prompb.Label
 	var names []string
@@ -41,16 +48,24 @@ func MetricLabelsFromTags(tags map[string]string, prefix string) ([]prompb.Label
 	for _, k := range names {
 		v := tags[k]
 		if k == "name" {
-			v = strings.TrimPrefix(v, prefix)
+			v = graphite_tmpl.Unescape(strings.TrimPrefix(v, prefix))
 			labels = append(labels, prompb.Label{Name: model.MetricNameLabel, Value: v})
 		} else {
-			labels = append(labels, prompb.Label{Name: k, Value: v})
+			labels = append(labels, prompb.Label{Name: UnescapeTagValue(k, format), Value: UnescapeTagValue(v, format)})
 		}
 	}
 
 	return labels, nil
 }
 
+// UnescapeTagValue reverses EscapeTagValue when the format allows it.
+func UnescapeTagValue(v string, format Format) string {
+	if format == FormatCarbonTagsPercent {
+		return graphite_tmpl.UnescapeTaggedPercent(v)
+	}
+	return v
+}
+
 // MetricLabelsFromPath provides labels from given path.
 func MetricLabelsFromPath(path string, prefix string) ([]prompb.Label, error) {
 	// It uses the "default" write format to read back (See defaultPath function)
@@ -59,7 +74,7 @@ func MetricLabelsFromPath(path string, prefix string) ([]prompb.Label, error) {
 	cleanedPath := strings.TrimPrefix(path, prefix)
 	cleanedPath = strings.Trim(cleanedPath, ".")
 	nodes := strings.Split(cleanedPath, ".")
-	labels = append(labels, prompb.Label{Name: model.MetricNameLabel, Value: nodes[0]})
+	labels = append(labels, prompb.Label{Name: model.MetricNameLabel, Value: graphite_tmpl.Unescape(nodes[0])})
 	if len(nodes[1:])%2 != 0 {
 		err := fmt.Errorf("unable to parse labels from path: odd number of nodes in path")
 		return nil, err
@@ -69,3 +84,193 @@ func MetricLabelsFromPath(path string, prefix string) ([]prompb.Label, error) {
 	}
 	return labels, nil
 }
+
+// MetricLabelsFromReadRules parses the labels of a path using the first read
+// rule whose regex matches it. The returned bool is false if no rule applies.
+func MetricLabelsFromReadRules(path string, rules []*config.ReadRule) ([]prompb.Label, bool) {
+	for _, rule := range rules {
+		submatches := rule.Regex.FindStringSubmatch(path)
+		if submatches == nil {
+			continue
+		}
+		labelMap := make(map[string]string, len(rule.Labels)+len(submatches))
+		for ln, lv := range rule.Labels {
+			labelMap[string(ln)] = string(lv)
+		}
+		for i, name := range rule.Regex.SubexpNames() {
+			if name == "" || submatches[i] == "" {
+				continue
+			}
+			labelMap[name] = graphite_tmpl.Unescape(submatches[i])
+		}
+
+		labels := make([]prompb.Label, 0, len(labelMap))
+		for ln, lv := range labelMap {
+			labels = append(labels, prompb.Label{Name: ln, Value: lv})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+		return labels, true
+	}
+	return nil, false
+}
+
+// QueriesFromReadRules renders the Graphite globs of the read rules which
+// may produce series matching all the given matchers.
+func QueriesFromReadRules(matchers []*prompb.LabelMatcher, rules []*config.ReadRule, templateData map[string]interface{}) ([]string, error) {
+	var queries []string
+	for _, rule := range rules {
+		m, applies, err := readRuleMetric(matchers, rule)
+		if err != nil {
+			return nil, err
+		}
+		if !applies {
+			continue
+		}
+
+		var query bytes.Buffer
+		if err := rule.Query.Execute(&query, loadContext(templateData, m)); err != nil {
+			return nil, err
+		}
+		queries = append(queries, query.String())
+	}
+	return queries, nil
+}
+
+// readRuleMetric builds the labels used to render the query of a read rule:
+// the escaped values of the equality matchers for the labels captured by the
+// regex, wildcard otherwise. It reports whether the rule may match the query at all.
+func readRuleMetric(matchers []*prompb.LabelMatcher, rule *config.ReadRule) (model.Metric, bool, error) {
+	m := make(model.Metric)
+	captured := make(map[string]bool)
+	for _, name := range rule.Regex.SubexpNames() {
+		if name != "" {
+			captured[name] = true
+			m[model.LabelName(name)] = wildcard
+		}
+	}
+
+	for _, pm := range matchers {
+		matcher, err := plabels.NewMatcher(plabels.MatchType(pm.Type), pm.Name, pm.Value)
+		if err != nil {
+			return nil, false, err
+		}
+		if lv, ok := rule.Labels[model.LabelName(pm.Name)]; ok {
+			if !matcher.Matches(string(lv)) {
+				return nil, false, nil
+			}
+			continue
+		}
+		if !captured[pm.Name] {
+			// The rule never produces this label.
+			if !matcher.Matches("") {
+				return nil, false, nil
+			}
+			continue
+		}
+		if pm.Type == prompb.LabelMatcher_EQ {
+			m[model.LabelName(pm.Name)] = model.LabelValue(globValue(pm.Value))
+		}
+	}
+	return m, true, nil
+}
+
+// globValue escapes a label value like the write templates, for a read rule
+// query.
+func globValue(v string) string {
+	return GlobLiteral(string(graphite_tmpl.Escape(v)))
+}
+
+// GlobLiteral returns a Graphite glob matching path, for the paths with
+// characters the glob or render target syntax give a meaning to, like the
+// braces and commas of OpenMetrics paths. They are replaced by '?', with the
+// backslash escaping them, so the glob may match other paths too, which the
+// callers filter out.
+func GlobLiteral(path string) string {
+	const special = `*?[]{}(),='"`
+	if !strings.ContainsAny(path, special+`\`) {
+		return path
+	}
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; {
+		case c == '\\' && i+1 < len(path) && path[i+1] != '.':
+			sb.WriteString("??")
+			i++
+		case c == '\\' || strings.IndexByte(special, c) != -1:
+			sb.WriteByte('?')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// MetricLabelsFromOpenMetricsPath parses the labels of a path written with
+// FormatCarbonOpenMetrics: <prefix><__name__>{<labelName>="<labelValue>",...}
+func MetricLabelsFromOpenMetricsPath(path string, prefix string) ([]prompb.Label, error) {
+	cleanedPath := strings.TrimPrefix(path, prefix)
+	open := indexUnescaped(cleanedPath, '{')
+	if open == -1 {
+		return []prompb.Label{{Name: model.MetricNameLabel, Value: graphite_tmpl.Unescape(cleanedPath)}}, nil
+	}
+	if !strings.HasSuffix(cleanedPath, "}") {
+		return nil, fmt.Errorf("unable to parse labels from path: missing closing brace")
+	}
+
+	labels := []prompb.Label{{Name: model.MetricNameLabel, Value: graphite_tmpl.Unescape(cleanedPath[:open])}}
+	body := cleanedPath[open+1 : len(cleanedPath)-1]
+	for len(body) > 0 {
+		eq := indexUnescaped(body, '=')
+		if eq == -1 || eq+1 >= len(body) || body[eq+1] != '"' {
+			return nil, fmt.Errorf("unable to parse labels from path: expected %s=\"<value>\"", body)
+		}
+		name := body[:eq]
+		body = body[eq+2:]
+
+		quote := indexUnescaped(body, '"')
+		if quote == -1 {
+			return nil, fmt.Errorf("unable to parse labels from path: unterminated value for label %s", name)
+		}
+		labels = append(labels, prompb.Label{Name: graphite_tmpl.Unescape(name), Value: graphite_tmpl.Unescape(body[:quote])})
+		body = body[quote+1:]
+
+		switch {
+		case len(body) == 0:
+		case body[0] == ',':
+			body = body[1:]
+		default:
+			return nil, fmt.Errorf("unable to parse labels from path: unexpected %q after label %s", body[0], name)
+		}
+	}
+	return labels, nil
+}
+
+// indexUnescaped returns the index of the first c in s which is not escaped
+// by a backslash, or -1.
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// UnderscoreQueryNames translates the names of a query like the ones of
+// written series with config.NameEscapingUnderscores.
+func UnderscoreQueryNames(query *prompb.Query) *prompb.Query {
+	translated := *query
+	translated.Matchers = make([]*prompb.LabelMatcher, 0, len(query.Matchers))
+	for _, m := range query.Matchers {
+		tm := *m
+		tm.Name = model.EscapeName(m.Name, model.UnderscoreEscaping)
+		if m.Name == model.MetricNameLabel && m.Type == prompb.LabelMatcher_EQ {
+			tm.Value = model.EscapeName(m.Value, model.UnderscoreEscaping)
+		}
+		translated.Matchers = append(translated.Matchers, &tm)
+	}
+	return &translated
+}
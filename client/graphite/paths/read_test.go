@@ -17,6 +17,7 @@
 package paths
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/prometheus/common/model"
@@ -24,6 +25,17 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// editorconfig-checker-disable used because next lines are part of the template
+const testReadRulesConfigStr = `
+read:
+  rules:
+  - labels:
+      __name__: host_service
+    query: 'great.graphite.path.host.{{.labels.owner}}.{{.labels.service}}'
+    regex: 'great\.graphite\.path\.host\.(?P<owner>[^.]+)\.(?P<service>[^.]+)'`
+
+// editorconfig-checker-enable
+
 func TestMetricLabelsFromPath(t *testing.T) {
 	path := "prometheus-prefix.test.owner.team-X"
 	prefix := "prometheus-prefix"
@@ -59,7 +71,175 @@ func TestMetricLabelsFromTags(t *testing.T) {
 		{Name: model.MetricNameLabel, Value: "metric"},
 		{Name: "owner", Value: "team-X"},
 	}
-	actualLabels, err := MetricLabelsFromTags(tags, prefix)
+	actualLabels, err := MetricLabelsFromTags(tags, prefix, FormatCarbonTags)
+	require.NoError(t, err)
+	require.Equal(t, expectedLabels, actualLabels)
+}
+
+func TestMetricLabelsFromReadRules(t *testing.T) {
+	cfg := loadTestConfig(testReadRulesConfigStr)
+	require.NotNil(t, cfg)
+
+	expectedLabels := []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "host_service"},
+		{Name: "owner", Value: "team-X"},
+		{Name: "service", Value: "foo.1"},
+	}
+	actualLabels, ok := MetricLabelsFromReadRules("great.graphite.path.host.team-X.foo%2E1", cfg.Read.Rules)
+	require.True(t, ok)
+	require.Equal(t, expectedLabels, actualLabels)
+
+	_, ok = MetricLabelsFromReadRules("prefix.test.owner.team-X", cfg.Read.Rules)
+	require.False(t, ok)
+}
+
+func TestQueriesFromReadRules(t *testing.T) {
+	cfg := loadTestConfig(testReadRulesConfigStr)
+	require.NotNil(t, cfg)
+
+	queries, err := QueriesFromReadRules([]*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "host_service"},
+		{Type: prompb.LabelMatcher_EQ, Name: "owner", Value: "team-X"},
+		{Type: prompb.LabelMatcher_RE, Name: "service", Value: "foo.*"},
+	}, cfg.Read.Rules, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"great.graphite.path.host.team-X.*"}, queries)
+
+	// The values are escaped, and their glob metacharacters only match
+	// themselves or another character.
+	queries, err = QueriesFromReadRules([]*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "host_service"},
+		{Type: prompb.LabelMatcher_EQ, Name: "owner", Value: "team.X*"},
+		{Type: prompb.LabelMatcher_EQ, Name: "service", Value: "{a,b}[0]?"},
+	}, cfg.Read.Rules, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{`great.graphite.path.host.team%2EX?.??a??b???0??`}, queries)
+	labels, ok := MetricLabelsFromReadRules(`great.graphite.path.host.team%2EX*.\{a\,b\}[0]?`, cfg.Read.Rules)
+	require.True(t, ok)
+	require.Contains(t, labels, prompb.Label{Name: "owner", Value: "team.X*"})
+	require.Contains(t, labels, prompb.Label{Name: "service", Value: "{a,b}[0]?"})
+
+	// Another metric name does not match the rule labels.
+	queries, err = QueriesFromReadRules([]*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "test"},
+	}, cfg.Read.Rules, nil)
+	require.NoError(t, err)
+	require.Empty(t, queries)
+
+	// The rule never produces an "env" label.
+	queries, err = QueriesFromReadRules([]*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "host_service"},
+		{Type: prompb.LabelMatcher_EQ, Name: "env", Value: "prod"},
+	}, cfg.Read.Rules, nil)
+	require.NoError(t, err)
+	require.Empty(t, queries)
+}
+
+func TestMetricLabelsFromOpenMetricsPath(t *testing.T) {
+	path := `prefix.test:metric{owner="team-X",path="%2Fvar\,log\"\\",empty=""}`
+	expectedLabels := []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "test:metric"},
+		{Name: "owner", Value: "team-X"},
+		{Name: "path", Value: `/var,log"\`},
+		{Name: "empty", Value: ""},
+	}
+	actualLabels, err := MetricLabelsFromOpenMetricsPath(path, "prefix.")
+	require.NoError(t, err)
+	require.Equal(t, expectedLabels, actualLabels)
+
+	actualLabels, err = MetricLabelsFromOpenMetricsPath("prefix.test", "prefix.")
+	require.NoError(t, err)
+	require.Equal(t, []prompb.Label{{Name: model.MetricNameLabel, Value: "test"}}, actualLabels)
+
+	for _, invalid := range []string{
+		`prefix.test{owner="team-X"`,
+		`prefix.test{owner=team-X}`,
+		`prefix.test{owner="team-X}`,
+		`prefix.test{owner="team-X"owner2="team-Y"}`,
+	} {
+		_, err = MetricLabelsFromOpenMetricsPath(invalid, "prefix.")
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestOpenMetricsPathRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"simple",
+		"with space",
+		`quote"inside`,
+		`back\slash`,
+		`\"`,
+		`a\\(`,
+		"{braces},commas=equals",
+		"dots.and/slashes",
+		"percent%25encoded%",
+		"ünïcödé ✓",
+		"tab\tnewline\n",
+		"(){},=.'\"\\",
+	}
+	for _, v := range values {
+		m := model.Metric{
+			model.MetricNameLabel: "test:metric",
+			"a":                   model.LabelValue(v),
+			"b":                   model.LabelValue(v + v),
+		}
+		path := defaultPath(m, FormatCarbonOpenMetrics, "prefix.")
+		labels, err := MetricLabelsFromOpenMetricsPath(string(path), "prefix.")
+		require.NoError(t, err, string(path))
+		require.Equal(t, []prompb.Label{
+			{Name: model.MetricNameLabel, Value: "test:metric"},
+			{Name: "a", Value: v},
+			{Name: "b", Value: v + v},
+		}, labels, string(path))
+	}
+}
+
+func TestMetricLabelsFromPercentTags(t *testing.T) {
+	tags := map[string]string{
+		"name":  "prefix.metric",
+		"owner": "team%20X%3Bprod",
+	}
+	expectedLabels := []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "metric"},
+		{Name: "owner", Value: "team X;prod"},
+	}
+	actualLabels, err := MetricLabelsFromTags(tags, "prefix.", FormatCarbonTagsPercent)
 	require.NoError(t, err)
 	require.Equal(t, expectedLabels, actualLabels)
 }
+
+func TestUTF8NamesRoundTrip(t *testing.T) {
+	m := model.Metric{
+		model.MetricNameLabel: "http.server.request.duration",
+		"service.name":        "checkout.v2",
+		"ünïcödé label":       "x=y;z",
+	}
+	expectedLabels := []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "http.server.request.duration"},
+		{Name: "service.name", Value: "checkout.v2"},
+		{Name: "ünïcödé label", Value: "x=y;z"},
+	}
+
+	path := defaultPath(m, FormatCarbon, "prefix.")
+	require.Equal(t, "prefix.http%2Eserver%2Erequest%2Eduration.service%2Ename.checkout%2Ev2.%C3%BCn%C3%AFc%C3%B6d%C3%A9%20label.x%3Dy;z", string(path))
+	labels, err := MetricLabelsFromPath(string(path), "prefix.")
+	require.NoError(t, err)
+	require.Equal(t, expectedLabels, labels)
+
+	path = defaultPath(m, FormatCarbonOpenMetrics, "prefix.")
+	labels, err = MetricLabelsFromOpenMetricsPath(string(path), "prefix.")
+	require.NoError(t, err)
+	require.Equal(t, expectedLabels, labels)
+
+	path = defaultPath(m, FormatCarbonTagsPercent, "prefix.")
+	nodes := strings.Split(string(path), ";")
+	tags := map[string]string{"name": nodes[0]}
+	for _, node := range nodes[1:] {
+		kv := strings.SplitN(node, "=", 2)
+		tags[kv[0]] = kv[1]
+	}
+	labels, err = MetricLabelsFromTags(tags, "prefix.", FormatCarbonTagsPercent)
+	require.NoError(t, err)
+	require.ElementsMatch(t, expectedLabels, labels)
+}
@@ -0,0 +1,282 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
+)
+
+// percentNonASCII matches a percent-encoded non-ASCII character: a leading
+// byte followed by its continuation bytes.
+const percentNonASCII = "%[C-F][0-9A-F](?:%[89AB][0-9A-F])+"
+
+// TagRegexp translates the regular expression of a matcher on a label value
+// to one matching the tag values written in format. With
+// FormatCarbonTagsPercent, the characters percent-encoded on write are
+// matched encoded, and an error is returned for the character classes which
+// can't be, the ones with only some non-ASCII characters. The expressions of
+// the other formats are returned as is.
+//
+// Either way, the returned expression has no double quote, so that it can be
+// quoted in a seriesByTag call.
+func TagRegexp(expr string, format Format) (string, error) {
+	percent := format == FormatCarbonTagsPercent
+	if !percent && !strings.Contains(expr, `"`) {
+		return expr, nil
+	}
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	w := &tagRegexpWriter{percent: percent}
+	if err := w.write(re); err != nil {
+		return "", fmt.Errorf("regular expression %q: %w", expr, err)
+	}
+	return w.b.String(), nil
+}
+
+// tagRegexpWriter writes a parsed regular expression with the syntax shared
+// by Go and Python, the latter being used by graphite-web.
+type tagRegexpWriter struct {
+	b       strings.Builder
+	percent bool
+}
+
+func (w *tagRegexpWriter) write(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		w.b.WriteString(`[^\s\S]`)
+	case syntax.OpEmptyMatch:
+		w.b.WriteString("(?:)")
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				if err := w.writeClass(foldRanges(r)); err != nil {
+					return err
+				}
+				continue
+			}
+			w.writeRune(r)
+		}
+	case syntax.OpCharClass:
+		return w.writeClass(re.Rune)
+	case syntax.OpAnyCharNotNL:
+		return w.writeClass([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune})
+	case syntax.OpAnyChar:
+		return w.writeClass([]rune{0, unicode.MaxRune})
+	// Tag values have no raw line breaks, lines are the whole text.
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		w.b.WriteString("^")
+	case syntax.OpEndLine, syntax.OpEndText:
+		w.b.WriteString("$")
+	case syntax.OpWordBoundary:
+		w.b.WriteString(`\b`)
+	case syntax.OpNoWordBoundary:
+		w.b.WriteString(`\B`)
+	case syntax.OpCapture:
+		return w.group(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if err := w.group(re.Sub[0]); err != nil {
+			return err
+		}
+		switch re.Op {
+		case syntax.OpStar:
+			w.b.WriteString("*")
+		case syntax.OpPlus:
+			w.b.WriteString("+")
+		case syntax.OpQuest:
+			w.b.WriteString("?")
+		case syntax.OpRepeat:
+			switch {
+			case re.Max == -1:
+				fmt.Fprintf(&w.b, "{%d,}", re.Min)
+			case re.Min == re.Max:
+				fmt.Fprintf(&w.b, "{%d}", re.Min)
+			default:
+				fmt.Fprintf(&w.b, "{%d,%d}", re.Min, re.Max)
+			}
+		}
+		if re.Flags&syntax.NonGreedy != 0 {
+			w.b.WriteString("?")
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := w.write(sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		w.b.WriteString("(?:")
+		for i, sub := range re.Sub {
+			if i > 0 {
+				w.b.WriteString("|")
+			}
+			if err := w.write(sub); err != nil {
+				return err
+			}
+		}
+		w.b.WriteString(")")
+	default:
+		return fmt.Errorf("unsupported operator %v", re.Op)
+	}
+	return nil
+}
+
+func (w *tagRegexpWriter) group(re *syntax.Regexp) error {
+	w.b.WriteString("(?:")
+	if err := w.write(re); err != nil {
+		return err
+	}
+	w.b.WriteString(")")
+	return nil
+}
+
+// writeRune writes a literal character, encoded as it is written with the
+// percent escaping.
+func (w *tagRegexpWriter) writeRune(r rune) {
+	if w.percent {
+		if encoded, ok := percentEncoded(r); ok {
+			// Only '%', digits and capital letters.
+			w.b.WriteString(encoded)
+			return
+		}
+	}
+	w.writeClassRune(r)
+}
+
+// writeClassRune writes a character, hex-escaped unless it is alphanumeric
+// or non-ASCII, which is valid both in and out of a character class.
+func (w *tagRegexpWriter) writeClassRune(r rune) {
+	switch {
+	case r >= utf8.RuneSelf:
+		w.b.WriteRune(r)
+	case r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z'):
+		w.b.WriteRune(r)
+	default:
+		fmt.Fprintf(&w.b, `\x%02X`, r)
+	}
+}
+
+// writeClass writes a character class given as pairs of ranges. With the
+// percent escaping, the characters written encoded are matched as
+// alternatives of their encodings.
+func (w *tagRegexpWriter) writeClass(ranges []rune) error {
+	if !w.percent {
+		w.writeRanges(ranges)
+		return nil
+	}
+
+	var plain []rune
+	var encoded []string
+	nonASCII := 0
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		for r := lo; r <= hi && r < utf8.RuneSelf; r++ {
+			switch e, ok := percentEncoded(r); {
+			case ok:
+				encoded = append(encoded, e)
+			case len(plain) > 0 && plain[len(plain)-1] == r-1:
+				plain[len(plain)-1] = r
+			default:
+				plain = append(plain, r, r)
+			}
+		}
+		if hi >= utf8.RuneSelf {
+			nonASCII += int(hi - max(lo, utf8.RuneSelf) + 1)
+		}
+	}
+	if nonASCII > 0 && nonASCII != unicode.MaxRune-utf8.RuneSelf+1 {
+		return fmt.Errorf("character classes with only some non-ASCII characters can't match percent-encoded tag values")
+	}
+
+	var alternatives []string
+	if len(plain) > 0 {
+		var class tagRegexpWriter
+		class.writeRanges(plain)
+		alternatives = append(alternatives, class.b.String())
+	}
+	alternatives = append(alternatives, groupEncoded(encoded)...)
+	if nonASCII > 0 {
+		alternatives = append(alternatives, percentNonASCII)
+	}
+	switch len(alternatives) {
+	case 0:
+		w.b.WriteString(`[^\s\S]`)
+	case 1:
+		w.b.WriteString(alternatives[0])
+	default:
+		w.b.WriteString("(?:" + strings.Join(alternatives, "|") + ")")
+	}
+	return nil
+}
+
+func (w *tagRegexpWriter) writeRanges(ranges []rune) {
+	if len(ranges) == 0 {
+		w.b.WriteString(`[^\s\S]`)
+		return
+	}
+	w.b.WriteString("[")
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		w.writeClassRune(lo)
+		if hi > lo {
+			w.b.WriteString("-")
+			w.writeClassRune(hi)
+		}
+	}
+	w.b.WriteString("]")
+}
+
+// percentEncoded returns the encoding of r with the percent escaping, and
+// whether it is encoded.
+func percentEncoded(r rune) (string, bool) {
+	encoded := string(graphite_tmpl.EscapeTaggedPercent(string(r)))
+	return encoded, encoded != string(r)
+}
+
+// groupEncoded groups percent-encoded ASCII characters by their first hex
+// digit: "%3B" and "%3D" are matched by "%3[BD]".
+func groupEncoded(encoded []string) []string {
+	var groups []string
+	for _, e := range encoded {
+		if n := len(groups); n > 0 && groups[n-1][1] == e[1] {
+			if last := groups[n-1]; last[len(last)-1] == ']' {
+				groups[n-1] = last[:len(last)-1] + e[2:] + "]"
+			} else {
+				groups[n-1] = last[:2] + "[" + last[2:] + e[2:] + "]"
+			}
+			continue
+		}
+		groups = append(groups, e)
+	}
+	return groups
+}
+
+// foldRanges returns the ranges of the characters equal to r under simple
+// case folding.
+func foldRanges(r rune) []rune {
+	ranges := []rune{r, r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		ranges = append(ranges, f, f)
+	}
+	return ranges
+}
@@ -0,0 +1,60 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"regexp"
+	"testing"
+
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRegexpPercent(t *testing.T) {
+	values := []string{"", "abc", "a;b", "a b", "a%b", "a=b~c", "A;B", "a\"b", "a\nb", "日本", "a日b", "%3B", "a_b"}
+	exprs := []string{
+		`abc`, `a;b`, `a b`, `a%b`, `(?i)a;b`, `a"b`, `a.b`, `a.*`, `.+;.+`, `[; ]`, `a[^x]b`, `[^;]*`,
+		`日.`, `.{2}`, `a(;|=)b~c`, `a\x{0A}b`, `(?s)a.b`, `%3B`, `\w+`, `a_?b`, `^a.*$`,
+	}
+	for _, expr := range exprs {
+		translated, err := TagRegexp(expr, FormatCarbonTagsPercent)
+		require.NoError(t, err, expr)
+		assert.NotContains(t, translated, `"`, expr)
+		original := regexp.MustCompile("^(?:" + expr + ")$")
+		encoded := regexp.MustCompile("^(?:" + translated + ")$")
+		for _, v := range values {
+			escaped := string(graphite_tmpl.EscapeTaggedPercent(v))
+			assert.Equal(t, original.MatchString(v), encoded.MatchString(escaped), "%s (%s) on %q (%s)", expr, translated, v, escaped)
+		}
+	}
+
+	_, err := TagRegexp(`[а-я]+`, FormatCarbonTagsPercent)
+	assert.Error(t, err)
+}
+
+func TestTagRegexpOtherFormats(t *testing.T) {
+	translated, err := TagRegexp(`us-.*`, FormatCarbonTags)
+	require.NoError(t, err)
+	assert.Equal(t, `us-.*`, translated)
+
+	translated, err = TagRegexp(`say "hi".*`, FormatCarbonTags)
+	require.NoError(t, err)
+	assert.NotContains(t, translated, `"`)
+	re := regexp.MustCompile("^(?:" + translated + ")$")
+	assert.True(t, re.MatchString(`say "hi" there`))
+	assert.False(t, re.MatchString(`say hi`))
+}
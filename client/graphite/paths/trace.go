@@ -0,0 +1,107 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/common/model"
+)
+
+// RuleTrace tells how a templating rule was evaluated for a metric.
+type RuleTrace struct {
+	// Rule is the index of the rule in the rules.
+	Rule     int  `json:"rule"`
+	Matched  bool `json:"matched"`
+	Continue bool `json:"continue"`
+	// Mismatches tells why match or match_re failed, one per label.
+	Mismatches []string `json:"mismatches,omitempty"`
+	// Dropped is set when the rule matched and has no template nor
+	// continue, silencing the metric.
+	Dropped  bool                   `json:"dropped,omitempty"`
+	Template string                 `json:"template,omitempty"`
+	Context  map[string]interface{} `json:"context,omitempty"`
+	Path     string                 `json:"path,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	// Rejected is the reason the rendered path isn't written, like a
+	// missing label or a path validation failure.
+	Rejected string `json:"rejected,omitempty"`
+	// Repaired are the reasons the rendered path was repaired.
+	Repaired []string `json:"repaired,omitempty"`
+}
+
+// Trace tells how the paths of a metric are rendered: the rules evaluated
+// in order, until one doesn't continue, and whether the default path is
+// appended.
+type Trace struct {
+	Labels              model.Metric `json:"labels"`
+	Prefix              string       `json:"prefix"`
+	Rules               []RuleTrace  `json:"rules"`
+	DefaultPathAppended bool         `json:"default_path_appended"`
+	// Paths are the paths written, none if the metric is dropped or a
+	// template failed.
+	Paths []string `json:"paths"`
+	Error string   `json:"error,omitempty"`
+}
+
+// TraceMetric renders the paths of m like ToDatapoints, recording how each
+// rule is evaluated.
+func TraceMetric(m model.Metric, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}) *Trace {
+	t := &Trace{Labels: m, Prefix: prefix, Rules: []RuleTrace{}, Paths: []string{}}
+	paths, _, stop, err := templatedPaths(m, rules, templateData, func(rt RuleTrace) {
+		t.Rules = append(t.Rules, rt)
+	})
+	if err != nil {
+		// The sample isn't written.
+		t.Error = err.Error()
+		return t
+	}
+	for _, path := range paths {
+		t.Paths = append(t.Paths, string(path))
+	}
+	if !stop {
+		t.DefaultPathAppended = true
+		t.Paths = append(t.Paths, string(defaultPath(m, format, prefix)))
+	}
+	return t
+}
+
+// mismatches tells why m doesn't match the labels of a rule, sorted by label.
+func mismatches(m model.Metric, match config.LabelSet, matchRE config.LabelSetRE) []string {
+	var reasons []string
+	for ln, lv := range match {
+		if v, ok := m[ln]; !ok {
+			reasons = append(reasons, fmt.Sprintf("match: label %s is not set, expected %q", ln, lv))
+		} else if v != lv {
+			reasons = append(reasons, fmt.Sprintf("match: label %s is %q, expected %q", ln, v, lv))
+		}
+	}
+	for ln, r := range matchRE {
+		if !r.MatchString(string(m[ln])) {
+			reasons = append(reasons, fmt.Sprintf("match_re: label %s is %q, not matching %s", ln, m[ln], r.String()))
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+func templateString(tmpl config.Template) string {
+	s, _ := tmpl.MarshalYAML()
+	str, _ := s.(string)
+	return str
+}
@@ -0,0 +1,107 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceMetric(t *testing.T) {
+	m := model.Metric{
+		model.MetricNameLabel: "test:metric",
+		"testlabel":           "test:value",
+		"owner":               "team-X",
+	}
+	trace := TraceMetric(m, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	require.Len(t, trace.Rules, 4)
+
+	assert.True(t, trace.Rules[0].Matched)
+	assert.True(t, trace.Rules[0].Continue)
+	assert.Equal(t, "tmpl_1.{{.shared | escape}}.{{.labels.owner}}", trace.Rules[0].Template)
+	assert.Equal(t, "tmpl_1.data%2Efoo.team-X", trace.Rules[0].Path)
+	assert.Equal(t, "data.foo", trace.Rules[0].Context["shared"])
+	assert.Equal(t, map[string]string{"__name__": "test:metric", "testlabel": "test:value", "owner": "team-X"}, trace.Rules[0].Context["labels"])
+
+	assert.False(t, trace.Rules[1].Matched)
+	assert.Equal(t, []string{`match: label testlabel2 is not set, expected "test:value2"`}, trace.Rules[1].Mismatches)
+	assert.Equal(t, []string{`match: label owner is "team-X", expected "team-Y"`}, trace.Rules[2].Mismatches)
+	assert.Empty(t, trace.Rules[3].Path)
+
+	assert.True(t, trace.DefaultPathAppended)
+	assert.Equal(t, []string{"tmpl_1.data%2Efoo.team-X", "prefix.test:metric.owner.team-X.testlabel.test:value"}, trace.Paths)
+	expected, _, err := pathsFromMetric(m, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
+	require.NoError(t, err)
+	for i, path := range expected {
+		assert.Equal(t, string(path), trace.Paths[i])
+	}
+
+	// The rules after a rule which doesn't continue aren't evaluated.
+	m["testlabel2"] = "test:value2"
+	trace = TraceMetric(m, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	require.Len(t, trace.Rules, 2)
+	assert.False(t, trace.DefaultPathAppended)
+	assert.Equal(t, []string{"tmpl_1.data%2Efoo.team-X", "tmpl_2.team-X.data.foo"}, trace.Paths)
+
+	// Dropped.
+	trace = TraceMetric(model.Metric{"__name__": "up", "owner": "team-Z", "testlabel": "x"}, FormatCarbon, "", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	require.Len(t, trace.Rules, 4)
+	assert.Equal(t, []string{`match: label owner is "team-Z", expected "team-X"`, `match_re: label testlabel is "x", not matching ^(?:^test:.*$)$`}, trace.Rules[0].Mismatches)
+	assert.True(t, trace.Rules[3].Dropped)
+	assert.False(t, trace.DefaultPathAppended)
+	assert.Empty(t, trace.Paths)
+}
+
+func TestTraceMetricError(t *testing.T) {
+	cfg := loadTestConfig(`
+write:
+  rules:
+  - match:
+      job: node
+    template: '{{index .labels.instance 5}}'
+`)
+	require.NotNil(t, cfg)
+	trace := TraceMetric(model.Metric{"__name__": "up", "job": "node", "instance": "a"}, FormatCarbon, "", cfg.Write.Rules, nil)
+	require.Len(t, trace.Rules, 1)
+	assert.Contains(t, trace.Rules[0].Error, "index out of range")
+	assert.NotEmpty(t, trace.Error)
+	assert.False(t, trace.DefaultPathAppended)
+	assert.Empty(t, trace.Paths)
+}
+
+func TestTraceMetricRejected(t *testing.T) {
+	cfg := loadTestConfig(`
+write:
+  path_validation:
+    action: reject
+  rules:
+  - template: 'nodes.{{ .labels.instance }}'
+    on_missing_label: drop
+    continue: true
+  - template: 'jobs..{{ .labels.job }}'
+`)
+	require.NotNil(t, cfg)
+	trace := TraceMetric(model.Metric{"__name__": "up", "job": "node"}, FormatCarbon, "", cfg.Write.Rules, nil)
+	require.Len(t, trace.Rules, 2)
+	assert.Equal(t, ReasonMissingLabel, trace.Rules[0].Rejected)
+	assert.Equal(t, ReasonEmptyNode, trace.Rules[1].Rejected)
+	assert.Empty(t, trace.Rules[1].Path)
+	assert.Empty(t, trace.Error)
+	assert.Empty(t, trace.Paths)
+}
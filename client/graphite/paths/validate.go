@@ -0,0 +1,163 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"bytes"
+	"unicode"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons of the paths rendered by the rules which are rejected or repaired.
+const (
+	// ReasonMissingLabel is a template using a label, or a template_data
+	// key, the metric doesn't have, with on_missing_label: drop.
+	ReasonMissingLabel = "missing_label"
+	// ReasonEmpty is a path without name, once repaired.
+	ReasonEmpty      = "empty"
+	ReasonEmptyNode  = "empty_node"
+	ReasonLeadingDot = "leading_dot"
+	// ReasonTrailingDot is a name ending with a dot, which may be followed
+	// by tags.
+	ReasonTrailingDot = "trailing_dot"
+	// ReasonIllegalCharacter is a whitespace, control character or slash.
+	ReasonIllegalCharacter = "illegal_character"
+	ReasonTooLong          = "too_long"
+)
+
+var (
+	rejectedPaths = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "rejected_paths_total",
+			Help:      "Total number of paths rendered by the rules which were rejected, by reason.",
+		},
+		[]string{"reason"},
+	)
+	repairedPaths = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "repaired_paths_total",
+			Help:      "Total number of paths rendered by the rules which were repaired, by reason.",
+		},
+		[]string{"reason"},
+	)
+)
+
+// PathReasons are the reasons of the paths of a metric rejected or repaired
+// by the rules.
+type PathReasons struct {
+	Rejected []string
+	Repaired []string
+}
+
+// Count adds the reasons to the rejected and repaired paths counters. It is
+// only called by the writers once the sample is sent, not by dry runs.
+func (r PathReasons) Count() {
+	for _, reason := range r.Rejected {
+		rejectedPaths.WithLabelValues(reason).Inc()
+	}
+	for _, reason := range r.Repaired {
+		repairedPaths.WithLabelValues(reason).Inc()
+	}
+}
+
+var (
+	dot    = []byte{'.'}
+	dotDot = []byte{'.', '.'}
+)
+
+// validatePath checks a path rendered by a rule against cfg. It returns the
+// path, repaired if the action allows it, and the reasons of the repairs, or
+// the reason rejecting it. Only the name is checked for dots, not the tags
+// following a ';'.
+func validatePath(path []byte, cfg config.PathValidationConfig) ([]byte, string, []string) {
+	if cfg.Action == "" {
+		return path, "", nil
+	}
+	var repaired []string
+	repair := func(reason string) bool {
+		if cfg.Action == config.PathValidationReject {
+			return false
+		}
+		repaired = append(repaired, reason)
+		return true
+	}
+
+	name, tags := path, []byte(nil)
+	if i := bytes.IndexByte(path, ';'); i >= 0 {
+		name, tags = path[:i], path[i:]
+	}
+	if bytes.IndexFunc(path, illegalRune) >= 0 {
+		if !repair(ReasonIllegalCharacter) {
+			return nil, ReasonIllegalCharacter, nil
+		}
+		name = bytes.Map(replaceIllegalRune, name)
+		tags = bytes.Map(replaceIllegalRune, tags)
+	}
+	if bytes.HasPrefix(name, dot) {
+		if !repair(ReasonLeadingDot) {
+			return nil, ReasonLeadingDot, nil
+		}
+		name = bytes.TrimLeft(name, ".")
+	}
+	if bytes.HasSuffix(name, dot) {
+		if !repair(ReasonTrailingDot) {
+			return nil, ReasonTrailingDot, nil
+		}
+		name = bytes.TrimRight(name, ".")
+	}
+	if bytes.Contains(name, dotDot) {
+		if !repair(ReasonEmptyNode) {
+			return nil, ReasonEmptyNode, nil
+		}
+		nodes := bytes.Split(name, dot)
+		kept := nodes[:0]
+		for _, node := range nodes {
+			if len(node) > 0 {
+				kept = append(kept, node)
+			}
+		}
+		name = bytes.Join(kept, dot)
+	}
+	if len(name) == 0 {
+		return nil, ReasonEmpty, repaired
+	}
+
+	if len(repaired) > 0 {
+		path = make([]byte, 0, len(name)+len(tags))
+		path = append(path, name...)
+		path = append(path, tags...)
+	}
+	if cfg.MaxLength > 0 && len(path) > cfg.MaxLength {
+		return nil, ReasonTooLong, repaired
+	}
+	return path, "", repaired
+}
+
+func illegalRune(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || r == '/'
+}
+
+func replaceIllegalRune(r rune) rune {
+	if illegalRune(r) {
+		return '_'
+	}
+	return r
+}
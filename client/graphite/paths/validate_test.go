@@ -0,0 +1,66 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package paths
+
+import (
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePath(t *testing.T) {
+	repair := config.PathValidationConfig{Action: config.PathValidationRepair, MaxLength: 20}
+	reject := config.PathValidationConfig{Action: config.PathValidationReject, MaxLength: 20}
+	for _, test := range []struct {
+		path     string
+		repaired string
+		repairs  []string
+		rejected string
+	}{
+		{path: "a.b.c", repaired: "a.b.c"},
+		{path: "a..b", repaired: "a.b", repairs: []string{ReasonEmptyNode}, rejected: ReasonEmptyNode},
+		{path: "..a.b", repaired: "a.b", repairs: []string{ReasonLeadingDot}, rejected: ReasonLeadingDot},
+		{path: "a.b.;tag=v.", repaired: "a.b;tag=v.", repairs: []string{ReasonTrailingDot}, rejected: ReasonTrailingDot},
+		{path: "a.<no value>/x\n", repaired: "a.<no_value>_x_", repairs: []string{ReasonIllegalCharacter}, rejected: ReasonIllegalCharacter},
+		{path: ". .", repaired: "_", repairs: []string{ReasonIllegalCharacter, ReasonLeadingDot, ReasonTrailingDot}, rejected: ReasonIllegalCharacter},
+	} {
+		path, rejected, repairs := validatePath([]byte(test.path), repair)
+		assert.Equal(t, test.repaired, string(path), test.path)
+		assert.Empty(t, rejected, test.path)
+		assert.Equal(t, test.repairs, repairs, test.path)
+
+		path, rejected, _ = validatePath([]byte(test.path), reject)
+		assert.Equal(t, test.rejected, rejected, test.path)
+		if rejected == "" {
+			assert.Equal(t, test.path, string(path))
+		}
+	}
+
+	for _, cfg := range []config.PathValidationConfig{repair, reject} {
+		for path, reason := range map[string]string{"": ReasonEmpty, "a.very.long.path.over.the.limit": ReasonTooLong} {
+			_, rejected, _ := validatePath([]byte(path), cfg)
+			assert.Equal(t, reason, rejected, path)
+		}
+	}
+	_, rejected, _ := validatePath([]byte(".."), repair)
+	assert.Equal(t, ReasonEmpty, rejected)
+
+	path, rejected, repairs := validatePath([]byte("a..b c"), config.PathValidationConfig{})
+	assert.Equal(t, "a..b c", string(path))
+	assert.Empty(t, rejected)
+	assert.Empty(t, repairs)
+}
@@ -22,24 +22,27 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	graphitetmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
-	"github.com/patrickmn/go-cache"
 	"github.com/prometheus/common/model"
 )
 
-// ToDatapoints builds points from samples.
-func ToDatapoints(s *model.Sample, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}) ([][]byte, error) {
+// ToDatapoints builds points from samples, with the reasons of the paths
+// rejected or repaired by the rules, see PathReasons.Count. The paths are
+// looked up in cache first, unless it is nil.
+func ToDatapoints(s *model.Sample, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}, cache *Cache) ([][]byte, PathReasons, error) {
 	t := float64(s.Timestamp.UnixNano()) / 1e9
 	v := float64(s.Value)
 	if math.IsNaN(v) || math.IsInf(v, 0) {
-		return nil, errors.New("invalid sample value")
+		return nil, PathReasons{}, errors.New("invalid sample value")
 	}
 
-	paths, err := pathsFromMetric(s.Metric, format, prefix, rules, templateData)
+	paths, reasons, err := pathsFromMetric(s.Metric, format, prefix, rules, templateData, cache)
 	if err != nil {
-		return nil, err
+		return nil, reasons, err
 	}
 
 	dataPoints := make([][]byte, 0, len(paths))
@@ -49,81 +52,196 @@ func ToDatapoints(s *model.Sample, format Format, prefix string, rules []*config
 	//math.MaxFloat64 + 0 precision symbols
 	tmBuf := make([]byte, 0, 309)
 	tm := strconv.AppendFloat(tmBuf, t, 'f', 0, 64)
+	// The points share a buffer, but not its capacity, so that appending
+	// to one doesn't overwrite the next.
 	var length int
 	for i := range paths {
-		pathLength := len(paths[i])
-		if pathLength > length {
-			length = pathLength
-		}
+		length += len(paths[i]) + len(val) + len(tm) + 3
 	}
-	buf := bytes.NewBuffer(make([]byte, 0, length+len(val)+len(tm)+3))
+	buf := make([]byte, 0, length)
 
 	for _, path := range paths {
-		buf.Write(path)
-		buf.WriteByte(' ')
-		buf.Write(val)
-		buf.WriteByte(' ')
-		buf.Write(tm)
-		buf.WriteByte('\n')
-		dataPoints = append(dataPoints, buf.Bytes())
-		buf.Reset()
-	}
-	return dataPoints, nil
+		start := len(buf)
+		buf = append(buf, path...)
+		buf = append(buf, ' ')
+		buf = append(buf, val...)
+		buf = append(buf, ' ')
+		buf = append(buf, tm...)
+		buf = append(buf, '\n')
+		dataPoints = append(dataPoints, buf[start:len(buf):len(buf)])
+	}
+	return dataPoints, reasons, nil
 }
 
-func pathsFromMetric(m model.Metric, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}) ([][]byte, error) {
-	var fingerPrint string
-	if pathsCacheEnabled {
-		ffp := m.FastFingerprint()
-		//math.MaxUint64
-		buf := make([]byte, 0, 16)
-		fingerPrintBYtes := strconv.AppendUint(buf, uint64(ffp), 16)
-		fingerPrint = string(fingerPrintBYtes)
-		cachedPaths, cached := pathsCache.Get(fingerPrint)
-		if cached {
-			return cachedPaths.([][]byte), nil
+func pathsFromMetric(m model.Metric, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}, cache *Cache) ([][]byte, PathReasons, error) {
+	// The prefix selects both the path and its format.
+	if cache != nil {
+		if cachedPaths, reasons, cached := cache.get(m, prefix); cached {
+			return cachedPaths, reasons, nil
 		}
 	}
-	paths, stop, err := templatedPaths(m, rules, templateData)
+	paths, reasons, stop, err := templatedPaths(m, rules, templateData, nil)
 	// if it doesn't match any rule, use default path
 	if !stop {
 		paths = append(paths, defaultPath(m, format, prefix))
 	}
-	if pathsCacheEnabled {
-		pathsCache.Set(fingerPrint, paths, cache.DefaultExpiration)
+	if cache != nil && err == nil {
+		cache.set(m, prefix, paths, reasons)
 	}
-	return paths, err
+	return paths, reasons, err
 }
 
-func templatedPaths(m model.Metric, rules []*config.Rule, templateData map[string]interface{}) ([][]byte, bool, error) {
+// templatedPaths renders the paths of the rules matching m, with the reasons
+// of the rejected and repaired ones. If set, trace is called with each rule
+// evaluated.
+func templatedPaths(m model.Metric, rules []*config.Rule, templateData map[string]interface{}, trace func(RuleTrace)) ([][]byte, PathReasons, bool, error) {
 	var paths [][]byte
+	var reasons PathReasons
 	var stop = false
 	var err error
-	for _, rule := range rules {
+	for i, rule := range rules {
 		ruleMatch := match(m, rule.Match, rule.MatchRE)
 		if !ruleMatch {
+			if trace != nil {
+				trace(RuleTrace{Rule: i, Continue: rule.Continue, Mismatches: mismatches(m, rule.Match, rule.MatchRE)})
+			}
 			continue
 		}
 		// We have a rule to silence this metric
 		if !rule.Continue && (rule.Tmpl == config.Template{}) {
-			return nil, true, nil
+			if trace != nil {
+				trace(RuleTrace{Rule: i, Matched: true, Dropped: true})
+			}
+			return nil, reasons, true, nil
 		}
 
 		context := loadContext(templateData, m)
 		stop = !rule.Continue
+		if rule.OnMissingLabel == config.OnMissingLabelDefault {
+			labels := context["labels"].(map[string]string)
+			for _, ln := range rule.Labels() {
+				if _, ok := labels[ln]; !ok {
+					labels[ln] = rule.MissingLabelValue
+				}
+			}
+		}
 		var path bytes.Buffer
+		var rejected string
+		var repaired []string
 		err = rule.Tmpl.Execute(&path, context)
+		if err != nil && rule.OnMissingLabel == config.OnMissingLabelDrop && isMissingKey(err) {
+			err = nil
+			rejected = ReasonMissingLabel
+		}
+		valid := path.Bytes()
+		if err == nil && rejected == "" {
+			valid, rejected, repaired = validatePath(valid, rule.PathValidation)
+		}
+		if trace != nil {
+			rt := RuleTrace{Rule: i, Matched: true, Continue: rule.Continue, Template: templateString(rule.Tmpl), Context: context,
+				Rejected: rejected, Repaired: repaired}
+			if err != nil {
+				rt.Error = err.Error()
+			} else if rejected == "" {
+				rt.Path = string(valid)
+			}
+			trace(rt)
+		}
 		if err != nil {
 			// We had an error processing the template so we break the loop
 			break
 		}
-		paths = append(paths, path.Bytes())
+		if rejected != "" {
+			reasons.Rejected = append(reasons.Rejected, rejected)
+		} else {
+			reasons.Repaired = append(reasons.Repaired, repaired...)
+			paths = append(paths, valid)
+		}
 
 		if !rule.Continue {
 			break
 		}
 	}
-	return paths, stop, err
+	return paths, reasons, stop, err
+}
+
+// isMissingKey reports whether err is a template failing on a key missing
+// from a map, with the missingkey=error option.
+func isMissingKey(err error) bool {
+	var execErr template.ExecError
+	return errors.As(err, &execErr) && strings.Contains(execErr.Err.Error(), "map has no entry for key")
+}
+
+// RulePath is a path of a metric, and the index in the rules of the rule
+// rendering it, or -1 for the default path.
+type RulePath struct {
+	Path string
+	Rule int
+}
+
+// RulePaths returns the paths ToDatapoints writes m to, with the rules
+// rendering them.
+func RulePaths(m model.Metric, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}) ([]RulePath, error) {
+	var indices []int
+	paths, _, stop, err := templatedPaths(m, rules, templateData, func(rt RuleTrace) {
+		if rt.Matched && !rt.Dropped && rt.Error == "" && rt.Rejected == "" {
+			indices = append(indices, rt.Rule)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	rulePaths := make([]RulePath, 0, len(paths)+1)
+	for i, path := range paths {
+		rulePaths = append(rulePaths, RulePath{Path: string(path), Rule: indices[i]})
+	}
+	if !stop {
+		rulePaths = append(rulePaths, RulePath{Path: string(defaultPath(m, format, prefix)), Rule: -1})
+	}
+	return rulePaths, nil
+}
+
+// UnderscoreNames translates the metric and label names of m which are not
+// valid in the legacy Prometheus charset, replacing invalid characters by '_'.
+// m is returned as is if all its names are valid.
+func UnderscoreNames(m model.Metric) model.Metric {
+	translate := func(name string) string {
+		return model.EscapeName(name, model.UnderscoreEscaping)
+	}
+	valid := translate(string(m[model.MetricNameLabel])) == string(m[model.MetricNameLabel])
+	for ln := range m {
+		valid = valid && translate(string(ln)) == string(ln)
+	}
+	if valid {
+		return m
+	}
+
+	translated := make(model.Metric, len(m))
+	for ln, lv := range m {
+		if ln == model.MetricNameLabel {
+			lv = model.LabelValue(translate(string(lv)))
+		}
+		translated[model.LabelName(translate(string(ln)))] = lv
+	}
+	return translated
+}
+
+// EscapeTagValue escapes a label name or value as written in tagged paths of
+// the given format.
+func EscapeTagValue(v string, format Format) string {
+	if format == FormatCarbonTagsPercent {
+		return string(graphitetmpl.EscapeTaggedPercent(v))
+	}
+	return string(graphitetmpl.EscapeTagged(v))
+}
+
+// DefaultPath returns the path m is written to when no templating rule stops
+// it, or an empty string if it is only written to templated paths.
+func DefaultPath(m model.Metric, format Format, prefix string, rules []*config.Rule, templateData map[string]interface{}) string {
+	if _, _, stop, _ := templatedPaths(m, rules, templateData, nil); stop {
+		return ""
+	}
+	return string(defaultPath(m, format, prefix))
 }
 
 func defaultPath(m model.Metric, format Format, prefix string) []byte {
@@ -146,7 +264,6 @@ func defaultPath(m model.Metric, format Format, prefix string) []byte {
 			continue
 		}
 
-		k := []byte(l)
 		v := string(m[l])
 		switch format {
 		case FormatCarbonOpenMetrics:
@@ -154,26 +271,24 @@ func defaultPath(m model.Metric, format Format, prefix string) []byte {
 			if !first {
 				lbuffer.WriteByte(',')
 			}
-			lbuffer.Write(k)
+			lbuffer.Write(graphitetmpl.Escape(string(l)))
 			lbuffer.WriteByte('=')
 			lbuffer.WriteByte('"')
 			val := graphitetmpl.Escape(v)
 			lbuffer.Write(val)
 			lbuffer.WriteByte('"')
-		case FormatCarbonTags:
+		case FormatCarbonTags, FormatCarbonTagsPercent:
 			// See http://graphite.readthedocs.io/en/latest/tags.html
 			lbuffer.WriteByte(';')
-			lbuffer.Write(k)
+			lbuffer.WriteString(EscapeTagValue(string(l), format))
 			lbuffer.WriteByte('=')
-			val := graphitetmpl.EscapeTagged(v)
-			lbuffer.Write(val)
+			lbuffer.WriteString(EscapeTagValue(v, format))
 		default:
 			// For each label, in order, add ".<label>.<value>".
-			// Since we use '.' instead of '=' to separate label and values
-			// it means that we can't have an '.' in the metric name. Fortunately
-			// this is prohibited in prometheus metrics.
+			// Since we use '.' instead of '=' to separate label and values,
+			// the '.' allowed in UTF-8 names is escaped like in values.
 			lbuffer.WriteByte('.')
-			lbuffer.Write(k)
+			lbuffer.Write(graphitetmpl.Escape(string(l)))
 			lbuffer.WriteByte('.')
 			val := graphitetmpl.Escape(v)
 			lbuffer.Write(val)
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -83,7 +84,7 @@ func TestDefaultPathsFromMetric(t *testing.T) {
 		".many_chars.abc!ABC:012-3!45%C3%B667~89%2E%2F\\(\\)\\{\\}\\,%3D%2E\\\"\\\\" +
 		".owner.team-X" +
 		".testlabel.test:value"
-	actual, err := pathsFromMetric(metric, FormatCarbon, "prefix.", nil, nil)
+	actual, _, err := pathsFromMetric(metric, FormatCarbon, "prefix.", nil, nil, nil)
 	require.Equal(t, expected, string(actual[0]))
 	require.Empty(t, err)
 
@@ -96,7 +97,17 @@ func TestDefaultPathsFromMetric(t *testing.T) {
 		";owner=team-X" +
 		";testlabel=test:value"
 
-	actual, err = pathsFromMetric(metric, FormatCarbonTags, "prefix.", nil, nil)
+	actual, _, err = pathsFromMetric(metric, FormatCarbonTags, "prefix.", nil, nil, nil)
+	require.Equal(t, expected, string(actual[0]))
+	require.Empty(t, err)
+
+	expected = "prefix." +
+		"test:metric" +
+		";many_chars=abc!ABC:012-3!45%C3%B667%7E89./(){},%3D.\"\\" +
+		";owner=team-X" +
+		";testlabel=test:value"
+
+	actual, _, err = pathsFromMetric(metric, FormatCarbonTagsPercent, "prefix.", nil, nil, nil)
 	require.Equal(t, expected, string(actual[0]))
 	require.Empty(t, err)
 
@@ -106,7 +117,7 @@ func TestDefaultPathsFromMetric(t *testing.T) {
 		",owner=\"team-X\"" +
 		",testlabel=\"test:value\"" +
 		"}"
-	actual, err = pathsFromMetric(metric, FormatCarbonOpenMetrics, "prefix.", nil, nil)
+	actual, _, err = pathsFromMetric(metric, FormatCarbonOpenMetrics, "prefix.", nil, nil, nil)
 	require.Equal(t, expected, string(actual[0]))
 	require.Empty(t, err)
 }
@@ -124,7 +135,7 @@ func TestUnmatchedMetricPathsFromMetric(t *testing.T) {
 		".owner.team-K"+
 		".testlabel.test:value"+
 		".testlabel2.test:value2"))
-	actual, err := pathsFromMetric(unmatchedMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	actual, _, err := pathsFromMetric(unmatchedMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
 	require.Equal(t, expected, actual)
 	require.Empty(t, err)
 }
@@ -132,7 +143,7 @@ func TestUnmatchedMetricPathsFromMetric(t *testing.T) {
 func TestTemplatedPathsFromMetric(t *testing.T) {
 	expected := make([][]byte, 0)
 	expected = append(expected, []byte("tmpl_3.team-Y.data.foo"))
-	actual, err := pathsFromMetric(metricY, FormatCarbon, "", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	actual, _, err := pathsFromMetric(metricY, FormatCarbon, "", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
 	require.Equal(t, expected, actual)
 	require.Empty(t, err)
 }
@@ -145,7 +156,7 @@ func TestTemplatedPathsFromMetricWithDefault(t *testing.T) {
 		".many_chars.abc!ABC:012-3!45%C3%B667~89%2E%2F\\(\\)\\{\\}\\,%3D%2E\\\"\\\\"+
 		".owner.team-X"+
 		".testlabel.test:value"))
-	actual, err := pathsFromMetric(metric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	actual, _, err := pathsFromMetric(metric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
 	require.Equal(t, expected, actual)
 	require.Empty(t, err)
 }
@@ -160,11 +171,28 @@ func TestMultiTemplatedPathsFromMetric(t *testing.T) {
 	expected := make([][]byte, 0)
 	expected = append(expected, []byte("tmpl_1.data%2Efoo.team-X"))
 	expected = append(expected, []byte("tmpl_2.team-X.data.foo"))
-	actual, err := pathsFromMetric(multiMatchMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	actual, _, err := pathsFromMetric(multiMatchMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
 	require.Equal(t, expected, actual)
 	require.Empty(t, err)
 }
 
+func TestRulePaths(t *testing.T) {
+	multiMatchMetric := model.Metric{
+		model.MetricNameLabel: "test:metric",
+		"testlabel":           "test:value",
+		"owner":               "team-X",
+		"testlabel2":          "test:value2",
+	}
+	actual, err := RulePaths(multiMatchMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	require.NoError(t, err)
+	require.Equal(t, []RulePath{{"tmpl_1.data%2Efoo.team-X", 0}, {"tmpl_2.team-X.data.foo", 1}}, actual)
+
+	unmatchedMetric := model.Metric{model.MetricNameLabel: "test:metric", "owner": "team-K"}
+	actual, err = RulePaths(unmatchedMetric, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	require.NoError(t, err)
+	require.Equal(t, []RulePath{{"prefix.test:metric.owner.team-K", -1}}, actual)
+}
+
 func TestSkipedTemplatedPathsFromMetric(t *testing.T) {
 	skipedMetric := model.Metric{
 		model.MetricNameLabel: "test:metric",
@@ -173,7 +201,7 @@ func TestSkipedTemplatedPathsFromMetric(t *testing.T) {
 		"testlabel2":          "test:value2",
 	}
 	t.Log(testConfig.Write.Rules[2])
-	actual, err := pathsFromMetric(skipedMetric, FormatCarbon, "", testConfig.Write.Rules, testConfig.Write.TemplateData)
+	actual, _, err := pathsFromMetric(skipedMetric, FormatCarbon, "", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
 	require.Empty(t, actual)
 	require.Empty(t, err)
 }
@@ -191,18 +219,111 @@ write:
 	testConfigNilLabel := loadTestConfig(testConfigNilLabelStr)
 
 	t.Log(testConfigNilLabel.Write.Rules[0])
-	actual, err := pathsFromMetric(metric, FormatCarbon, "", testConfigNilLabel.Write.Rules, testConfigNilLabel.Write.TemplateData)
+	actual, _, err := pathsFromMetric(metric, FormatCarbon, "", testConfigNilLabel.Write.Rules, testConfigNilLabel.Write.TemplateData, nil)
 	require.Empty(t, actual)
 	require.Error(t, err)
 }
 
+func TestOnMissingLabelTemplatedPathsFromMetric(t *testing.T) {
+	// editorconfig-checker-disable used because next lines are part of the template
+	testConfigMissingLabel := loadTestConfig(`
+write:
+  rules:
+  - match:
+      owner: team-X
+    template: 'error.{{ .labels.doesnotexist }}'
+    on_missing_label: error
+    continue: true
+  - match:
+      owner: team-Y
+    template: 'drop.{{ .labels.doesnotexist }}'
+    on_missing_label: drop
+  - match:
+      owner: team-Z
+    template: 'default.{{ .labels.owner }}.{{ .labels.doesnotexist }}'
+    on_missing_label: default
+    missing_label_value: unknown`)
+	// editorconfig-checker-enable
+
+	rules := testConfigMissingLabel.Write.Rules
+	_, _, err := pathsFromMetric(metric, FormatCarbon, "", rules, nil, nil)
+	require.ErrorContains(t, err, "map has no entry for key")
+
+	// The rule doesn't continue, so the default path isn't appended either.
+	actual, _, err := pathsFromMetric(metricY, FormatCarbon, "", rules, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+
+	actual, _, err = pathsFromMetric(model.Metric{model.MetricNameLabel: "up", "owner": "team-Z"}, FormatCarbon, "", rules, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("default.team-Z.unknown")}, actual)
+}
+
+func TestPathValidationTemplatedPathsFromMetric(t *testing.T) {
+	for action, expected := range map[string][][]byte{
+		"repair": {[]byte("tmpl.team-X.a_b"), []byte("test:metric.name.a%20b.owner.team-X")},
+		"reject": {[]byte("test:metric.name.a%20b.owner.team-X")},
+	} {
+		// editorconfig-checker-disable used because next lines are part of the template
+		cfg := loadTestConfig(`
+write:
+  path_validation:
+    action: ` + action + `
+  rules:
+  - template: 'tmpl..{{ .labels.owner }}.{{ .labels.name }}.'
+    continue: true`)
+		// editorconfig-checker-enable
+		m := model.Metric{model.MetricNameLabel: "test:metric", "owner": "team-X", "name": "a b"}
+		actual, _, err := pathsFromMetric(m, FormatCarbon, "", cfg.Write.Rules, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, action)
+	}
+}
+
+func TestPathReasonsNotCountedOnRender(t *testing.T) {
+	// editorconfig-checker-disable used because next lines are part of the template
+	cfg := loadTestConfig(`
+write:
+  rules:
+  - template: 'tmpl.{{ .labels.missing }}'
+    on_missing_label: drop
+    continue: true`)
+	// editorconfig-checker-enable
+	m := model.Metric{model.MetricNameLabel: "test:metric", "owner": "team-X"}
+	rejected := func() float64 {
+		return testutil.ToFloat64(rejectedPaths.WithLabelValues(ReasonMissingLabel))
+	}
+	before := rejected()
+
+	TraceMetric(m, FormatCarbon, "", cfg.Write.Rules, nil)
+	_, err := RulePaths(m, FormatCarbon, "", cfg.Write.Rules, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, DefaultPath(m, FormatCarbon, "", cfg.Write.Rules, nil))
+
+	// The cached paths keep their reasons, counted by the writers only.
+	cache := NewCache(0, 0, 0, 0)
+	defer cache.Close()
+	sample := &model.Sample{Metric: m, Value: 1, Timestamp: model.TimeFromUnix(1234567890)}
+	for i := 0; i < 2; i++ {
+		points, reasons, err := ToDatapoints(sample, FormatCarbon, "", cfg.Write.Rules, nil, cache)
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("test:metric.owner.team-X 1.000000 1234567890\n")}, points)
+		require.Equal(t, PathReasons{Rejected: []string{ReasonMissingLabel}}, reasons)
+	}
+	require.Equal(t, 1, cache.Len())
+	require.Equal(t, before, rejected())
+
+	PathReasons{Rejected: []string{ReasonMissingLabel}}.Count()
+	require.Equal(t, before+1, rejected())
+}
+
 func TestToDatapoints(t *testing.T) {
 	sample := &model.Sample{
 		Metric:    metric,
 		Value:     42.5,
 		Timestamp: model.TimeFromUnix(1234567890),
 	}
-	points, err := ToDatapoints(sample, FormatCarbon, "", nil, nil)
+	points, _, err := ToDatapoints(sample, FormatCarbon, "", nil, nil, nil)
 	require.NoError(t, err)
 	require.NotEmpty(t, points)
 	// Check that points contain the value
@@ -212,12 +333,41 @@ func TestToDatapoints(t *testing.T) {
 	}
 }
 
+func TestToDatapointsMultiplePaths(t *testing.T) {
+	sample := &model.Sample{
+		Metric: model.Metric{
+			model.MetricNameLabel: "test:metric",
+			"testlabel":           "test:value",
+			"owner":               "team-X",
+		},
+		Value:     1,
+		Timestamp: model.TimeFromUnix(1234567890),
+	}
+	points, _, err := ToDatapoints(sample, FormatCarbon, "prefix.", testConfig.Write.Rules, testConfig.Write.TemplateData, nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{
+		[]byte("tmpl_1.data%2Efoo.team-X 1.000000 1234567890\n"),
+		[]byte("prefix.test:metric.owner.team-X.testlabel.test:value 1.000000 1234567890\n"),
+	}, points)
+}
+
 func TestToDatapointsInvalidValue(t *testing.T) {
 	sample := &model.Sample{
 		Metric:    metric,
 		Value:     model.SampleValue(math.NaN()),
 		Timestamp: model.Time(1234567890),
 	}
-	_, err := ToDatapoints(sample, FormatCarbon, "", nil, nil)
+	_, _, err := ToDatapoints(sample, FormatCarbon, "", nil, nil, nil)
 	require.Error(t, err)
 }
+
+func TestUnderscoreNames(t *testing.T) {
+	legacy := model.Metric{model.MetricNameLabel: "test:metric", "owner": "team.X"}
+	require.Equal(t, legacy, UnderscoreNames(legacy))
+
+	m := model.Metric{model.MetricNameLabel: "http.server.duration", "service.name": "checkout.v2"}
+	expected := model.Metric{model.MetricNameLabel: "http_server_duration", "service_name": "checkout.v2"}
+	require.Equal(t, expected, UnderscoreNames(m))
+	// The original metric is left untouched.
+	require.Equal(t, model.LabelValue("http.server.duration"), m[model.MetricNameLabel])
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,7 +29,9 @@ import (
 
 	"context"
 
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
 	"github.com/prometheus/common/model"
 	plabels "github.com/prometheus/prometheus/pkg/labels"
@@ -45,14 +48,78 @@ func (client *Client) QueryToTargets(ctx context.Context, query *prompb.Query, g
 		}
 	}
 
-	if name == "" {
+	// Paths written by templating rules can only be found with read rules.
+	queries, err := paths.QueriesFromReadRules(query.Matchers, client.cfg.Read.Rules, client.cfg.Write.TemplateData)
+	if err != nil {
+		client.logger.Warn("Error rendering read rules queries", "err", err)
+		return nil, err
+	}
+
+	// Series known to the index don't need to be expanded, if it has all
+	// the series of the query: the ones written by this adapter only, since
+	// the start of the query.
+	var indexed []string
+	var inIndex bool
+	if idx := client.seriesIndex(); name != "" && idx != nil {
+		indexed, inIndex, err = idx.Lookup(graphitePrefix, query.Matchers)
+		if err != nil {
+			return nil, err
+		}
+		inIndex = inIndex && client.indexCovers(query.StartTimestampMs)
+	}
+
+	if name != "" && !inIndex {
+		escapedName := string(graphite_tmpl.Escape(name))
+		if client.openMetricsPaths() {
+			// Labels are part of the last node: name{...}
+			queries = append(queries, graphitePrefix+escapedName+"*")
+		} else {
+			queries = append(queries, graphitePrefix+escapedName+".**")
+		}
+	}
+
+	if len(queries) == 0 && !inIndex {
 		err := fmt.Errorf("invalid remote query: no %s label provided", model.MetricNameLabel)
 		return nil, err
 	}
 
+	expanded := indexed
+	seen := make(map[string]struct{}, len(indexed))
+	for _, path := range indexed {
+		seen[path] = struct{}{}
+	}
+	for _, queryStr := range queries {
+		results, err := client.expand(ctx, queryStr, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if _, ok := seen[result]; ok {
+				continue
+			}
+			seen[result] = struct{}{}
+			expanded = append(expanded, result)
+		}
+	}
+
+	targets, err := client.filterTargets(query, expanded, graphitePrefix)
+	return targets, err
+}
+
+// indexCovers reports whether the index has all the series written since
+// the start of a query, so that Graphite needn't be searched.
+func (client *Client) indexCovers(startMs int64) bool {
+	return client.cfg.Index.Exclusive && client.index.Covers(time.UnixMilli(startMs))
+}
+
+// expand returns the nodes matching a Graphite glob, or only the leaves.
+func (client *Client) expand(ctx context.Context, queryStr string, leavesOnly bool) ([]string, error) {
+	params := map[string]string{"format": "json", "query": queryStr}
+	if leavesOnly {
+		params["leavesOnly"] = "1"
+	}
 	// Prepare the url to fetch
-	queryStr := graphitePrefix + name + ".**"
-	expandURL, err := PrepareURL(client.cfg.Read.URL, expandEndpoint, map[string]string{"format": "json", "leavesOnly": "1", "query": queryStr})
+	expandURL, err := PrepareURL(client.cfg.Read.URL, expandEndpoint, params)
 	if err != nil {
 		client.logger.Warn("Error preparing URL", "graphite_web", client.cfg.Read.URL, "path", expandEndpoint, "err", err)
 		return nil, err
@@ -71,41 +138,119 @@ func (client *Client) QueryToTargets(ctx context.Context, query *prompb.Query, g
 		client.logger.Warn("Error parsing expand endpoint response body", "url", expandURL, "body", utils.TruncateString(string(body), 140)+"...", "err", err)
 		return nil, err
 	}
+	return expandResponse.Results, nil
+}
 
-	targets, err := client.filterTargets(query, expandResponse.Results, graphitePrefix)
-	return targets, err
+// labelsFromPath parses the labels of a path, using the read rules first and
+// the layout of the write format otherwise.
+func (client *Client) labelsFromPath(path string, graphitePrefix string) ([]prompb.Label, error) {
+	if client.cfg == nil {
+		return paths.MetricLabelsFromPath(path, graphitePrefix)
+	}
+	if labels, ok := paths.MetricLabelsFromReadRules(path, client.cfg.Read.Rules); ok {
+		return labels, nil
+	}
+	if client.openMetricsPaths() {
+		return paths.MetricLabelsFromOpenMetricsPath(path, graphitePrefix)
+	}
+	return paths.MetricLabelsFromPath(path, graphitePrefix)
+}
+
+// openMetricsPaths reports whether series are written as OpenMetrics-style
+// paths, which Graphite does not index as tags.
+func (client *Client) openMetricsPaths() bool {
+	return client.cfg.EnableTags && client.cfg.UseOpenMetricsFormat
+}
+
+// readsTags reports whether series are read back with seriesByTag.
+func (client *Client) readsTags() bool {
+	return client.cfg.EnableTags && !client.cfg.UseOpenMetricsFormat
 }
 
 func (client *Client) QueryToTargetsWithTags(ctx context.Context, query *prompb.Query, graphitePrefix string) ([]string, error) {
-	var tagSet []string
+	exprs, err := client.tagExpressions(query.Matchers, graphitePrefix)
+	if err != nil {
+		return nil, err
+	}
+	tagSet := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		tagSet = append(tagSet, "\""+expr+"\"")
+	}
 
-	for _, m := range query.Matchers {
+	targets := []string{"seriesByTag(" + strings.Join(tagSet, ",") + ")"}
+	return targets, nil
+}
+
+// tagExpressions translates matchers to Graphite tag expressions. They have
+// no double quote, so that they can be quoted in a seriesByTag call.
+func (client *Client) tagExpressions(matchers []*prompb.LabelMatcher, graphitePrefix string) ([]string, error) {
+	var exprs []string
+	format := client.formatForPrefix(graphitePrefix)
+
+	for _, m := range matchers {
 		var name string
 		var value string
+		var err error
 		if m.Name == model.MetricNameLabel {
 			name = "name"
 			value = graphitePrefix + m.Value
+			switch m.Type {
+			case prompb.LabelMatcher_EQ, prompb.LabelMatcher_NEQ:
+				value = graphitePrefix + string(graphite_tmpl.Escape(m.Value))
+			case prompb.LabelMatcher_RE, prompb.LabelMatcher_NRE:
+				value, err = paths.TagRegexp(value, paths.FormatCarbonTags)
+			}
 		} else {
-			name = m.Name
+			name = paths.EscapeTagValue(m.Name, format)
 			value = m.Value
+			// Values are compared to the stored ones. Only the percent
+			// escaping can be matched exactly, the values of the other
+			// ones are compared as is.
+			switch m.Type {
+			case prompb.LabelMatcher_EQ, prompb.LabelMatcher_NEQ:
+				if format == paths.FormatCarbonTagsPercent {
+					value = paths.EscapeTagValue(value, format)
+				}
+			case prompb.LabelMatcher_RE, prompb.LabelMatcher_NRE:
+				value, err = paths.TagRegexp(value, format)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(name, `"`) {
+			return nil, fmt.Errorf("label name %q can't be queried with seriesByTag", m.Name)
 		}
 
-		switch m.Type {
+		matchType := m.Type
+		if strings.Contains(value, `"`) {
+			// Equality matchers on values with a quote are sent as regular
+			// expressions, which can match it escaped.
+			value, err = paths.TagRegexp(regexp.QuoteMeta(value), paths.FormatCarbonTags)
+			if err != nil {
+				return nil, err
+			}
+			if matchType == prompb.LabelMatcher_EQ {
+				matchType = prompb.LabelMatcher_RE
+			} else {
+				matchType = prompb.LabelMatcher_NRE
+			}
+		}
+
+		switch matchType {
 		case prompb.LabelMatcher_EQ:
-			tagSet = append(tagSet, "\""+name+"="+value+"\"")
+			exprs = append(exprs, name+"="+value)
 		case prompb.LabelMatcher_NEQ:
-			tagSet = append(tagSet, "\""+name+"!="+value+"\"")
+			exprs = append(exprs, name+"!="+value)
 		case prompb.LabelMatcher_RE:
-			tagSet = append(tagSet, "\""+name+"=~^("+value+")$\"")
+			exprs = append(exprs, name+"=~^("+value+")$")
 		case prompb.LabelMatcher_NRE:
-			tagSet = append(tagSet, "\""+name+"!=~^("+value+")$\"")
+			exprs = append(exprs, name+"!=~^("+value+")$")
 		default:
 			return nil, fmt.Errorf("unknown match type %v", m.Type)
 		}
 	}
-
-	targets := []string{"seriesByTag(" + strings.Join(tagSet, ",") + ")"}
-	return targets, nil
+	return exprs, nil
 }
 
 func (client *Client) filterTargets(query *prompb.Query, targets []string, graphitePrefix string) ([]string, error) {
@@ -113,7 +258,7 @@ func (client *Client) filterTargets(query *prompb.Query, targets []string, graph
 	var results []string
 	for _, target := range targets {
 		// Put labels in a map.
-		prompbLabels, err := paths.MetricLabelsFromPath(target, graphitePrefix)
+		prompbLabels, err := client.labelsFromPath(target, graphitePrefix)
 		if err != nil {
 			client.logger.Warn("Error parsing metric labels from path", "path", target, "prefix", graphitePrefix, "err", err)
 			continue
@@ -149,8 +294,15 @@ func (client *Client) filterTargets(query *prompb.Query, targets []string, graph
 	return results, nil
 }
 
+// TargetToTimeseries renders a target. The braces, commas and quotes of
+// OpenMetrics paths mean something else to Graphite, so these paths are
+// rendered with a glob matching them, keeping the series of the path only.
 func (client *Client) TargetToTimeseries(ctx context.Context, target string, from string, until string, graphitePrefix string) ([]*prompb.TimeSeries, error) {
-	renderURL, err := PrepareURL(client.cfg.Read.URL, renderEndpoint, map[string]string{"format": "json", "from": from, "until": until, "target": target})
+	renderTarget := target
+	if client.openMetricsPaths() {
+		renderTarget = paths.GlobLiteral(target)
+	}
+	renderURL, err := PrepareURL(client.cfg.Read.URL, renderEndpoint, map[string]string{"format": "json", "from": from, "until": until, "target": renderTarget})
 	if err != nil {
 		client.logger.Warn("Error preparing URL", "graphite_web", client.cfg.Read.URL, "path", renderEndpoint, "err", err)
 		return nil, err
@@ -169,14 +321,17 @@ func (client *Client) TargetToTimeseries(ctx context.Context, target string, fro
 		return nil, err
 	}
 
-	ret := make([]*prompb.TimeSeries, len(renderResponses))
-	for i, renderResponse := range renderResponses {
+	ret := make([]*prompb.TimeSeries, 0, len(renderResponses))
+	for _, renderResponse := range renderResponses {
+		if renderTarget != target && renderResponse.Target != target {
+			continue
+		}
 		ts := &prompb.TimeSeries{}
 
-		if client.cfg.EnableTags {
-			ts.Labels, err = paths.MetricLabelsFromTags(renderResponse.Tags, graphitePrefix)
+		if client.readsTags() {
+			ts.Labels, err = paths.MetricLabelsFromTags(renderResponse.Tags, graphitePrefix, client.formatForPrefix(graphitePrefix))
 		} else {
-			ts.Labels, err = paths.MetricLabelsFromPath(renderResponse.Target, graphitePrefix)
+			ts.Labels, err = client.labelsFromPath(renderResponse.Target, graphitePrefix)
 		}
 
 		if err != nil {
@@ -185,8 +340,7 @@ func (client *Client) TargetToTimeseries(ctx context.Context, target string, fro
 		}
 
 		ts.Samples = samplesFromDatapoints(renderResponse.Datapoints, client.cfg.Read.MaxPointDelta)
-
-		ret[i] = ts
+		ret = append(ret, ts)
 	}
 	return ret, nil
 }
@@ -249,15 +403,7 @@ func (client *Client) handleReadQuery(ctx context.Context, query *prompb.Query,
 	fromStr := strconv.Itoa(from)
 	untilStr := strconv.Itoa(until)
 
-	var targets []string
-	var err error
-
-	if client.cfg.EnableTags {
-		targets, err = client.QueryToTargetsWithTags(ctx, query, graphitePrefix)
-	} else {
-		// If we don't have tags we try to emulate then with normal paths.
-		targets, err = client.QueryToTargets(ctx, query, graphitePrefix)
-	}
+	targets, err := client.Targets(ctx, query, graphitePrefix)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +414,21 @@ func (client *Client) handleReadQuery(ctx context.Context, query *prompb.Query,
 
 }
 
+// Targets returns the Graphite targets rendering the series of the query,
+// with QueryToTargetsWithTags or QueryToTargets depending on how series are
+// read back.
+func (client *Client) Targets(ctx context.Context, query *prompb.Query, graphitePrefix string) ([]string, error) {
+	if client.cfg.NameEscaping == graphiteCfg.NameEscapingUnderscores {
+		query = paths.UnderscoreQueryNames(query)
+	}
+
+	if client.readsTags() {
+		return client.QueryToTargetsWithTags(ctx, query, graphitePrefix)
+	}
+	// If we don't have tags we try to emulate then with normal paths.
+	return client.QueryToTargets(ctx, query, graphitePrefix)
+}
+
 func (client *Client) fetchData(ctx context.Context, queryResult *prompb.QueryResult, targets []string, fromStr string, untilStr string, graphitePrefix string) {
 	input := make(chan string, len(targets))
 	output := make(chan *prompb.TimeSeries, len(targets)+1)
@@ -335,7 +496,7 @@ func (client *Client) Read(req *prompb.ReadRequest, r *http.Request) (*prompb.Re
 		return nil, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), client.readTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), client.readTimeout)
 	defer cancel()
 
 	graphitePrefix := client.cfg.StoragePrefixFromRequest(r)
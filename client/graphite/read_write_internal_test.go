@@ -17,23 +17,30 @@ package graphite
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"log/slog"
 
 	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/index"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func makeSample(metricName string, timestamp int64, value float64) *model.Sample {
@@ -65,8 +72,9 @@ func TestPrepareWriteSplitsUDPBuffers(t *testing.T) {
 		samples = append(samples, makeSample("test", int64(1+i), float64(i)))
 	}
 
-	buffers, err := client.prepareWrite(samples, 256, req)
+	write, err := client.prepareWrite(samples, 256, req)
 	require.NoError(t, err)
+	buffers := write.buffers
 	require.Greater(t, len(buffers), 1)
 	for _, buf := range buffers {
 		assert.NotZero(t, buf.Len())
@@ -255,3 +263,426 @@ func TestQueryToTargetsWithTagsUnknownMatchType(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown match type")
 }
+
+func TestReadWithReadRules(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	cfg := &graphiteCfg.Config{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+read:
+  url: http://localhost
+  rules:
+  - labels:
+      __name__: host_service
+    query: 'great.graphite.path.host.{{.labels.owner}}.*'
+    regex: 'great\.graphite\.path\.host\.(?P<owner>[^.]+)\.(?P<service>[^.]+)'`), cfg))
+
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		switch u.Path {
+		case expandEndpoint:
+			if u.Query().Get("query") != "great.graphite.path.host.team-X.*" {
+				return []byte(`{"results":[]}`), nil
+			}
+			return []byte(`{"results":["great.graphite.path.host.team-X.foo","great.graphite.path.host.team-X.bar"]}`), nil
+		case renderEndpoint:
+			return []byte(`[{"target":"` + u.Query().Get("target") + `","datapoints":[[1.0,123]]}]`), nil
+		default:
+			return nil, fmt.Errorf("unexpected path %s", u.Path)
+		}
+	}
+
+	client := &Client{
+		cfg:         cfg,
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbon,
+		readTimeout: 5 * time.Second,
+	}
+
+	now := time.Now().Unix()
+	query := &prompb.Query{
+		StartTimestampMs: (now - 10) * 1000,
+		EndTimestampMs:   now * 1000,
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "host_service"},
+			{Name: "owner", Type: prompb.LabelMatcher_EQ, Value: "team-X"},
+			{Name: "service", Type: prompb.LabelMatcher_EQ, Value: "foo"},
+		},
+	}
+
+	resp, err := client.Read(&prompb.ReadRequest{Queries: []*prompb.Query{query}}, httptest.NewRequest(http.MethodPost, "http://example.com", nil))
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Len(t, resp.Results[0].Timeseries, 1)
+	assert.Equal(t, []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "host_service"},
+		{Name: "owner", Value: "team-X"},
+		{Name: "service", Value: "foo"},
+	}, resp.Results[0].Timeseries[0].Labels)
+}
+
+// graphiteRender returns the paths a graphite-web render target matches: the
+// symbols of its grammar must be backslash-escaped in paths, and the globs
+// have the fnmatch syntax, where the backslash has no special meaning.
+func graphiteRender(target string, paths []string) ([]string, error) {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(target); i++ {
+		switch c := target[i]; {
+		case c == '\\' && i+1 < len(target):
+			re.WriteString(regexp.QuoteMeta(target[i : i+2]))
+			i++
+		case strings.IndexByte(`(){},='"`, c) != -1:
+			return nil, fmt.Errorf("invalid target %s", target)
+		case c == '*':
+			re.WriteString(`[^.]*`)
+		case c == '?':
+			re.WriteString(`[^.]`)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	var matched []string
+	for _, path := range paths {
+		if regexp.MustCompile(re.String()).MatchString(path) {
+			matched = append(matched, path)
+		}
+	}
+	return matched, nil
+}
+
+func TestReadOpenMetricsPaths(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		switch u.Path {
+		case expandEndpoint:
+			if u.Query().Get("query") != "prefix.test*" {
+				return nil, fmt.Errorf("unexpected query %s", u.Query().Get("query"))
+			}
+			return []byte(`{"results":["prefix.test{owner=\"team-X\"}","prefix.test{owner=\"team-Y\"}","prefix.test2"]}`), nil
+		case renderEndpoint:
+			targets, err := graphiteRender(u.Query().Get("target"), []string{
+				`prefix.test{owner="team-X"}`, `prefix.test{owner="team-Y"}`, `prefix.test{owner=_team-X_}`,
+			})
+			if err != nil {
+				return nil, err
+			}
+			var resp []string
+			for _, target := range targets {
+				name, _ := json.Marshal(target)
+				resp = append(resp, `{"target":`+string(name)+`,"datapoints":[[1.0,123]]}`)
+			}
+			return []byte("[" + strings.Join(resp, ",") + "]"), nil
+		default:
+			return nil, fmt.Errorf("unexpected path %s", u.Path)
+		}
+	}
+
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			Read:                 graphiteCfg.ReadConfig{URL: "http://localhost"},
+			DefaultPrefix:        "prefix.",
+			EnableTags:           true,
+			UseOpenMetricsFormat: true,
+		},
+		logger:      slog.New(slog.DiscardHandler),
+		format:      paths.FormatCarbonOpenMetrics,
+		readTimeout: 5 * time.Second,
+	}
+
+	now := time.Now().Unix()
+	query := &prompb.Query{
+		StartTimestampMs: (now - 10) * 1000,
+		EndTimestampMs:   now * 1000,
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+			{Name: "owner", Type: prompb.LabelMatcher_EQ, Value: "team-X"},
+		},
+	}
+
+	targets, err := client.QueryToTargets(context.Background(), query, "prefix.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`prefix.test{owner="team-X"}`}, targets)
+
+	resp, err := client.Read(&prompb.ReadRequest{Queries: []*prompb.Query{query}}, httptest.NewRequest(http.MethodPost, "http://example.com", nil))
+	require.NoError(t, err)
+	require.Len(t, resp.Results[0].Timeseries, 1)
+	assert.Equal(t, []prompb.Label{
+		{Name: model.MetricNameLabel, Value: "test"},
+		{Name: "owner", Value: "team-X"},
+	}, resp.Results[0].Timeseries[0].Labels)
+}
+
+func TestPrepareWriteTagEscapingPerPrefix(t *testing.T) {
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			EnableTags: true,
+			TagEscapingPrefixes: map[string]graphiteCfg.TagEscaping{
+				"new.": graphiteCfg.TagEscapingPercent,
+			},
+		},
+		logger: slog.New(slog.DiscardHandler),
+		format: paths.FormatCarbonTags,
+	}
+	samples := model.Samples{{
+		Metric:    model.Metric{model.MetricNameLabel: "test", "owner": "team X"},
+		Value:     1,
+		Timestamp: model.Time(1000),
+	}}
+
+	write, err := client.prepareWrite(samples, 256, httptest.NewRequest(http.MethodPost, "http://example.com?graphite.default-prefix=old.", nil))
+	require.NoError(t, err)
+	assert.Contains(t, write.buffers[0].String(), "old.test;owner=team_X ")
+
+	write, err = client.prepareWrite(samples, 256, httptest.NewRequest(http.MethodPost, "http://example.com?graphite.default-prefix=new.", nil))
+	require.NoError(t, err)
+	assert.Contains(t, write.buffers[0].String(), "new.test;owner=team%20X ")
+}
+
+func TestQueryToTargetsUTF8Names(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	var queries []string
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		queries = append(queries, u.Query().Get("query"))
+		return []byte(`{"results":["prefix.http_server_duration.service_name.checkout","prefix.http%2Eserver%2Eduration.service%2Ename.checkout"]}`), nil
+	}
+
+	client := &Client{
+		cfg:    &graphiteCfg.Config{Read: graphiteCfg.ReadConfig{URL: "http://localhost"}},
+		logger: slog.New(slog.DiscardHandler),
+	}
+	query := &prompb.Query{
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "http.server.duration"},
+			{Name: "service.name", Type: prompb.LabelMatcher_EQ, Value: "checkout"},
+		},
+	}
+
+	targets, err := client.QueryToTargets(context.Background(), query, "prefix.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefix.http%2Eserver%2Eduration.**"}, queries)
+	assert.Equal(t, []string{"prefix.http%2Eserver%2Eduration.service%2Ename.checkout"}, targets)
+
+	// With legacy translation, the query looks for the translated names.
+	queries = nil
+	targets, err = client.QueryToTargets(context.Background(), paths.UnderscoreQueryNames(query), "prefix.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefix.http_server_duration.**"}, queries)
+	assert.Equal(t, []string{"prefix.http_server_duration.service_name.checkout"}, targets)
+}
+
+func TestReadFromIndex(t *testing.T) {
+	oldFetchURL := FetchURL
+	defer func() { FetchURL = oldFetchURL }()
+
+	expanded := 0
+	FetchURL = func(ctx context.Context, logger *slog.Logger, u *url.URL) ([]byte, error) {
+		switch u.Path {
+		case expandEndpoint:
+			switch u.Query().Get("query") {
+			case "prefix.unknown.**":
+				return []byte(`{"results":["prefix.unknown.owner.team-X"]}`), nil
+			case "prefix.test.**":
+				expanded++
+				return []byte(`{"results":["prefix.test.owner.team-X","prefix.test.owner.team-Z"]}`), nil
+			}
+			return nil, fmt.Errorf("unexpected query %s", u.Query().Get("query"))
+		default:
+			return nil, fmt.Errorf("unexpected path %s", u.Path)
+		}
+	}
+
+	idx, err := index.New("", time.Hour, 0, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	client := &Client{
+		cfg: &graphiteCfg.Config{
+			DefaultPrefix: "prefix.",
+			Write:         graphiteCfg.WriteConfig{CarbonAddress: "localhost:2003"},
+			Read:          graphiteCfg.ReadConfig{URL: "http://localhost"},
+			Index:         graphiteCfg.IndexConfig{Exclusive: true},
+		},
+		logger: slog.New(slog.DiscardHandler),
+		format: paths.FormatCarbon,
+		index:  idx,
+	}
+	client.indexSamples(idx, model.Samples{
+		{Metric: model.Metric{model.MetricNameLabel: "test", "owner": "team-X"}, Value: 1},
+		{Metric: model.Metric{model.MetricNameLabel: "test", "owner": "team-Y"}, Value: 1},
+	}, httptest.NewRequest(http.MethodPost, "http://example.com", nil))
+
+	now := time.Now().UnixMilli()
+	targets, err := client.QueryToTargets(context.Background(), &prompb.Query{
+		StartTimestampMs: now,
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"},
+			{Name: "owner", Type: prompb.LabelMatcher_EQ, Value: "team-X"},
+		},
+	}, "prefix.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefix.test.owner.team-X"}, targets)
+	assert.Zero(t, expanded)
+
+	// Queries starting before the index, or with other writers, merge the
+	// index with expand.
+	query := &prompb.Query{
+		StartTimestampMs: now - time.Hour.Milliseconds(),
+		Matchers:         []*prompb.LabelMatcher{{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "test"}},
+	}
+	targets, err = client.QueryToTargets(context.Background(), query, "prefix.")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"prefix.test.owner.team-X", "prefix.test.owner.team-Y", "prefix.test.owner.team-Z"}, targets)
+	assert.Equal(t, 1, expanded)
+
+	client.cfg.Index.Exclusive = false
+	query.StartTimestampMs = now
+	targets, err = client.QueryToTargets(context.Background(), query, "prefix.")
+	require.NoError(t, err)
+	assert.Len(t, targets, 3)
+	assert.Equal(t, 2, expanded)
+
+	// Unknown metric names fall back to expand.
+	targets, err = client.QueryToTargets(context.Background(), &prompb.Query{
+		Matchers: []*prompb.LabelMatcher{
+			{Name: model.MetricNameLabel, Type: prompb.LabelMatcher_EQ, Value: "unknown"},
+		},
+	}, "prefix.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefix.unknown.owner.team-X"}, targets)
+}
+
+func TestWriteRegistersTags(t *testing.T) {
+	carbon, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = carbon.Close() }()
+	go func() {
+		for {
+			conn, err := carbon.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }()
+		}
+	}()
+
+	var registered []string
+	graphiteWeb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/tags/tagMultiSeries", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		registered = append(registered, r.PostForm["path"]...)
+	}))
+	defer graphiteWeb.Close()
+
+	cfg := &config.Config{Graphite: graphiteCfg.DefaultConfig}
+	cfg.Graphite.DefaultPrefix = "tagdb."
+	cfg.Graphite.EnableTags = true
+	cfg.Graphite.Write.CarbonAddress = carbon.Addr().String()
+	cfg.Graphite.Read.URL = graphiteWeb.URL
+	cfg.Graphite.TagRegistration.Enabled = true
+	client := NewClient(cfg, slog.New(slog.DiscardHandler))
+	require.NotNil(t, client.tags)
+
+	samples := model.Samples{makeSample("test", 1000, 1), makeSample("test", 2000, 2)}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	_, err = client.Write(samples, 256, req, false)
+	require.NoError(t, err)
+	_, err = client.Write(samples, 256, req, true)
+	require.NoError(t, err)
+	// Queued paths are registered on shutdown.
+	client.Shutdown()
+
+	assert.Equal(t, []string{"tagdb.test;owner=team-X"}, registered)
+}
+
+func TestWriteCountsRejectedPathsOnce(t *testing.T) {
+	carbon, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = carbon.Close() }()
+	go func() {
+		for {
+			conn, err := carbon.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }()
+		}
+	}()
+
+	cfg := &config.Config{}
+	// editorconfig-checker-disable used because next lines are part of the template
+	require.NoError(t, yaml.Unmarshal([]byte(`
+graphite:
+  write:
+    carbon_address: `+carbon.Addr().String()+`
+    enable_paths_cache: true
+    rules:
+    - template: 'tmpl.{{ .labels.missing }}'
+      on_missing_label: drop
+      continue: true`), cfg))
+	// editorconfig-checker-enable
+	client := NewClient(cfg, slog.New(slog.DiscardHandler))
+	require.NotNil(t, client.pathsCache)
+	defer client.Shutdown()
+
+	rejected := func() float64 {
+		families, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != "remote_adapter_graphite_rejected_paths_total" {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				if m.GetLabel()[0].GetValue() == paths.ReasonMissingLabel {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+		return 0
+	}
+	before := rejected()
+
+	samples := model.Samples{makeSample("test", 1000, 1), makeSample("test", 2000, 2)}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+	_, err = client.Write(samples, 256, req, true)
+	require.NoError(t, err)
+	assert.Equal(t, before, rejected())
+
+	// Cached or not, the path of each sample is counted once.
+	for i := 1; i <= 2; i++ {
+		_, err = client.Write(samples, 256, req, false)
+		require.NoError(t, err)
+		assert.Equal(t, before+float64(2*i), rejected())
+	}
+}
+
+func TestPathsCacheIsRebuiltWithClient(t *testing.T) {
+	newClient := func(rules string) *Client {
+		cfg := &config.Config{Graphite: graphiteCfg.DefaultConfig}
+		cfg.Graphite.Write.CarbonAddress = ":2003"
+		require.NoError(t, yaml.Unmarshal([]byte(rules), &cfg.Graphite.Write.Rules))
+		client := NewClient(cfg, slog.New(slog.DiscardHandler))
+		require.NotNil(t, client.pathsCache)
+		return client
+	}
+	samples := model.Samples{makeSample("test", 1000, 1)}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+
+	client := newClient(`[{match: {owner: team-X}, template: 'old.{{.labels.owner}}'}]`)
+	payload, err := client.Write(samples, 256, req, true)
+	require.NoError(t, err)
+	assert.Equal(t, "old.team-X 1.000000 1\n", string(payload))
+	assert.Equal(t, 1, client.pathsCache.Len())
+	client.Shutdown()
+
+	// Like on a reload, the new rules apply at once.
+	client = newClient(`[{match: {owner: team-X}, template: 'new.{{.labels.owner}}'}]`)
+	defer client.Shutdown()
+	payload, err = client.Write(samples, 256, req, true)
+	require.NoError(t, err)
+	assert.Equal(t, "new.team-X 1.000000 1\n", string(payload))
+}
@@ -0,0 +1,202 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package tagdb registers tagged paths with the TagDB of graphite-web, so
+// that seriesByTag finds them even when carbon doesn't register them.
+package tagdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const tagMultiSeriesEndpoint = "/tags/tagMultiSeries"
+
+var (
+	registeredPaths = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "tag_registered_paths_total",
+			Help:      "Total number of tagged paths registered with graphite-web.",
+		},
+	)
+	failedPaths = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_graphite",
+			Name:      "tag_failed_paths_total",
+			Help:      "Total number of tagged paths which could not be registered with graphite-web, or queued.",
+		},
+	)
+)
+
+// Registrar registers the tagged paths it is given with graphite-web, in
+// batches from a background goroutine. A path is registered once per TTL,
+// failed registrations are retried on the next write of the path.
+type Registrar struct {
+	url           string
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	client        *http.Client
+
+	// registered holds the paths registered or queued.
+	registered *cache.Cache
+	queue      chan string
+
+	stop   chan struct{}
+	done   chan struct{}
+	logger *slog.Logger
+}
+
+// New returns a Registrar registering paths with the graphite-web server of
+// baseURL, and starts it. Requests time out after timeout, unless zero.
+func New(baseURL string, cfg graphiteCfg.TagRegistrationConfig, timeout time.Duration, logger *slog.Logger) *Registrar {
+	r := &Registrar{
+		url:           strings.TrimSuffix(baseURL, "/") + tagMultiSeriesEndpoint,
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		timeout:       timeout,
+		client:        &http.Client{},
+		registered:    cache.New(cfg.TTL, cfg.TTL),
+		queue:         make(chan string, cfg.QueueSize),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		logger:        logger,
+	}
+	go r.run()
+	return r
+}
+
+// Register queues the tagged paths not registered in the last TTL. It
+// doesn't block, the paths which don't fit in the queue are counted as
+// failed.
+func (r *Registrar) Register(paths []string) {
+	for _, path := range paths {
+		if err := r.registered.Add(path, struct{}{}, cache.DefaultExpiration); err != nil {
+			continue
+		}
+		select {
+		case r.queue <- path:
+		default:
+			r.registered.Delete(path)
+			failedPaths.Inc()
+		}
+	}
+}
+
+// Close registers the queued paths and stops the Registrar. It may be
+// called more than once.
+func (r *Registrar) Close() {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+}
+
+func (r *Registrar) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]string, 0, r.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			r.register(batch)
+			batch = make([]string, 0, r.batchSize)
+		}
+	}
+	add := func(path string) {
+		batch = append(batch, path)
+		if len(batch) >= r.batchSize {
+			flush()
+		}
+	}
+	for {
+		select {
+		case path := <-r.queue:
+			add(path)
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			// Register the queued paths before stopping.
+			for {
+				select {
+				case path := <-r.queue:
+					add(path)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// register sends a batch to /tags/tagMultiSeries. On failure, the paths are
+// forgotten so that they are registered again on their next write.
+func (r *Registrar) register(batch []string) {
+	if err := r.post(batch); err != nil {
+		r.logger.Warn("Error registering tagged paths", "url", r.url, "num_paths", len(batch), "err", err)
+		for _, path := range batch {
+			r.registered.Delete(path)
+		}
+		failedPaths.Add(float64(len(batch)))
+		return
+	}
+	r.logger.Debug("Registered tagged paths", "url", r.url, "num_paths", len(batch))
+	registeredPaths.Add(float64(len(batch)))
+}
+
+func (r *Registrar) post(batch []string) error {
+	ctx := context.Background()
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	form := url.Values{"path": batch}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("graphite-web returned HTTP status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tagdb
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGraphiteWeb records the batches of paths registered with
+// /tags/tagMultiSeries, and fails while failing is set.
+type fakeGraphiteWeb struct {
+	lock    sync.Mutex
+	batches [][]string
+	failing bool
+}
+
+func (g *fakeGraphiteWeb) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/tags/tagMultiSeries" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.failing {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	g.batches = append(g.batches, r.PostForm["path"])
+	_, _ = w.Write([]byte(`[]`))
+}
+
+func (g *fakeGraphiteWeb) Batches() [][]string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.batches
+}
+
+func newTestRegistrar(t *testing.T, g *fakeGraphiteWeb) *Registrar {
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+	cfg := graphiteCfg.DefaultConfig.TagRegistration
+	cfg.BatchSize = 2
+	cfg.FlushInterval = time.Hour
+	return New(server.URL+"/", cfg, time.Second, slog.New(slog.DiscardHandler))
+}
+
+func TestRegistrarBatches(t *testing.T) {
+	g := &fakeGraphiteWeb{}
+	r := newTestRegistrar(t, g)
+
+	r.Register([]string{"up;job=a", "up;job=b", "up;job=a", "up;job=c"})
+	// Already registered.
+	r.Register([]string{"up;job=b"})
+	assert.Eventually(t, func() bool { return len(g.Batches()) == 1 }, time.Second, time.Millisecond)
+	// The last batch is registered on close.
+	r.Close()
+	r.Close()
+
+	assert.Equal(t, [][]string{{"up;job=a", "up;job=b"}, {"up;job=c"}}, g.Batches())
+}
+
+func TestRegistrarRetriesFailures(t *testing.T) {
+	g := &fakeGraphiteWeb{failing: true}
+	r := newTestRegistrar(t, g)
+
+	r.Register([]string{"up;job=a", "up;job=b"})
+	assert.Eventually(t, func() bool { return r.registered.ItemCount() == 0 }, time.Second, time.Millisecond)
+
+	g.lock.Lock()
+	g.failing = false
+	g.lock.Unlock()
+	r.Register([]string{"up;job=a", "up;job=b"})
+	r.Close()
+
+	require.Len(t, g.Batches(), 1)
+	assert.ElementsMatch(t, []string{"up;job=a", "up;job=b"}, g.Batches()[0])
+}
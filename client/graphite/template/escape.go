@@ -149,26 +149,94 @@ func EscapeTagged(tv string) []byte {
 	return result.Bytes()
 }
 
+// EscapeTaggedPercent is a reversible alternative to EscapeTagged: the
+// characters EscapeTagged replaces by '_' and '%' itself are percent-encoded
+// instead, so UnescapeTaggedPercent gives back the original value.
+func EscapeTaggedPercent(tv string) []byte {
+	length := len(tv)
+	result := bytes.NewBuffer(make([]byte, 0, length*2))
+	for i := 0; i < length; i++ {
+		b := tv[i]
+		switch {
+		case b == ';' || b == '~' || b == ' ' || b == '=' || b == '%':
+			fmt.Fprintf(result, "%%%02X", b)
+		// These are all fine.
+		case strings.IndexByte(printables, b) != -1:
+			result.WriteByte(b)
+		// Defaults to percent-encoding.
+		default:
+			fmt.Fprintf(result, "%%%02X", b)
+		}
+	}
+	return result.Bytes()
+}
+
+// UnescapeTaggedPercent reverts EscapeTaggedPercent.
+func UnescapeTaggedPercent(tv string) string {
+	unescaped, err := url.PathUnescape(tv)
+	if err != nil {
+		return tv
+	}
+	return unescaped
+}
+
 // Unescape takes string that have been escape to comply graphite's storage format
 // and return their original value
 func Unescape(tv string) string {
 	// unescape percent encoding
-	new, _ := url.PathUnescape(tv)
+	new := unescapePercent(tv)
 	length := len(new)
 	result := bytes.NewBuffer(make([]byte, 0, length))
 	for i := 0; i < length; i++ {
 		b := new[i]
-		switch {
-		// / could have been double escaped
-		case b == '\\' && i < length-1:
-			n := new[i+1]
-			// if next byte is not a symbol, this / is legitimate
-			if strings.IndexByte(symbols, n) == -1 {
-				result.WriteByte(b)
-			}
-		default:
-			result.WriteByte(b)
+		// A backslash escapes the symbol following it, including another backslash.
+		if b == '\\' && i < length-1 && strings.IndexByte(symbols, new[i+1]) != -1 {
+			result.WriteByte(new[i+1])
+			i++
+			continue
 		}
+		result.WriteByte(b)
 	}
 	return result.String()
 }
+
+// unescapePercent decodes the percent-encoded bytes of Escape, which are
+// written with a single hex digit below 0x10 (%9 for a tab). A single digit
+// is only read as such if no other hex digit follows it, so that the bytes
+// written with two digits, like by EscapeTaggedPercent, are decoded too. The
+// bytes below 0x10 followed by a hex digit can't be told apart.
+func unescapePercent(tv string) string {
+	if strings.IndexByte(tv, '%') == -1 {
+		return tv
+	}
+	result := make([]byte, 0, len(tv))
+	for i := 0; i < len(tv); i++ {
+		if tv[i] != '%' || i+1 >= len(tv) || !isHex(tv[i+1]) {
+			result = append(result, tv[i])
+			continue
+		}
+		b := unhex(tv[i+1])
+		i++
+		if i+1 < len(tv) && isHex(tv[i+1]) {
+			b = b<<4 | unhex(tv[i+1])
+			i++
+		}
+		result = append(result, b)
+	}
+	return string(result)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -91,3 +91,60 @@ func TestUnescape(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, actual)
 	}
 }
+
+func TestEscapeUnescapeRoundTrip(t *testing.T) {
+	values := []string{
+		"a\\(",
+		"\\.",
+		"%5C(",
+		"\\%41",
+		"é/|_;:%.",
+		"(){},=.'\"\\",
+	}
+	for _, value := range values {
+		actual := Unescape(string(Escape(value)))
+		if value != actual {
+			t.Errorf("Expected %s, got %s", value, actual)
+		}
+	}
+}
+
+func TestEscapeTaggedPercent(t *testing.T) {
+	value := "a;b~c d=e%f/é"
+	expected := "a%3Bb%7Ec%20d%3De%25f/%C3%A9"
+	actual := EscapeTaggedPercent(value)
+	if expected != string(actual) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+
+	for _, value := range []string{value, "%41", "ct=\"app/vnd.k8s.p;s=w\"", "~~ ;;", ""} {
+		actual := UnescapeTaggedPercent(string(EscapeTaggedPercent(value)))
+		if value != actual {
+			t.Errorf("Expected %s, got %s", value, actual)
+		}
+	}
+}
+
+func TestEscapeSingleHexDigit(t *testing.T) {
+	// The paths of the bytes below 0x10 are kept as written so far.
+	expected := "a%9z%0"
+	actual := Escape("a\tz\x00")
+	if expected != string(actual) {
+		t.Errorf("Expected %s, got %s", expected, actual)
+	}
+
+	for value, expected := range map[string]string{
+		"a%9z%0": "a\tz\x00",
+		"a%09b":  "a\tb",
+		// Ambiguous, a byte below 0x10 followed by a hex digit.
+		"a%9b": "a\x9b",
+		"a%9":  "a\t",
+		"a%":   "a%",
+		"%zz":  "%zz",
+	} {
+		actual := Unescape(value)
+		if expected != actual {
+			t.Errorf("Expected %q, got %q", expected, actual)
+		}
+	}
+}
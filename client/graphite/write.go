@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/index"
 	gpaths "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/utils/lz4"
 	"github.com/prometheus/common/model"
@@ -68,10 +69,22 @@ func (client *Client) disconnectFromCarbon() {
 	client.carbonCon = nil
 }
 
-func (client *Client) prepareWrite(samples model.Samples, reqBufLen int, r *http.Request) ([]*bytes.Buffer, error) {
+// carbonWrite are the lines of a write to carbon, with what is reported once
+// they are sent.
+type carbonWrite struct {
+	buffers []*bytes.Buffer
+	// reasons of the paths rejected or repaired by the rules, counted once
+	// per sent sample.
+	reasons gpaths.PathReasons
+	// tagged are the tagged paths, registered with the TagDB if enabled.
+	tagged []string
+}
+
+func (client *Client) prepareWrite(samples model.Samples, reqBufLen int, r *http.Request) (*carbonWrite, error) {
 	client.logger.Debug("Remote write", "num_samples", len(samples), "storage", client.Name())
 
 	graphitePrefix := client.cfg.StoragePrefixFromRequest(r)
+	format := client.formatForPrefix(graphitePrefix)
 
 	var currentBuf *bytes.Buffer
 	if client.cfg.Write.CarbonTransport == "udp" {
@@ -82,25 +95,36 @@ func (client *Client) prepareWrite(samples model.Samples, reqBufLen int, r *http
 		currentBuf = bytes.NewBuffer(buf)
 	}
 
-	bytesBuffers := []*bytes.Buffer{currentBuf}
+	write := &carbonWrite{buffers: []*bytes.Buffer{currentBuf}}
+	underscoreNames := client.cfg.NameEscaping == config.NameEscapingUnderscores
 	for _, s := range samples {
-		datapoints, err := gpaths.ToDatapoints(s, client.format, graphitePrefix, client.cfg.Write.Rules, client.cfg.Write.TemplateData)
+		if underscoreNames {
+			s = &model.Sample{Metric: gpaths.UnderscoreNames(s.Metric), Value: s.Value, Timestamp: s.Timestamp}
+		}
+		datapoints, reasons, err := gpaths.ToDatapoints(s, format, graphitePrefix, client.cfg.Write.Rules, client.cfg.Write.TemplateData, client.pathsCache)
 		//client.logger.Debug("sample", "sample", s.String())
 		if err != nil {
 			client.logger.Debug("sample parse error", "sample", s, "err", err)
 			client.ignoredSamples.Inc()
 			continue
 		}
+		write.reasons.Rejected = append(write.reasons.Rejected, reasons.Rejected...)
+		write.reasons.Repaired = append(write.reasons.Repaired, reasons.Repaired...)
 		for _, str := range datapoints {
 			if client.cfg.Write.CarbonTransport == "udp" && (currentBuf.Len()+len(str)) > udpMaxBytes {
 				currentBuf = bytes.NewBuffer(make([]byte, 0, udpMaxBytes))
-				bytesBuffers = append(bytesBuffers, currentBuf)
+				write.buffers = append(write.buffers, currentBuf)
 			}
 			currentBuf.Write(str)
 			//client.logger.Debug("Sending", "line", str)
+			if client.tags != nil {
+				if path, _, _ := bytes.Cut(str, []byte(" ")); bytes.IndexByte(path, ';') >= 0 {
+					write.tagged = append(write.tagged, string(path))
+				}
+			}
 		}
 	}
-	return bytesBuffers, nil
+	return write, nil
 }
 
 // Write implements the client.Writer interface.
@@ -109,14 +133,14 @@ func (client *Client) Write(samples model.Samples, reqBufLen int, r *http.Reques
 		return []byte("Skipped: Not set carbon address."), nil
 	}
 
-	bytesBuffers, err := client.prepareWrite(samples, reqBufLen, r)
+	write, err := client.prepareWrite(samples, reqBufLen, r)
 	if err != nil {
 		return nil, err
 	}
 
 	if dryRun {
 		dryRunResponse := make([]byte, 0)
-		for _, buf := range bytesBuffers {
+		for _, buf := range write.buffers {
 			dryRunResponse = append(dryRunResponse, buf.Bytes()...)
 		}
 		return dryRunResponse, nil
@@ -132,7 +156,7 @@ func (client *Client) Write(samples model.Samples, reqBufLen int, r *http.Reques
 	default:
 	}
 
-	for _, buf := range bytesBuffers {
+	for _, buf := range write.buffers {
 		var conn net.Conn
 		conn, err = client.connectToCarbon()
 		if err != nil {
@@ -179,9 +203,34 @@ func (client *Client) Write(samples model.Samples, reqBufLen int, r *http.Reques
 		}
 	}
 
+	if err == nil {
+		write.reasons.Count()
+	}
+	if idx := client.seriesIndex(); err == nil && idx != nil {
+		client.indexSamples(idx, samples, r)
+	}
+	if err == nil && client.tags != nil {
+		client.tags.Register(write.tagged)
+	}
 	return []byte("Done."), err
 }
 
+// indexSamples records the default paths of the written samples in the index.
+func (client *Client) indexSamples(idx *index.Index, samples model.Samples, r *http.Request) {
+	graphitePrefix := client.cfg.StoragePrefixFromRequest(r)
+	format := client.formatForPrefix(graphitePrefix)
+	underscoreNames := client.cfg.NameEscaping == config.NameEscapingUnderscores
+	for _, s := range samples {
+		m := s.Metric
+		if underscoreNames {
+			m = gpaths.UnderscoreNames(m)
+		}
+		idx.Touch(m, graphitePrefix, func() string {
+			return gpaths.DefaultPath(m, format, graphitePrefix, client.cfg.Write.Rules, client.cfg.Write.TemplateData)
+		})
+	}
+}
+
 func (client *Client) compressLZ4(pipeWriter *io.PipeWriter, buf *bytes.Buffer) (written int64, err error) {
 	var lz4Writer *lz4.Writer
 	lz4Writer, err = lz4.NewWriter(pipeWriter, client.logger, client.cfg.Write.CompressLZ4Preferences)
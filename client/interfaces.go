@@ -17,6 +17,7 @@
 package client
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/prometheus/common/model"
@@ -42,3 +43,11 @@ type Reader interface {
 	Read(req *prompb.ReadRequest, r *http.Request) (*prompb.ReadResponse, error)
 	Client
 }
+
+// LabelReader is a Reader that can also discover label names and values, of
+// the series written since start, in milliseconds.
+type LabelReader interface {
+	LabelNames(ctx context.Context, start int64, matchers []*prompb.LabelMatcher, r *http.Request) ([]string, error)
+	LabelValues(ctx context.Context, start int64, name string, matchers []*prompb.LabelMatcher, r *http.Request) ([]string, error)
+	Reader
+}
@@ -0,0 +1,202 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// Settings are the settings of an entry of the clients list.
+type Settings interface {
+	// Decode decodes the settings into v, like yaml.Unmarshal.
+	Decode(v interface{}) error
+}
+
+// Factory builds the clients of an entry of the clients list, from its
+// settings and the top-level configuration. The returned writer or reader
+// is nil if the entry doesn't write or read.
+type Factory func(settings Settings, cfg *config.Config, logger *slog.Logger) (Writer, Reader, error)
+
+// clientType is a registered type of client. newSettings returns its
+// settings with their defaults.
+type clientType struct {
+	factory     Factory
+	newSettings func() interface{}
+}
+
+var (
+	factoriesMtx sync.RWMutex
+	factories    = make(map[string]clientType)
+)
+
+func init() {
+	config.CheckClient = check
+}
+
+// Register makes a type of client available to the clients list. The
+// settings of its entries are decoded into the value returned by
+// newSettings when the config loads, so that invalid ones fail the load
+// rather than the build of the clients. It panics if the type is already
+// registered.
+func Register(typ string, newSettings func() interface{}, f Factory) {
+	factoriesMtx.Lock()
+	defer factoriesMtx.Unlock()
+	if _, ok := factories[typ]; ok {
+		panic(fmt.Sprintf("client type %q registered twice", typ))
+	}
+	factories[typ] = clientType{factory: f, newSettings: newSettings}
+}
+
+// check checks the type of an entry of the clients list, and decodes its
+// settings.
+func check(cc *config.ClientConfig) error {
+	t, err := lookup(cc)
+	if err != nil {
+		return err
+	}
+	if err := cc.Settings.Decode(t.newSettings()); err != nil {
+		return fmt.Errorf("invalid settings of client %q: %w", cc.Name, err)
+	}
+	return nil
+}
+
+func lookup(cc *config.ClientConfig) (clientType, error) {
+	factoriesMtx.RLock()
+	t, ok := factories[cc.Type]
+	factoriesMtx.RUnlock()
+	if !ok {
+		return clientType{}, fmt.Errorf("unknown type %q of client %q, expected one of %v", cc.Type, cc.Name, Types())
+	}
+	return t, nil
+}
+
+// Types returns the registered types of client, sorted.
+func Types() []string {
+	factoriesMtx.RLock()
+	defer factoriesMtx.RUnlock()
+	types := make([]string, 0, len(factories))
+	for typ := range factories {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New builds the clients of an entry of the clients list. They are named
+// after the entry, and the writer is a Selector of the samples matching the
+// selectors of the entry.
+func New(cc *config.ClientConfig, cfg *config.Config, logger *slog.Logger) (Writer, Reader, error) {
+	t, err := lookup(cc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, r, err := t.factory(&cc.Settings, cfg, logger.With("client", cc.Name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building client %q: %w", cc.Name, err)
+	}
+	if w != nil {
+		w = &namedWriter{Writer: w, name: cc.Name, matchers: cc.Matchers}
+	}
+	switch reader := r.(type) {
+	case nil:
+	case LabelReader:
+		r = &namedLabelReader{LabelReader: reader, name: cc.Name}
+	default:
+		r = &namedReader{Reader: reader, name: cc.Name}
+	}
+	return w, r, nil
+}
+
+// Selector is a Writer which is only given the samples it selects, see
+// Select.
+type Selector interface {
+	Selects(m model.Metric) bool
+}
+
+type namedWriter struct {
+	Writer
+	name     string
+	matchers [][]*labels.Matcher
+}
+
+func (w *namedWriter) Name() string {
+	return w.name
+}
+
+// Selects implements the Selector interface, the metrics matching any
+// selector are written.
+func (w *namedWriter) Selects(m model.Metric) bool {
+	if len(w.matchers) == 0 {
+		return true
+	}
+	for _, matchers := range w.matchers {
+		if matchMetric(matchers, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMetric(matchers []*labels.Matcher, m model.Metric) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(string(m[model.LabelName(matcher.Name)])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the samples a writer writes: the ones it selects if it is
+// a Selector, all of them otherwise.
+func Select(w Writer, samples model.Samples) model.Samples {
+	s, ok := w.(Selector)
+	if !ok {
+		return samples
+	}
+	selected := make(model.Samples, 0, len(samples))
+	for _, sample := range samples {
+		if s.Selects(sample.Metric) {
+			selected = append(selected, sample)
+		}
+	}
+	return selected
+}
+
+type namedReader struct {
+	Reader
+	name string
+}
+
+func (r *namedReader) Name() string {
+	return r.name
+}
+
+type namedLabelReader struct {
+	LabelReader
+	name string
+}
+
+func (r *namedLabelReader) Name() string {
+	return r.name
+}
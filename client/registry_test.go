@@ -0,0 +1,101 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	target string
+}
+
+func (c *fakeClient) Name() string   { return "fake" }
+func (c *fakeClient) Target() string { return c.target }
+func (c *fakeClient) String() string { return c.target }
+func (c *fakeClient) Shutdown()      {}
+
+func (c *fakeClient) Write(model.Samples, int, *http.Request, bool) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) Read(*prompb.ReadRequest, *http.Request) (*prompb.ReadResponse, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) LabelNames(context.Context, int64, []*prompb.LabelMatcher, *http.Request) ([]string, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) LabelValues(context.Context, int64, string, []*prompb.LabelMatcher, *http.Request) ([]string, error) {
+	return nil, nil
+}
+
+func init() {
+	Register("fake", func() interface{} { return new(string) }, func(settings Settings, _ *config.Config, _ *slog.Logger) (Writer, Reader, error) {
+		c := &fakeClient{}
+		if err := settings.Decode(&c.target); err != nil {
+			return nil, nil, err
+		}
+		return c, c, nil
+	})
+}
+
+func TestNew(t *testing.T) {
+	cfg, err := config.Load(`
+clients:
+  - name: a
+    type: fake
+    selectors: ['{job="node"}']
+    settings: localhost:2003
+`)
+	require.NoError(t, err)
+
+	w, r, err := New(cfg.Clients[0], cfg, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.Equal(t, "a", w.Name())
+	assert.Equal(t, "localhost:2003", w.Target())
+	assert.Equal(t, "a", r.Name())
+	assert.Implements(t, (*LabelReader)(nil), r)
+
+	samples := model.Samples{
+		{Metric: model.Metric{"__name__": "up", "job": "node"}},
+		{Metric: model.Metric{"__name__": "up", "job": "api"}},
+	}
+	assert.Equal(t, samples[:1], Select(w, samples))
+	assert.Equal(t, samples, Select(&fakeClient{}, samples))
+
+	_, _, err = New(&config.ClientConfig{Name: "b", Type: "unknown"}, cfg, slog.New(slog.DiscardHandler))
+	assert.ErrorContains(t, err, `unknown type "unknown" of client "b", expected one of [fake]`)
+	assert.Panics(t, func() { Register("fake", nil, nil) })
+}
+
+func TestCheckOnLoad(t *testing.T) {
+	_, err := config.Load(`clients: [{name: b, type: unknown}]`)
+	assert.ErrorContains(t, err, `unknown type "unknown" of client "b", expected one of [fake]`)
+
+	_, err = config.Load(`clients: [{name: a, type: fake, settings: [localhost]}]`)
+	assert.ErrorContains(t, err, `invalid settings of client "a"`)
+}
@@ -0,0 +1,107 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package remote implements clients of the Prometheus remote read and write
+// protocols.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// maxErrMsgLen is the maximum length of the response body included in errors.
+const maxErrMsgLen = 256
+
+var userAgent = fmt.Sprintf("graphite-remote-adapter/%s", version.Version)
+
+// ReadClient reads series from a Prometheus remote read endpoint.
+type ReadClient struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewReadClient returns a ReadClient for the given remote read URL.
+func NewReadClient(url string, timeout time.Duration) *ReadClient {
+	return &ReadClient{url: url, timeout: timeout, client: &http.Client{}}
+}
+
+// Read sends a query to the remote read endpoint and returns its result.
+func (c *ReadClient) Read(ctx context.Context, query *prompb.Query) (*prompb.QueryResult, error) {
+	data, err := proto.Marshal(&prompb.ReadRequest{Queries: []*prompb.Query{query}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal read request: %w", err)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	httpReq.Header.Add("Content-Encoding", "snappy")
+	httpReq.Header.Add("Accept-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.Header.Set("X-Prometheus-Remote-Read-Version", "0.1.0")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	compressed, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if httpResp.StatusCode/100 != 2 {
+		if len(compressed) > maxErrMsgLen {
+			compressed = compressed[:maxErrMsgLen]
+		}
+		return nil, fmt.Errorf("remote server %s returned HTTP status %s: %s", c.url, httpResp.Status, bytes.TrimSpace(compressed))
+	}
+
+	uncompressed, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	var resp prompb.ReadResponse
+	if err := proto.Unmarshal(uncompressed, &resp); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal response body: %w", err)
+	}
+	if len(resp.Results) != 1 {
+		return nil, fmt.Errorf("responses: want %d, got %d", 1, len(resp.Results))
+	}
+	return resp.Results[0], nil
+}
+
+// String returns the URL of the remote endpoint.
+func (c *ReadClient) String() string {
+	return c.url
+}
@@ -0,0 +1,73 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadClient(t *testing.T) {
+	var received prompb.ReadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, _ := io.ReadAll(r.Body)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		require.NoError(t, proto.Unmarshal(data, &received))
+
+		resp, _ := proto.Marshal(&prompb.ReadResponse{Results: []*prompb.QueryResult{{
+			Timeseries: []*prompb.TimeSeries{{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "test"}},
+				Samples: []prompb.Sample{{Timestamp: 1000, Value: 1}},
+			}},
+		}}})
+		w.Header().Set("Content-Encoding", "snappy")
+		_, _ = w.Write(snappy.Encode(nil, resp))
+	}))
+	defer server.Close()
+
+	query := &prompb.Query{
+		StartTimestampMs: 0,
+		EndTimestampMs:   1000,
+		Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "test"}},
+	}
+	result, err := NewReadClient(server.URL, time.Minute).Read(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, []*prompb.Query{query}, received.Queries)
+	require.Len(t, result.Timeseries, 1)
+	assert.Equal(t, "test", result.Timeseries[0].Labels[0].Value)
+}
+
+func TestReadClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad query", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewReadClient(server.URL, time.Minute).Read(context.Background(), &prompb.Query{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad query")
+}
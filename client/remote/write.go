@@ -0,0 +1,116 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// RecoverableError is returned by Store when the request may succeed if retried.
+type RecoverableError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e RecoverableError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e RecoverableError) Unwrap() error {
+	return e.Err
+}
+
+// IsRecoverable reports whether err is a RecoverableError.
+func IsRecoverable(err error) bool {
+	var re RecoverableError
+	return errors.As(err, &re)
+}
+
+// WriteClient writes series to a Prometheus remote write endpoint.
+type WriteClient struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewWriteClient returns a WriteClient for the given remote write URL.
+func NewWriteClient(url string, timeout time.Duration) *WriteClient {
+	return &WriteClient{url: url, timeout: timeout, client: &http.Client{}}
+}
+
+// NewWriteClientWithTransport returns a WriteClient sending its requests
+// with rt, e.g. to authenticate them.
+func NewWriteClientWithTransport(url string, timeout time.Duration, rt http.RoundTripper) *WriteClient {
+	return &WriteClient{url: url, timeout: timeout, client: &http.Client{Transport: rt}}
+}
+
+// Store sends a batch of series to the remote write endpoint. Network errors,
+// server errors and throttling are returned as RecoverableError.
+func (c *WriteClient) Store(ctx context.Context, req *prompb.WriteRequest) error {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("unable to marshal write request: %w", err)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	httpReq.Header.Add("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return RecoverableError{Err: fmt.Errorf("error sending request: %w", err)}
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, httpResp.Body)
+		_ = httpResp.Body.Close()
+	}()
+
+	if httpResp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrMsgLen))
+	err = fmt.Errorf("remote server %s returned HTTP status %s: %s", c.url, httpResp.Status, bytes.TrimSpace(body))
+	if httpResp.StatusCode/100 == 5 || httpResp.StatusCode == http.StatusTooManyRequests {
+		return RecoverableError{Err: err}
+	}
+	return err
+}
+
+// String returns the URL of the remote endpoint.
+func (c *WriteClient) String() string {
+	return c.url
+}
@@ -0,0 +1,68 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteClient(t *testing.T) {
+	var received prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, _ := io.ReadAll(r.Body)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		require.NoError(t, proto.Unmarshal(data, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	req := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "test"}},
+		Samples: []prompb.Sample{{Timestamp: 1000, Value: 1}},
+	}}}
+	require.NoError(t, NewWriteClient(server.URL, time.Minute).Store(context.Background(), req))
+	assert.Equal(t, req.Timeseries, received.Timeseries)
+}
+
+func TestWriteClientError(t *testing.T) {
+	for status, recoverable := range map[int]bool{
+		http.StatusBadRequest:          false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rejected", status)
+		}))
+
+		err := NewWriteClient(server.URL, time.Minute).Store(context.Background(), &prompb.WriteRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rejected")
+		assert.Equal(t, recoverable, IsRecoverable(err), "status %d", status)
+		server.Close()
+	}
+}
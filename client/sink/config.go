@@ -0,0 +1,92 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sink
+
+import (
+	"fmt"
+	"time"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
+)
+
+// Formats of the records.
+const (
+	// FormatCarbon records the carbon lines, as sent to carbon.
+	FormatCarbon = "carbon"
+	// FormatJSON records a JSON object per path, with the labels of the
+	// sample and the rule rendering the path.
+	FormatJSON = "json"
+)
+
+// DefaultConfig is the default configuration of a sink client.
+var DefaultConfig = Config{
+	Format:      FormatCarbon,
+	MaxSize:     100 << 20,
+	MaxBackups:  10,
+	SampleRatio: 1,
+}
+
+// Config is the settings of a sink client.
+type Config struct {
+	// Path is the file the records are appended to, stdout if empty or "-".
+	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// The file is rotated before a write once it holds MaxSize bytes, or
+	// was opened MaxAge ago. Zero disables a condition. MaxBackups rotated
+	// files are kept, all of them if zero, and gzipped if Compress is set.
+	MaxSize    int64         `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+	MaxAge     time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+	MaxBackups int           `yaml:"max_backups,omitempty" json:"max_backups,omitempty"`
+	Compress   bool          `yaml:"compress,omitempty" json:"compress,omitempty"`
+	// SampleRatio is the ratio of the series recorded. Series are chosen by
+	// the hash of their labels, so all the samples of a series are recorded.
+	SampleRatio float64 `yaml:"sample_ratio,omitempty" json:"sample_ratio,omitempty"`
+	// Prefixes restricts the records to the samples written with one of
+	// these prefixes, e.g. the ones of some tenants.
+	Prefixes []string `yaml:"prefixes,omitempty" json:"prefixes,omitempty"`
+	// Graphite renders the paths, the graphite block of the configuration
+	// if unset.
+	Graphite *graphiteCfg.Config `yaml:"graphite,omitempty" json:"graphite,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+	type plain Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Format != FormatCarbon && c.Format != FormatJSON {
+		return fmt.Errorf("unknown sink format %q, expected %s or %s", c.Format, FormatCarbon, FormatJSON)
+	}
+	if c.MaxSize < 0 || c.MaxAge < 0 || c.MaxBackups < 0 {
+		return fmt.Errorf("sink max_size, max_age and max_backups must not be negative")
+	}
+	if c.SampleRatio <= 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("sink sample_ratio %v must be in (0, 1]", c.SampleRatio)
+	}
+
+	return utils.CheckOverflow(c.XXX, "sink config")
+}
+
+// stdout reports whether the records are written to stdout.
+func (c *Config) stdout() bool {
+	return c.Path == "" || c.Path == "-"
+}
@@ -0,0 +1,197 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sink
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names the rotated files after the time of their rotation,
+// so they sort by age.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// rotatingFile appends to a file, rotated once it reaches a size or an age.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+	now        func() time.Time
+	logger     *slog.Logger
+
+	f      *os.File
+	size   int64
+	opened time.Time
+
+	// backupsLock serializes the compression and pruning of the backups,
+	// done in the background.
+	backupsLock sync.Mutex
+	background  sync.WaitGroup
+}
+
+func openRotatingFile(cfg *Config, now func() time.Time, logger *slog.Logger) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       cfg.Path,
+		maxSize:    cfg.MaxSize,
+		maxAge:     cfg.MaxAge,
+		maxBackups: cfg.MaxBackups,
+		compress:   cfg.Compress,
+		now:        now,
+		logger:     logger,
+	}
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0o755); err != nil {
+		return nil, err
+	}
+	return rf, rf.open()
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.f, rf.size, rf.opened = f, info.Size(), rf.now()
+	return nil
+}
+
+// Write implements the io.Writer interface, rotating the file first if
+// needed.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+	if rf.size > 0 && ((rf.maxSize > 0 && rf.size+int64(len(p)) > rf.maxSize) ||
+		(rf.maxAge > 0 && rf.now().Sub(rf.opened) >= rf.maxAge)) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate renames the file after the current time and reopens it. The backup
+// is compressed and the old ones pruned in the background.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		rf.logger.Warn("Error closing sink file", "file", rf.path, "err", err)
+	}
+	rf.f = nil
+	backup := rf.path + "." + rf.now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(rf.path, backup); err != nil {
+		return err
+	}
+	rotations.WithLabelValues(rf.path).Inc()
+
+	rf.background.Add(1)
+	go func() {
+		defer rf.background.Done()
+		rf.backupsLock.Lock()
+		defer rf.backupsLock.Unlock()
+		if rf.compress {
+			if err := gzipFile(backup); err != nil {
+				rf.logger.Warn("Error compressing sink file", "file", backup, "err", err)
+			}
+		}
+		rf.prune()
+	}()
+	return rf.open()
+}
+
+// prune removes the oldest backups beyond maxBackups.
+func (rf *rotatingFile) prune() {
+	if rf.maxBackups == 0 {
+		return
+	}
+	dir, base := filepath.Split(rf.path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		rf.logger.Warn("Error listing sink files", "dir", dir, "err", err)
+		return
+	}
+	var backups []string
+	for _, e := range entries {
+		suffix, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(suffix, ".gz")); err == nil {
+			backups = append(backups, e.Name())
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > rf.maxBackups {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			rf.logger.Warn("Error removing sink file", "file", backups[0], "err", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// Close closes the file, and waits for the backups to be compressed.
+func (rf *rotatingFile) Close() error {
+	var err error
+	if rf.f != nil {
+		err = rf.f.Close()
+		rf.f = nil
+	}
+	rf.background.Wait()
+	return err
+}
+
+// gzipFile replaces name by its gzipped copy, name.gz.
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
@@ -0,0 +1,102 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sink
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readDir(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		require.NoError(t, err)
+		var r io.Reader = f
+		if filepath.Ext(e.Name()) == ".gz" {
+			r, err = gzip.NewReader(f)
+			require.NoError(t, err)
+		}
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := &Config{Path: filepath.Join(dir, "carbon.log"), MaxSize: 12, MaxAge: time.Hour, MaxBackups: 2, Compress: true}
+	rf, err := openRotatingFile(cfg, func() time.Time { return now }, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	write := func(s string) {
+		_, err := rf.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	write("a.b 1 1\n")
+	// Rotated by size.
+	now = now.Add(time.Second)
+	write("a.c 1 1\n")
+	// Rotated by age, the oldest backup is removed.
+	now = now.Add(time.Hour)
+	write("d\n")
+	now = now.Add(time.Hour)
+	write("e\n")
+	require.NoError(t, rf.Close())
+
+	assert.Equal(t, map[string]string{
+		"carbon.log": "e\n",
+		"carbon.log.20240102T040406.000000000.gz": "a.c 1 1\n",
+		"carbon.log.20240102T050406.000000000.gz": "d\n",
+	}, readDir(t, dir))
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Path: filepath.Join(dir, "sink", "carbon.log"), MaxSize: 10}
+	require.NoError(t, os.MkdirAll(filepath.Dir(cfg.Path), 0o755))
+	require.NoError(t, os.WriteFile(cfg.Path, []byte("a.b 1 1\n"), 0o644))
+	require.NoError(t, os.WriteFile(cfg.Path+".old", []byte("kept"), 0o644))
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rf, err := openRotatingFile(cfg, func() time.Time { return now }, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	// The existing content counts towards the size.
+	_, err = rf.Write([]byte("a.c\n"))
+	require.NoError(t, err)
+	_, err = rf.Write([]byte("a.d\n"))
+	require.NoError(t, err)
+	require.NoError(t, rf.Close())
+
+	assert.Equal(t, map[string]string{
+		"carbon.log":                           "a.c\na.d\n",
+		"carbon.log.20240102T030405.000000000": "a.b 1 1\n",
+		"carbon.log.old":                       "kept",
+	}, readDir(t, filepath.Dir(cfg.Path)))
+}
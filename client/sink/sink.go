@@ -0,0 +1,280 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package sink records the Graphite paths of the written samples to a
+// rotating file or stdout, to audit or debug the rendering of the paths.
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+var (
+	records = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_sink",
+			Name:      "records_total",
+			Help:      "Total number of carbon lines or JSON records written by the sink.",
+		},
+		[]string{"file"},
+	)
+	rotations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_sink",
+			Name:      "rotations_total",
+			Help:      "Total number of rotations of the sink file.",
+		},
+		[]string{"file"},
+	)
+)
+
+func init() {
+	client.Register("sink", newSettings, newFromSettings)
+}
+
+// record is a path of a sample, in the json format.
+type record struct {
+	Labels model.Metric `json:"labels"`
+	Prefix string       `json:"prefix"`
+	// Rule is the index of the rule rendering the path, unset for the
+	// default path.
+	Rule      *int    `json:"rule,omitempty"`
+	Template  string  `json:"template,omitempty"`
+	Path      string  `json:"path"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// Client writes the paths of the samples to a file or stdout.
+type Client struct {
+	cfg      *Config
+	graphite *graphiteCfg.Config
+	logger   *slog.Logger
+
+	lock   sync.Mutex
+	out    io.WriteCloser
+	closed bool
+}
+
+// NewClient returns a new Client rendering the paths with graphite, and
+// opens its file.
+func NewClient(cfg *Config, graphite *graphiteCfg.Config, logger *slog.Logger) (*Client, error) {
+	c := &Client{cfg: cfg, graphite: graphite, logger: logger}
+	if cfg.stdout() {
+		c.out = nopCloser{os.Stdout}
+		return c, nil
+	}
+	rf, err := openRotatingFile(cfg, time.Now, logger)
+	if err != nil {
+		return nil, err
+	}
+	c.out = rf
+	return c, nil
+}
+
+// newSettings returns the settings of the entries of the clients list.
+func newSettings() interface{} {
+	c := DefaultConfig
+	return &c
+}
+
+// newFromSettings builds a Client from the settings of an entry of the
+// clients list.
+func newFromSettings(settings client.Settings, cfg *config.Config, logger *slog.Logger) (client.Writer, client.Reader, error) {
+	c := DefaultConfig
+	if err := settings.Decode(&c); err != nil {
+		return nil, nil, err
+	}
+	graphite := c.Graphite
+	if graphite == nil {
+		g := cfg.Graphite
+		graphite = &g
+	}
+	w, err := NewClient(&c, graphite, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, nil, nil
+}
+
+// Name implements the client.Client interface.
+func (c *Client) Name() string {
+	return "sink"
+}
+
+// Target implements the client.Client interface.
+func (c *Client) Target() string {
+	if c.cfg.stdout() {
+		return "stdout"
+	}
+	return c.cfg.Path
+}
+
+// String implements the client.Client interface.
+func (c *Client) String() string {
+	return c.Target()
+}
+
+// Shutdown implements the client.Client interface.
+func (c *Client) Shutdown() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if err := c.out.Close(); err != nil {
+		c.logger.Warn("Error closing sink", "target", c.Target(), "err", err)
+	}
+}
+
+// Write implements the client.Writer interface. The paths of the sampled
+// series are rendered like for carbon, and appended to the file. A dry run
+// returns them instead.
+func (c *Client) Write(samples model.Samples, _ int, r *http.Request, dryRun bool) ([]byte, error) {
+	prefix := c.graphite.StoragePrefixFromRequest(r)
+	if len(c.cfg.Prefixes) > 0 && !slices.Contains(c.cfg.Prefixes, prefix) {
+		return []byte("Skipped: prefix not recorded."), nil
+	}
+	format := paths.FormatForPrefix(c.graphite, prefix)
+	underscoreNames := c.graphite.NameEscaping == graphiteCfg.NameEscapingUnderscores
+
+	var buf bytes.Buffer
+	n := 0
+	for _, s := range samples {
+		if !c.sampled(s.Metric) {
+			continue
+		}
+		m := s.Metric
+		if underscoreNames {
+			m = paths.UnderscoreNames(m)
+		}
+		var written int
+		var err error
+		if c.cfg.Format == FormatJSON {
+			written, err = c.appendRecords(&buf, s, m, format, prefix)
+		} else {
+			written, err = c.appendLines(&buf, s, m, format, prefix)
+		}
+		n += written
+		if err != nil {
+			c.logger.Debug("sample parse error", "sample", s, "err", err)
+		}
+	}
+	if dryRun {
+		return buf.Bytes(), nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return nil, errors.New("sink is shut down")
+	}
+	if buf.Len() == 0 {
+		return []byte("Done."), nil
+	}
+	if _, err := c.out.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	records.WithLabelValues(c.Target()).Add(float64(n))
+	return []byte("Done."), nil
+}
+
+// sampled reports whether the series of m is recorded.
+func (c *Client) sampled(m model.Metric) bool {
+	if c.cfg.SampleRatio >= 1 {
+		return true
+	}
+	// The bits of the FNV fingerprints of similar series are mixed with the
+	// finalizer of MurmurHash3, so they spread over the whole range.
+	h := uint64(m.Fingerprint())
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return float64(h) < c.cfg.SampleRatio*math.MaxUint64
+}
+
+// appendLines appends the carbon lines of s, with the labels m, and returns
+// their number.
+func (c *Client) appendLines(buf *bytes.Buffer, s *model.Sample, m model.Metric, format paths.Format, prefix string) (int, error) {
+	s = &model.Sample{Metric: m, Value: s.Value, Timestamp: s.Timestamp}
+	datapoints, _, err := paths.ToDatapoints(s, format, prefix, c.graphite.Write.Rules, c.graphite.Write.TemplateData, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range datapoints {
+		buf.Write(line)
+	}
+	return len(datapoints), nil
+}
+
+// appendRecords appends the JSON records of the paths of s, with the labels
+// m, and returns their number.
+func (c *Client) appendRecords(buf *bytes.Buffer, s *model.Sample, m model.Metric, format paths.Format, prefix string) (int, error) {
+	v := float64(s.Value)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, errors.New("invalid sample value")
+	}
+	rulePaths, err := paths.RulePaths(m, format, prefix, c.graphite.Write.Rules, c.graphite.Write.TemplateData)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, rp := range rulePaths {
+		rec := record{Labels: s.Metric, Prefix: prefix, Path: rp.Path, Value: v, Timestamp: int64(s.Timestamp)}
+		if rp.Rule >= 0 {
+			rule := rp.Rule
+			rec.Rule = &rule
+			if tmpl, err := c.graphite.Write.Rules[rule].Tmpl.MarshalYAML(); err == nil {
+				rec.Template, _ = tmpl.(string)
+			}
+		}
+		if err := enc.Encode(rec); err != nil {
+			return 0, err
+		}
+	}
+	return len(rulePaths), nil
+}
+
+// nopCloser doesn't close stdout on shutdown.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sink
+
+import (
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var testSamples = model.Samples{
+	{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 1, Timestamp: 1700000000000},
+	{Metric: model.Metric{"__name__": "up", "job": "api"}, Value: 0, Timestamp: 1700000000000},
+}
+
+func newTestClient(t *testing.T, settings string) *Client {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(settings), &node))
+	cfg := config.DefaultConfig
+	cfg.Graphite.DefaultPrefix = "prom."
+	w, r, err := newFromSettings(node.Content[0], &cfg, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	return w.(*Client)
+}
+
+func TestClientWritesLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "carbon.log")
+	c := newTestClient(t, "path: "+path)
+
+	req := httptest.NewRequest("POST", "/write", nil)
+	_, err := c.Write(testSamples, 0, req, false)
+	require.NoError(t, err)
+	msg, err := c.Write(testSamples[:1], 0, req, true)
+	require.NoError(t, err)
+	assert.Equal(t, "prom.up.job.node 1.000000 1700000000\n", string(msg))
+
+	// Prefixes not recorded are skipped.
+	c.cfg.Prefixes = []string{"tenant-a."}
+	_, err = c.Write(testSamples, 0, httptest.NewRequest("POST", "/write?graphite.default-prefix=tenant-b.", nil), false)
+	require.NoError(t, err)
+	_, err = c.Write(testSamples[:1], 0, httptest.NewRequest("POST", "/write?graphite.default-prefix=tenant-a.", nil), false)
+	require.NoError(t, err)
+	c.Shutdown()
+	_, err = c.Write(testSamples, 0, httptest.NewRequest("POST", "/write?graphite.default-prefix=tenant-a.", nil), false)
+	assert.Error(t, err)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "prom.up.job.node 1.000000 1700000000\n"+
+		"prom.up.job.api 0.000000 1700000000\n"+
+		"tenant-a.up.job.node 1.000000 1700000000\n", string(b))
+}
+
+func TestClientWritesRecords(t *testing.T) {
+	c := newTestClient(t, `
+format: json
+graphite:
+  default_prefix: graphite.
+  write:
+    rules:
+      - match:
+          job: node
+        template: 'nodes.{{.labels.__name__}}'
+        continue: true
+`)
+
+	msg, err := c.Write(testSamples, 0, httptest.NewRequest("POST", "/write", nil), true)
+	require.NoError(t, err)
+	assert.Equal(t, `{"labels":{"__name__":"up","job":"node"},"prefix":"graphite.","rule":0,"template":"nodes.{{.labels.__name__}}","path":"nodes.up","value":1,"timestamp":1700000000000}
+{"labels":{"__name__":"up","job":"node"},"prefix":"graphite.","path":"graphite.up.job.node","value":1,"timestamp":1700000000000}
+{"labels":{"__name__":"up","job":"api"},"prefix":"graphite.","path":"graphite.up.job.api","value":0,"timestamp":1700000000000}
+`, string(msg))
+}
+
+func TestClientSamples(t *testing.T) {
+	c := newTestClient(t, "sample_ratio: 0.5")
+	recorded := 0
+	for i := range 2000 {
+		m := model.Metric{"__name__": "up", "instance": model.LabelValue(strconv.Itoa(i))}
+		if c.sampled(m) {
+			recorded++
+			// All the samples of a series are recorded.
+			assert.True(t, c.sampled(m.Clone()))
+		}
+	}
+	assert.InDelta(t, 1000, recorded, 100)
+}
+
+func TestConfig(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte("path: /var/log/carbon.log"), &cfg))
+	assert.Equal(t, FormatCarbon, cfg.Format)
+	assert.Equal(t, 1.0, cfg.SampleRatio)
+	assert.Nil(t, cfg.Graphite)
+
+	for _, s := range []string{
+		"format: xml",
+		"sample_ratio: 0",
+		"sample_ratio: 2",
+		"max_size: -1",
+		"max_files: 3",
+	} {
+		assert.Error(t, yaml.Unmarshal([]byte(s), &cfg), s)
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package whisper writes the Graphite paths of the samples straight into
+// whisper files, in the format and layout of carbon, for setups without
+// carbon-cache. It can also read the files back, for setups without
+// graphite-web.
+package whisper
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+var (
+	createdFiles = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_whisper",
+			Name:      "created_files_total",
+			Help:      "Total number of whisper files created.",
+		},
+	)
+	failedUpdates = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "remote_adapter_whisper",
+			Name:      "failed_updates_total",
+			Help:      "Total number of whisper file updates which failed.",
+		},
+	)
+)
+
+func init() {
+	client.Register("whisper", newSettings, newFromSettings)
+}
+
+// Client writes samples to the whisper files of a directory, and reads them
+// back.
+type Client struct {
+	cfg       *graphiteCfg.Config
+	logger    *slog.Logger
+	now       func() time.Time
+	readDelay time.Duration
+	// pathsCache is nil if the paths cache is disabled.
+	pathsCache *paths.Cache
+
+	lock  sync.Mutex
+	files *fileCache
+}
+
+// NewClient returns a new Client, or nil if no whisper root directory is
+// configured.
+func NewClient(cfg *config.Config, logger *slog.Logger) *Client {
+	if cfg.Graphite.Whisper.RootDir == "" {
+		return nil
+	}
+	return &Client{
+		cfg:        &cfg.Graphite,
+		logger:     logger,
+		now:        time.Now,
+		readDelay:  cfg.Read.Delay,
+		pathsCache: paths.NewCacheFromConfig(&cfg.Graphite.Write),
+		files:      newFileCache(cfg.Graphite.Whisper.MaxOpenFiles),
+	}
+}
+
+// newSettings returns the settings of the entries of the clients list, a
+// graphite block.
+func newSettings() interface{} {
+	c := graphiteCfg.DefaultConfig
+	return &c
+}
+
+// newFromSettings builds a Client from the settings of an entry of the
+// clients list, which are a graphite block with a whisper root directory.
+// It writes and reads depending on the whisper mode.
+func newFromSettings(settings client.Settings, cfg *config.Config, logger *slog.Logger) (client.Writer, client.Reader, error) {
+	entryCfg := *cfg
+	entryCfg.Graphite = graphiteCfg.DefaultConfig
+	if err := settings.Decode(&entryCfg.Graphite); err != nil {
+		return nil, nil, err
+	}
+	c := NewClient(&entryCfg, logger)
+	if c == nil {
+		return nil, nil, errors.New("a whisper root directory is required")
+	}
+
+	var w client.Writer
+	var r client.Reader
+	if entryCfg.Graphite.Whisper.Writes() {
+		w = c
+	}
+	if entryCfg.Graphite.Whisper.Reads() {
+		r = c
+	}
+	return w, r, nil
+}
+
+// Name implements the client.Client interface.
+func (c *Client) Name() string {
+	return "whisper"
+}
+
+// Target implements the client.Client interface.
+func (c *Client) Target() string {
+	return c.cfg.Whisper.RootDir
+}
+
+// String implements the client.Client interface.
+func (c *Client) String() string {
+	return c.cfg.String()
+}
+
+// Shutdown implements the client.Client interface.
+func (c *Client) Shutdown() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.pathsCache != nil {
+		c.pathsCache.Close()
+	}
+	c.files.closeAll()
+}
+
+// Write implements the client.Writer interface. The samples are rendered to
+// carbon lines like for carbon, and each path is updated with its points.
+func (c *Client) Write(samples model.Samples, _ int, r *http.Request, dryRun bool) ([]byte, error) {
+	prefix := c.cfg.StoragePrefixFromRequest(r)
+	format := paths.FormatForPrefix(c.cfg, prefix)
+	underscoreNames := c.cfg.NameEscaping == graphiteCfg.NameEscapingUnderscores
+
+	var lines []byte
+	points := make(map[string][]Point)
+	var order []string
+	for _, s := range samples {
+		if underscoreNames {
+			s = &model.Sample{Metric: paths.UnderscoreNames(s.Metric), Value: s.Value, Timestamp: s.Timestamp}
+		}
+		datapoints, _, err := paths.ToDatapoints(s, format, prefix, c.cfg.Write.Rules, c.cfg.Write.TemplateData, c.pathsCache)
+		if err != nil {
+			c.logger.Debug("sample parse error", "sample", s, "err", err)
+			continue
+		}
+		for _, line := range datapoints {
+			if dryRun {
+				lines = append(lines, line...)
+				continue
+			}
+			path, p, err := parseLine(line)
+			if err != nil {
+				c.logger.Debug("carbon line parse error", "line", string(line), "err", err)
+				continue
+			}
+			if _, ok := points[path]; !ok {
+				order = append(order, path)
+			}
+			points[path] = append(points[path], p)
+		}
+	}
+	if dryRun {
+		return lines, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now().Unix()
+	var errs []error
+	for _, path := range order {
+		if err := c.update(path, points[path], now); err != nil {
+			failedUpdates.Inc()
+			errs = append(errs, fmt.Errorf("error updating %s: %w", path, err))
+		}
+	}
+	return []byte("Done."), errors.Join(errs...)
+}
+
+// update writes points to the whisper file of path, created with the
+// storage schema and aggregation of the path if needed.
+func (c *Client) update(path string, points []Point, now int64) error {
+	file, err := filePath(c.cfg.Whisper.RootDir, path)
+	if err != nil {
+		return err
+	}
+
+	f, err := c.files.get(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = c.create(file, path)
+	}
+	if err != nil {
+		return err
+	}
+	return f.UpdateMany(points, now)
+}
+
+func (c *Client) create(file, path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	aggregation, xFilesFactor := c.cfg.Whisper.Aggregation(path)
+	f, err := Create(file, c.cfg.Whisper.Retentions(path), aggregation, xFilesFactor)
+	if err != nil {
+		return nil, err
+	}
+	createdFiles.Inc()
+	c.logger.Debug("Created whisper file", "file", file)
+	c.files.add(file, f)
+	return f, nil
+}
+
+// parseLine parses a carbon line, "path value timestamp\n".
+func parseLine(line []byte) (string, Point, error) {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	i := bytes.LastIndexByte(line, ' ')
+	if i < 0 {
+		return "", Point{}, errors.New("missing timestamp")
+	}
+	ts, err := strconv.ParseInt(string(line[i+1:]), 10, 64)
+	if err != nil {
+		return "", Point{}, err
+	}
+	line = line[:i]
+	i = bytes.LastIndexByte(line, ' ')
+	if i < 0 {
+		return "", Point{}, errors.New("missing value")
+	}
+	v, err := strconv.ParseFloat(string(line[i+1:]), 64)
+	if err != nil {
+		return "", Point{}, err
+	}
+	return string(line[:i]), Point{Timestamp: ts, Value: v}, nil
+}
+
+// filePath returns the whisper file of a path, laid out like carbon does:
+// the nodes of plain paths are directories, and tagged paths are stored
+// under _tagged, in directories named after their hash.
+func filePath(root, path string) (string, error) {
+	var rel string
+	if strings.Contains(path, ";") {
+		sum := sha256.Sum256([]byte(path))
+		hash := hex.EncodeToString(sum[:])
+		rel = filepath.Join("_tagged", hash[0:3], hash[3:6], strings.ReplaceAll(path, ".", "_DOT_"))
+	} else {
+		rel = filepath.FromSlash(strings.TrimLeft(strings.ReplaceAll(path, ".", "/"), "/"))
+	}
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	return filepath.Join(root, rel+".wsp"), nil
+}
+
+// fileCache keeps the most recently used whisper files open.
+type fileCache struct {
+	max   int
+	lru   *list.List
+	files map[string]*list.Element
+}
+
+type cachedFile struct {
+	name string
+	f    *File
+}
+
+func newFileCache(max int) *fileCache {
+	return &fileCache{max: max, lru: list.New(), files: make(map[string]*list.Element)}
+}
+
+// get returns the open file name, opening it if needed.
+func (fc *fileCache) get(name string) (*File, error) {
+	if e, ok := fc.files[name]; ok {
+		fc.lru.MoveToFront(e)
+		return e.Value.(*cachedFile).f, nil
+	}
+	f, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fc.add(name, f)
+	return f, nil
+}
+
+// add adds an open file, closing the least recently used files beyond the
+// maximum.
+func (fc *fileCache) add(name string, f *File) {
+	fc.files[name] = fc.lru.PushFront(&cachedFile{name: name, f: f})
+	for fc.lru.Len() > fc.max {
+		e := fc.lru.Back()
+		cf := fc.lru.Remove(e).(*cachedFile)
+		delete(fc.files, cf.name)
+		_ = cf.f.Close()
+	}
+}
+
+func (fc *fileCache) closeAll() {
+	for e := fc.lru.Front(); e != nil; e = e.Next() {
+		_ = e.Value.(*cachedFile).f.Close()
+	}
+	fc.lru.Init()
+	fc.files = make(map[string]*list.Element)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package whisper
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testClient(t *testing.T, whisperCfg string) *Client {
+	cfg := config.DefaultConfig
+	cfg.Graphite = graphiteCfg.DefaultConfig
+	cfg.Graphite.DefaultPrefix = "prom."
+	require.NoError(t, yaml.Unmarshal([]byte(whisperCfg), &cfg.Graphite.Whisper))
+	cfg.Graphite.Whisper.RootDir = t.TempDir()
+
+	c := NewClient(&cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NotNil(t, c)
+	c.now = func() time.Time { return time.Unix(1700000000, 0) }
+	t.Cleanup(c.Shutdown)
+	return c
+}
+
+func TestClientWrite(t *testing.T) {
+	c := testClient(t, `
+storage_schemas:
+- pattern: 'prom\.up\..*'
+  retentions: 10s:1h,1m:1d
+storage_aggregation:
+- pattern: '.*\.node'
+  aggregation_method: max
+  xfiles_factor: 0
+`)
+	samples := model.Samples{
+		{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 1, Timestamp: 1699999990000},
+		{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 0, Timestamp: 1700000000000},
+		{Metric: model.Metric{"__name__": "load", "job": "api"}, Value: 0.5, Timestamp: 1700000000000},
+	}
+
+	msg, err := c.Write(samples, 0, httptest.NewRequest("POST", "/write", nil), true)
+	require.NoError(t, err)
+	assert.Equal(t, "prom.up.job.node 1.000000 1699999990\nprom.up.job.node 0.000000 1700000000\nprom.load.job.api 0.500000 1700000000\n", string(msg))
+
+	_, err = c.Write(samples, 0, httptest.NewRequest("POST", "/write", nil), false)
+	require.NoError(t, err)
+	_, err = c.Write(samples[2:], 0, httptest.NewRequest("POST", "/write?graphite.default-prefix=other.", nil), false)
+	require.NoError(t, err)
+	c.Shutdown()
+
+	root := c.cfg.Whisper.RootDir
+	w, err := Open(filepath.Join(root, "prom", "up", "job", "node.wsp"))
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+	assert.Equal(t, aggregationTypes[graphiteCfg.AggregationMax], w.aggregation)
+	assert.Equal(t, []*archive{{offset: 40, secondsPerPoint: 10, points: 360}, {offset: 4360, secondsPerPoint: 60, points: 1440}}, w.archives)
+	assert.Equal(t, []Point{{1699999990, 1}, {1700000000, 0}}, archivePoints(t, w, 0))
+	assert.Equal(t, []Point{{1699999980, 1}}, archivePoints(t, w, 1))
+
+	for _, file := range []string{"prom/load/job/api.wsp", "other/load/job/api.wsp"} {
+		w, err := Open(filepath.Join(root, filepath.FromSlash(file)))
+		require.NoError(t, err)
+		assert.Equal(t, aggregationTypes[graphiteCfg.AggregationAverage], w.aggregation)
+		assert.Equal(t, []*archive{{offset: 28, secondsPerPoint: 60, points: 1440}}, w.archives)
+		assert.Equal(t, []Point{{1699999980, 0.5}}, archivePoints(t, w, 0))
+		require.NoError(t, w.Close())
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		file string
+	}{
+		{"prom.up.job.node", "/data/prom/up/job/node.wsp"},
+		{".prom.up", "/data/prom/up.wsp"},
+		// The layout of carbon's TaggedSeries.encode.
+		{"up;job=node.1", "/data/_tagged/542/e51/up;job=node_DOT_1.wsp"},
+	} {
+		file, err := filePath("/data", tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.file, filepath.ToSlash(file))
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	fc := newFileCache(2)
+	for _, name := range []string{"a", "b", "c"} {
+		f, err := Create(filepath.Join(dir, name), graphiteCfg.DefaultRetentions, graphiteCfg.AggregationAverage, 0.5)
+		require.NoError(t, err)
+		fc.add(f.f.Name(), f)
+		if name == "b" {
+			// Using a makes b the least recently used, closed once c is added.
+			_, err = fc.get(filepath.Join(dir, "a"))
+			require.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 2, fc.lru.Len())
+	assert.Contains(t, fc.files, filepath.Join(dir, "a"))
+	assert.NotContains(t, fc.files, filepath.Join(dir, "b"))
+	fc.closeAll()
+	assert.Empty(t, fc.files)
+}
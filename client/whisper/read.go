@@ -0,0 +1,263 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package whisper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
+	"github.com/prometheus/common/model"
+	plabels "github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// taggedDir is the directory of the files of tagged paths, see filePath.
+const taggedDir = "_tagged"
+
+// series is a whisper file and the labels of its path.
+type series struct {
+	file   string
+	labels []prompb.Label
+}
+
+// Read implements the client.Reader interface. The files of the paths
+// matching a query are found by walking the directory, and read like
+// graphite-web does.
+func (c *Client) Read(req *prompb.ReadRequest, r *http.Request) (*prompb.ReadResponse, error) {
+	c.logger.Debug("Remote read", "req", req)
+
+	prefix := c.cfg.StoragePrefixFromRequest(r)
+
+	resp := &prompb.ReadResponse{}
+	for _, query := range req.Queries {
+		queryResult, err := c.readQuery(query, prefix)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, queryResult)
+	}
+	return resp, nil
+}
+
+func (c *Client) readQuery(query *prompb.Query, prefix string) (*prompb.QueryResult, error) {
+	queryResult := &prompb.QueryResult{}
+
+	now := c.now().Unix()
+	from := query.StartTimestampMs / 1000
+	until := min(query.EndTimestampMs/1000, now-int64(c.readDelay.Seconds()))
+	if until < from {
+		c.logger.Debug("Skipping query with empty time range")
+		return queryResult, nil
+	}
+
+	if c.cfg.NameEscaping == graphiteCfg.NameEscapingUnderscores {
+		query = paths.UnderscoreQueryNames(query)
+	}
+	matchers := make([]*plabels.Matcher, 0, len(query.Matchers))
+	for _, m := range query.Matchers {
+		matcher, err := plabels.NewMatcher(plabels.MatchType(m.Type), m.Name, m.Value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	found, err := c.findSeries(query.Matchers, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range found {
+		if !matchLabels(s.labels, matchers) {
+			continue
+		}
+		// Like for graphite-web, it is better to return "some" data than
+		// nothing.
+		samples, err := fetchSamples(s.file, from, until, now)
+		if err != nil {
+			c.logger.Warn("Error reading whisper file", "file", s.file, "err", err)
+			continue
+		}
+		queryResult.Timeseries = append(queryResult.Timeseries, &prompb.TimeSeries{Labels: s.labels, Samples: samples})
+	}
+	return queryResult, nil
+}
+
+// findSeries walks the whisper files which may hold series of the query,
+// and returns them with the labels parsed from their path.
+func (c *Client) findSeries(matchers []*prompb.LabelMatcher, prefix string) ([]series, error) {
+	root := c.cfg.Whisper.RootDir
+	format := paths.FormatForPrefix(c.cfg, prefix)
+	tagged := c.cfg.EnableTags && !c.cfg.UseOpenMetricsFormat
+
+	dir := filepath.Join(root, taggedDir)
+	if !tagged {
+		var err error
+		if dir, err = c.searchDir(matchers, prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	var found []series
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if file == dir && errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if !tagged && file == filepath.Join(root, taggedDir) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(file, ".wsp") {
+			return nil
+		}
+
+		var labels []prompb.Label
+		path, ok := pathOf(root, file, tagged)
+		if ok {
+			labels, ok, err = c.labelsFromPath(path, prefix, format, tagged)
+		}
+		if err != nil {
+			c.logger.Warn("Error parsing metric labels from path", "path", path, "prefix", prefix, "err", err)
+			return nil
+		}
+		if ok {
+			found = append(found, series{file: file, labels: labels})
+		}
+		return nil
+	})
+	return found, err
+}
+
+// searchDir returns the directory holding the plain paths of a query: the
+// one of the metric name when it is known and paths are written with the
+// default format, the one of the prefix otherwise. Read rules may match
+// paths anywhere.
+func (c *Client) searchDir(matchers []*prompb.LabelMatcher, prefix string) (string, error) {
+	if len(c.cfg.Read.Rules) > 0 {
+		return c.cfg.Whisper.RootDir, nil
+	}
+	path := prefix
+	if !c.cfg.EnableTags {
+		for _, m := range matchers {
+			if m.Name == model.MetricNameLabel && m.Type == prompb.LabelMatcher_EQ {
+				path = prefix + string(graphite_tmpl.Escape(m.Value))
+			}
+		}
+	}
+	rel := filepath.FromSlash(strings.Trim(strings.ReplaceAll(path, ".", "/"), "/"))
+	if rel == "" {
+		return c.cfg.Whisper.RootDir, nil
+	}
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	return filepath.Join(c.cfg.Whisper.RootDir, rel), nil
+}
+
+// labelsFromPath parses the labels of a path like the graphite client does.
+// The returned bool is false if the path is not a series of the prefix.
+func (c *Client) labelsFromPath(path, prefix string, format paths.Format, tagged bool) ([]prompb.Label, bool, error) {
+	if tagged {
+		name, tags, _ := strings.Cut(path, ";")
+		if !strings.HasPrefix(name, prefix) {
+			return nil, false, nil
+		}
+		tagMap := map[string]string{"name": name}
+		for _, tag := range strings.Split(tags, ";") {
+			k, v, ok := strings.Cut(tag, "=")
+			if !ok {
+				return nil, false, fmt.Errorf("invalid tag %q", tag)
+			}
+			tagMap[k] = v
+		}
+		labels, err := paths.MetricLabelsFromTags(tagMap, prefix, format)
+		return labels, err == nil, err
+	}
+
+	if labels, ok := paths.MetricLabelsFromReadRules(path, c.cfg.Read.Rules); ok {
+		return labels, true, nil
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return nil, false, nil
+	}
+	var labels []prompb.Label
+	var err error
+	if c.cfg.EnableTags && c.cfg.UseOpenMetricsFormat {
+		labels, err = paths.MetricLabelsFromOpenMetricsPath(path, prefix)
+	} else {
+		labels, err = paths.MetricLabelsFromPath(path, prefix)
+	}
+	return labels, err == nil, err
+}
+
+// pathOf returns the Graphite path of a whisper file, the reverse of
+// filePath. The returned bool is false for files not laid out by carbon.
+func pathOf(root, file string, tagged bool) (string, bool) {
+	rel, err := filepath.Rel(root, strings.TrimSuffix(file, ".wsp"))
+	if err != nil {
+		return "", false
+	}
+	if tagged {
+		if !strings.Contains(filepath.Base(rel), ";") {
+			return "", false
+		}
+		return strings.ReplaceAll(filepath.Base(rel), "_DOT_", "."), true
+	}
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", "."), true
+}
+
+func matchLabels(labels []prompb.Label, matchers []*plabels.Matcher) bool {
+	labelMap := make(map[string]string, len(labels))
+	for _, label := range labels {
+		labelMap[label.Name] = label.Value
+	}
+	for _, m := range matchers {
+		if !m.Matches(labelMap[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchSamples reads the points of a whisper file between from and until.
+func fetchSamples(file string, from, until, now int64) ([]prompb.Sample, error) {
+	f, err := OpenReadOnly(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	points, _, err := f.Fetch(from, until, now)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]prompb.Sample, 0, len(points))
+	for _, p := range points {
+		samples = append(samples, prompb.Sample{Value: p.Value, Timestamp: p.Timestamp * 1000})
+	}
+	return samples, nil
+}
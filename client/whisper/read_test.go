@@ -0,0 +1,88 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package whisper
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRead(t *testing.T) {
+	samples := model.Samples{
+		{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 1, Timestamp: 1699999990000},
+		{Metric: model.Metric{"__name__": "up", "job": "api"}, Value: 0, Timestamp: 1699999990000},
+		{Metric: model.Metric{"__name__": "load", "job": "api"}, Value: 0.5, Timestamp: 1699999990000},
+	}
+	query := &prompb.Query{
+		StartTimestampMs: 1699999940000,
+		EndTimestampMs:   1700000000000,
+		Matchers: []*prompb.LabelMatcher{
+			{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+			{Type: prompb.LabelMatcher_RE, Name: "job", Value: "no.*"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		enableTags bool
+		labels     []prompb.Label
+	}{
+		{"paths", false, []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "node"}}},
+		// Tags are sorted, like when reading them from graphite-web.
+		{"tags", true, []prompb.Label{{Name: "job", Value: "node"}, {Name: "__name__", Value: "up"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testClient(t, `{}`)
+			c.cfg.EnableTags = tc.enableTags
+			c.readDelay = 0
+			_, err := c.Write(samples, 0, httptest.NewRequest("POST", "/write", nil), false)
+			require.NoError(t, err)
+
+			resp, err := c.Read(&prompb.ReadRequest{Queries: []*prompb.Query{query}}, httptest.NewRequest("POST", "/read", nil))
+			require.NoError(t, err)
+			assert.Equal(t, &prompb.ReadResponse{Results: []*prompb.QueryResult{{Timeseries: []*prompb.TimeSeries{{
+				Labels:  tc.labels,
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1699999980000}},
+			}}}}}, resp)
+
+			// Series of other prefixes are ignored.
+			resp, err = c.Read(&prompb.ReadRequest{Queries: []*prompb.Query{query}}, httptest.NewRequest("POST", "/read?graphite.default-prefix=other.", nil))
+			require.NoError(t, err)
+			assert.Empty(t, resp.Results[0].Timeseries)
+		})
+	}
+}
+
+func TestPathOf(t *testing.T) {
+	for _, tc := range []struct {
+		path   string
+		tagged bool
+	}{
+		{"prom.up.job.node", false},
+		{"up;job=node.1", true},
+	} {
+		file, err := filePath("/data", tc.path)
+		require.NoError(t, err)
+		path, ok := pathOf("/data", file, tc.tagged)
+		assert.True(t, ok)
+		assert.Equal(t, tc.path, path)
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package whisper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+)
+
+// Sizes of the big endian structures of the whisper format: the metadata
+// (aggregation type, max retention, xFilesFactor, archive count), the archive
+// infos (offset, seconds per point, points) and the points (interval, value).
+const (
+	metadataSize    = 16
+	archiveInfoSize = 12
+	pointSize       = 12
+)
+
+// aggregationTypes are the codes of the aggregation methods in the header.
+var aggregationTypes = map[string]uint32{
+	config.AggregationAverage: 1,
+	config.AggregationSum:     2,
+	config.AggregationLast:    3,
+	config.AggregationMax:     4,
+	config.AggregationMin:     5,
+	config.AggregationAvgZero: 6,
+	config.AggregationAbsMax:  7,
+	config.AggregationAbsMin:  8,
+}
+
+// Point is a value of a whisper file.
+type Point struct {
+	Timestamp int64
+	Value     float64
+}
+
+type archive struct {
+	offset          int64
+	secondsPerPoint int64
+	points          int64
+}
+
+func (a *archive) size() int64      { return a.points * pointSize }
+func (a *archive) retention() int64 { return a.secondsPerPoint * a.points }
+
+// offsetOf returns the offset of the point of an interval, relative to the
+// interval of the first point of the archive.
+func (a *archive) offsetOf(interval, baseInterval int64) int64 {
+	pointDistance := (interval - baseInterval) / a.secondsPerPoint
+	return a.offset + mod(pointDistance*pointSize, a.size())
+}
+
+// File is a whisper file, in the format written by carbon.
+type File struct {
+	f            *os.File
+	aggregation  uint32
+	maxRetention int64
+	xFilesFactor float32
+	archives     []*archive
+}
+
+// Create creates a whisper file with the retentions and the aggregation.
+// Like carbon, the archives are preallocated with zeroes.
+func Create(path string, retentions config.Retentions, aggregation string, xFilesFactor float64) (*File, error) {
+	aggregationType, ok := aggregationTypes[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation method %q", aggregation)
+	}
+	if len(retentions) == 0 {
+		return nil, errors.New("no retentions")
+	}
+
+	w := &File{aggregation: aggregationType, xFilesFactor: float32(xFilesFactor)}
+	offset := int64(metadataSize + archiveInfoSize*len(retentions))
+	for _, r := range retentions {
+		a := &archive{offset: offset, secondsPerPoint: int64(r.SecondsPerPoint), points: int64(r.Points)}
+		w.archives = append(w.archives, a)
+		w.maxRetention = max(w.maxRetention, a.retention())
+		offset += a.size()
+	}
+
+	header := make([]byte, 0, metadataSize+archiveInfoSize*len(w.archives))
+	header = binary.BigEndian.AppendUint32(header, w.aggregation)
+	header = binary.BigEndian.AppendUint32(header, uint32(w.maxRetention))
+	header = binary.BigEndian.AppendUint32(header, math.Float32bits(w.xFilesFactor))
+	header = binary.BigEndian.AppendUint32(header, uint32(len(w.archives)))
+	for _, a := range w.archives {
+		header = binary.BigEndian.AppendUint32(header, uint32(a.offset))
+		header = binary.BigEndian.AppendUint32(header, uint32(a.secondsPerPoint))
+		header = binary.BigEndian.AppendUint32(header, uint32(a.points))
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w.f = f
+	if _, err := f.Write(header); err == nil {
+		err = f.Truncate(offset)
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Open opens an existing whisper file.
+func Open(path string) (*File, error) {
+	return open(path, os.O_RDWR)
+}
+
+// OpenReadOnly opens an existing whisper file for reading only.
+func OpenReadOnly(path string) (*File, error) {
+	return open(path, os.O_RDONLY)
+}
+
+func open(path string, flag int) (*File, error) {
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	w, err := readHeader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("invalid whisper file %s: %w", path, err)
+	}
+	return w, nil
+}
+
+func readHeader(f *os.File) (*File, error) {
+	metadata := make([]byte, metadataSize)
+	if _, err := f.ReadAt(metadata, 0); err != nil {
+		return nil, err
+	}
+	w := &File{
+		f:            f,
+		aggregation:  binary.BigEndian.Uint32(metadata[0:]),
+		maxRetention: int64(binary.BigEndian.Uint32(metadata[4:])),
+		xFilesFactor: math.Float32frombits(binary.BigEndian.Uint32(metadata[8:])),
+	}
+	count := binary.BigEndian.Uint32(metadata[12:])
+	if count == 0 || count > 64 {
+		return nil, fmt.Errorf("unexpected archive count %d", count)
+	}
+
+	infos := make([]byte, archiveInfoSize*int(count))
+	if _, err := f.ReadAt(infos, metadataSize); err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(count); i++ {
+		info := infos[i*archiveInfoSize:]
+		w.archives = append(w.archives, &archive{
+			offset:          int64(binary.BigEndian.Uint32(info[0:])),
+			secondsPerPoint: int64(binary.BigEndian.Uint32(info[4:])),
+			points:          int64(binary.BigEndian.Uint32(info[8:])),
+		})
+	}
+	return w, nil
+}
+
+// Close closes the file.
+func (w *File) Close() error {
+	return w.f.Close()
+}
+
+// Fetch returns the known points between from, excluded, and until, like
+// whisper's fetch: the range is read from the most precise archive retaining
+// from at now, and clipped to the retention of the file. The step of the
+// points is returned with them.
+func (w *File) Fetch(from, until, now int64) ([]Point, int64, error) {
+	if from > until {
+		return nil, 0, fmt.Errorf("invalid time interval: from %d is after until %d", from, until)
+	}
+	oldest := now - w.maxRetention
+	if from > now || until < oldest {
+		return nil, 0, nil
+	}
+	from = max(from, oldest)
+	until = min(until, now)
+
+	a := w.archives[len(w.archives)-1]
+	for _, candidate := range w.archives {
+		if candidate.retention() >= now-from {
+			a = candidate
+			break
+		}
+	}
+	step := a.secondsPerPoint
+	fromInterval := from - mod(from, step) + step
+	untilInterval := until - mod(until, step) + step
+	if fromInterval == untilInterval {
+		// Like whisper, always return at least one point.
+		untilInterval += step
+	}
+
+	baseInterval, _, err := w.readPoint(a.offset)
+	if err != nil || baseInterval == 0 {
+		return nil, step, err
+	}
+	count := min((untilInterval-fromInterval)/step, a.points)
+	series, err := w.readSeries(a, a.offsetOf(fromInterval, baseInterval), count)
+	if err != nil {
+		return nil, step, err
+	}
+
+	var points []Point
+	for i, p := range series {
+		// Points left from a previous pass over the archive are stale.
+		if p.Timestamp == fromInterval+int64(i)*step {
+			points = append(points, p)
+		}
+	}
+	return points, step, nil
+}
+
+// UpdateMany writes points, like whisper's update_many: each point is
+// written to the most precise archive retaining it at now, and propagated
+// to the lower precision archives. Points older than the retention of the
+// file are dropped.
+func (w *File) UpdateMany(points []Point, now int64) error {
+	points = append([]Point(nil), points...)
+	// Newest first. Like whisper, the first of points with the same timestamp
+	// is kept.
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp > points[j].Timestamp })
+
+	i := 0
+	var current []Point
+	for _, p := range points {
+		age := now - p.Timestamp
+		for i < len(w.archives) && w.archives[i].retention() < age {
+			if len(current) > 0 {
+				if err := w.archiveUpdateMany(i, current); err != nil {
+					return err
+				}
+				current = nil
+			}
+			i++
+		}
+		if i == len(w.archives) {
+			// The remaining points don't fit in the file.
+			return nil
+		}
+		current = append(current, p)
+	}
+	if len(current) > 0 {
+		return w.archiveUpdateMany(i, current)
+	}
+	return nil
+}
+
+// archiveUpdateMany writes points, newest first, to an archive and
+// propagates them to the lower precision archives.
+func (w *File) archiveUpdateMany(index int, points []Point) error {
+	a := w.archives[index]
+	step := a.secondsPerPoint
+
+	// Aligned intervals in chronological order, of the last point written
+	// in each interval.
+	var intervals []int64
+	values := make(map[int64]float64, len(points))
+	for j := len(points) - 1; j >= 0; j-- {
+		interval := points[j].Timestamp - mod(points[j].Timestamp, step)
+		if _, ok := values[interval]; !ok {
+			intervals = append(intervals, interval)
+		}
+		values[interval] = points[j].Value
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+
+	baseInterval, _, err := w.readPoint(a.offset)
+	if err != nil {
+		return err
+	}
+	if baseInterval == 0 {
+		// First update of the archive.
+		baseInterval = intervals[0]
+	}
+	for _, interval := range intervals {
+		if err := w.writePoint(a.offsetOf(interval, baseInterval), interval, values[interval]); err != nil {
+			return err
+		}
+	}
+
+	higher := a
+	for _, lower := range w.archives[index+1:] {
+		var lowerIntervals []int64
+		seen := make(map[int64]struct{})
+		for _, interval := range intervals {
+			lowerInterval := interval - mod(interval, lower.secondsPerPoint)
+			if _, ok := seen[lowerInterval]; !ok {
+				seen[lowerInterval] = struct{}{}
+				lowerIntervals = append(lowerIntervals, lowerInterval)
+			}
+		}
+		propagateFurther := false
+		for _, lowerInterval := range lowerIntervals {
+			propagated, err := w.propagate(lowerInterval, higher, lower)
+			if err != nil {
+				return err
+			}
+			propagateFurther = propagateFurther || propagated
+		}
+		if !propagateFurther {
+			break
+		}
+		higher = lower
+	}
+	return nil
+}
+
+// propagate aggregates the points of the higher archive in the interval of
+// the lower archive starting at lowerInterval, and writes the aggregate to
+// the lower archive if enough points are known.
+func (w *File) propagate(lowerInterval int64, higher, lower *archive) (bool, error) {
+	higherBaseInterval, _, err := w.readPoint(higher.offset)
+	if err != nil {
+		return false, err
+	}
+	firstOffset := higher.offset
+	if higherBaseInterval != 0 {
+		firstOffset = higher.offsetOf(lowerInterval, higherBaseInterval)
+	}
+
+	count := lower.secondsPerPoint / higher.secondsPerPoint
+	series, err := w.readSeries(higher, firstOffset, count)
+	if err != nil {
+		return false, err
+	}
+
+	var known []float64
+	interval := lowerInterval
+	for _, p := range series {
+		if p.Timestamp == interval {
+			known = append(known, p.Value)
+		}
+		interval += higher.secondsPerPoint
+	}
+	if len(known) == 0 || float64(len(known))/float64(count) < float64(w.xFilesFactor) {
+		return false, nil
+	}
+
+	lowerBaseInterval, _, err := w.readPoint(lower.offset)
+	if err != nil {
+		return false, err
+	}
+	offset := lower.offset
+	if lowerBaseInterval != 0 {
+		offset = lower.offsetOf(lowerInterval, lowerBaseInterval)
+	}
+	return true, w.writePoint(offset, lowerInterval, w.aggregate(known, int(count)))
+}
+
+// aggregate aggregates the known values of an interval of count points.
+func (w *File) aggregate(known []float64, count int) float64 {
+	var sum float64
+	for _, v := range known {
+		sum += v
+	}
+	switch w.aggregation {
+	case aggregationTypes[config.AggregationSum]:
+		return sum
+	case aggregationTypes[config.AggregationLast]:
+		return known[len(known)-1]
+	case aggregationTypes[config.AggregationMax]:
+		return fold(known, func(a, b float64) bool { return b > a })
+	case aggregationTypes[config.AggregationMin]:
+		return fold(known, func(a, b float64) bool { return b < a })
+	case aggregationTypes[config.AggregationAvgZero]:
+		return sum / float64(count)
+	case aggregationTypes[config.AggregationAbsMax]:
+		return fold(known, func(a, b float64) bool { return math.Abs(b) > math.Abs(a) })
+	case aggregationTypes[config.AggregationAbsMin]:
+		return fold(known, func(a, b float64) bool { return math.Abs(b) < math.Abs(a) })
+	default:
+		return sum / float64(len(known))
+	}
+}
+
+// fold returns the value of values which replaces all the others.
+func fold(values []float64, replaces func(a, b float64) bool) float64 {
+	v := values[0]
+	for _, b := range values[1:] {
+		if replaces(v, b) {
+			v = b
+		}
+	}
+	return v
+}
+
+// readSeries reads count points of an archive from offset, wrapping around
+// the end of the archive.
+func (w *File) readSeries(a *archive, offset, count int64) ([]Point, error) {
+	buf := make([]byte, count*pointSize)
+	end := a.offset + a.size()
+	n := min(int64(len(buf)), end-offset)
+	if _, err := w.f.ReadAt(buf[:n], offset); err != nil {
+		return nil, err
+	}
+	if n < int64(len(buf)) {
+		if _, err := w.f.ReadAt(buf[n:], a.offset); err != nil {
+			return nil, err
+		}
+	}
+
+	points := make([]Point, count)
+	for i := range points {
+		points[i] = decodePoint(buf[i*pointSize:])
+	}
+	return points, nil
+}
+
+func (w *File) readPoint(offset int64) (int64, float64, error) {
+	buf := make([]byte, pointSize)
+	if _, err := w.f.ReadAt(buf, offset); err != nil {
+		return 0, 0, err
+	}
+	p := decodePoint(buf)
+	return p.Timestamp, p.Value, nil
+}
+
+func (w *File) writePoint(offset, interval int64, value float64) error {
+	buf := make([]byte, 0, pointSize)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(interval))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(value))
+	_, err := w.f.WriteAt(buf, offset)
+	return err
+}
+
+func decodePoint(buf []byte) Point {
+	return Point{
+		Timestamp: int64(binary.BigEndian.Uint32(buf[0:])),
+		Value:     math.Float64frombits(binary.BigEndian.Uint64(buf[4:])),
+	}
+}
+
+// mod is the modulo of Python, which whisper relies on for offsets before
+// the first point of an archive.
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
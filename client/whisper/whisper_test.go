@@ -0,0 +1,156 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package whisper
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// archivePoints returns the non empty points of an archive, in file order.
+func archivePoints(t *testing.T, w *File, index int) []Point {
+	a := w.archives[index]
+	series, err := w.readSeries(a, a.offset, a.points)
+	require.NoError(t, err)
+	var points []Point
+	for _, p := range series {
+		if p.Timestamp != 0 {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+func TestCreate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.wsp")
+	retentions, err := config.ParseRetentions("1m:1h,1h:1d")
+	require.NoError(t, err)
+	w, err := Create(file, retentions, config.AggregationMax, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Len(t, content, 16+2*12+(60+24)*12)
+	// The header written by whisper.create([(60, 60), (3600, 24)], 0.5, 'max').
+	assert.Equal(t, "00000004"+"00015180"+"3f000000"+"00000002"+
+		"00000028"+"0000003c"+"0000003c"+
+		"000002f8"+"00000e10"+"00000018", hex.EncodeToString(content[:40]))
+
+	w, err = Open(file)
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+	assert.Equal(t, int64(86400), w.maxRetention)
+	assert.Equal(t, float32(0.5), w.xFilesFactor)
+	require.Len(t, w.archives, 2)
+	assert.Equal(t, archive{offset: 760, secondsPerPoint: 3600, points: 24}, *w.archives[1])
+
+	_, err = Create(file, retentions, config.AggregationMax, 0.5)
+	assert.Error(t, err, "files are not overwritten")
+}
+
+func TestUpdateMany(t *testing.T) {
+	retentions, err := config.ParseRetentions("10s:6,60s:10")
+	require.NoError(t, err)
+	now := int64(1700000000)
+
+	for _, tc := range []struct {
+		aggregation  string
+		xFilesFactor float64
+		lower        []Point
+	}{
+		{config.AggregationAverage, 0.5, []Point{{1699999920, 2}}},
+		{config.AggregationSum, 0.5, []Point{{1699999920, 6}}},
+		{config.AggregationAvgZero, 0.5, []Point{{1699999920, 1}}},
+		{config.AggregationAbsMin, 0.5, []Point{{1699999920, 2}}},
+		// Only 3 of the 6 points of the interval are known.
+		{config.AggregationLast, 0.9, nil},
+		{config.AggregationLast, 0, []Point{{1699999920, 7}, {1699999980, 9}}},
+	} {
+		t.Run(tc.aggregation, func(t *testing.T) {
+			w, err := Create(filepath.Join(t.TempDir(), "test.wsp"), retentions, tc.aggregation, tc.xFilesFactor)
+			require.NoError(t, err)
+			defer func() { _ = w.Close() }()
+
+			require.NoError(t, w.UpdateMany([]Point{
+				{1699999945, 2}, {1699999963, -3}, {1699999975, 7}, {1699999982, 9}, {1699999981, 8},
+				// Too old for the file.
+				{1699999000, 1},
+			}, now))
+			assert.Equal(t, []Point{{1699999940, 2}, {1699999960, -3}, {1699999970, 7}, {1699999980, 9}}, archivePoints(t, w, 0))
+			assert.Equal(t, tc.lower, archivePoints(t, w, 1))
+		})
+	}
+}
+
+func TestUpdateManyWraps(t *testing.T) {
+	retentions, err := config.ParseRetentions("10s:6,60s:10")
+	require.NoError(t, err)
+	w, err := Create(filepath.Join(t.TempDir(), "test.wsp"), retentions, config.AggregationAverage, 0)
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+
+	now := int64(1700000000)
+	require.NoError(t, w.UpdateMany([]Point{{now - 30, 1}, {now - 20, 2}}, now))
+	// The first archive wraps, and points older than it are only written to
+	// the second one.
+	require.NoError(t, w.UpdateMany([]Point{{now - 200, 10}, {now + 10, 3}, {now + 20, 4}, {now + 30, 5}, {now + 40, 6}}, now+40))
+	assert.ElementsMatch(t, []Point{{now + 10, 3}, {now + 20, 4}, {now + 30, 5}, {now + 40, 6}}, archivePoints(t, w, 0))
+	assert.ElementsMatch(t, []Point{{now - 200, 10}, {now - 80, 1}, {now - 20, 4}, {now + 40, 6}}, archivePoints(t, w, 1))
+}
+
+func TestFetch(t *testing.T) {
+	retentions, err := config.ParseRetentions("10s:6,60s:10")
+	require.NoError(t, err)
+	w, err := Create(filepath.Join(t.TempDir(), "test.wsp"), retentions, config.AggregationAverage, 0)
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+
+	now := int64(1700000000)
+	points, _, err := w.Fetch(now-50, now, now)
+	require.NoError(t, err)
+	assert.Empty(t, points, "nothing was written yet")
+
+	require.NoError(t, w.UpdateMany([]Point{{now - 30, 1}, {now - 20, 2}, {now - 10, 3}}, now))
+
+	points, step, err := w.Fetch(now-50, now, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), step)
+	assert.Equal(t, []Point{{now - 30, 1}, {now - 20, 2}, {now - 10, 3}}, points)
+
+	// The range isn't retained by the first archive.
+	points, step, err = w.Fetch(now-500, now, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), step)
+	assert.Equal(t, []Point{{now - 80, 1}, {now - 20, 2.5}}, points)
+
+	// Points written before the archive wrapped are stale.
+	points, _, err = w.Fetch(now+30, now+60, now+60)
+	require.NoError(t, err)
+	assert.Empty(t, points)
+
+	points, _, err = w.Fetch(now+10, now+20, now)
+	require.NoError(t, err)
+	assert.Empty(t, points)
+	_, _, err = w.Fetch(now, now-10, now)
+	assert.Error(t, err)
+}
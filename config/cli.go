@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	graphite "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	"github.com/alecthomas/kingpin/v2"
@@ -28,9 +30,67 @@ import (
 	"github.com/prometheus/common/version"
 )
 
+// Commands of the command line.
+const (
+	// CommandRun runs the adapter, the default.
+	CommandRun = "run"
+	// CommandBackfill replays historical series to Graphite.
+	CommandBackfill = "backfill"
+	// CommandExport dumps Graphite series as OpenMetrics or TSDB blocks.
+	CommandExport = "export"
+	// CommandTestRules runs unit tests of the write rules.
+	CommandTestRules = "test-rules"
+	// CommandCheckConfig checks config files and the templates of their
+	// write rules.
+	CommandCheckConfig = "check-config"
+)
+
+// backfillOptions are the options of the backfill command.
+type backfillOptions struct {
+	Start          time.Time
+	End            time.Time
+	Match          []string
+	RemoteReadURL  string
+	TSDBPath       string
+	Window         time.Duration
+	BatchSize      int
+	RateLimit      float64
+	CheckpointFile string
+	DryRun         bool
+}
+
+// exportOptions are the options of the export command.
+type exportOptions struct {
+	Start    time.Time
+	End      time.Time
+	Match    []string
+	Prefix   string
+	Window   time.Duration
+	TSDBPath string
+}
+
+// testRulesOptions are the options of the test-rules command.
+type testRulesOptions struct {
+	Files []string
+}
+
+// checkConfigOptions are the options of the check-config command.
+type checkConfigOptions struct {
+	Files  []string
+	Series []string
+	Strict bool
+}
+
 // ParseCommandLine parse flags and args from cli.
 func ParseCommandLine() *Config {
 	cfg := DefaultConfig
+	// The carbon listener, OTLP, scrape and federation options are set in the
+	// config file. Leave their defaults to it, so they don't override the file
+	// once merged.
+	cfg.CarbonListener = carbonListenerOptions{}
+	cfg.OTLP = otlpOptions{}
+	cfg.Scrape = scrapeOptions{}
+	cfg.Federation = federationOptions{}
 
 	a := kingpin.New(filepath.Base(os.Args[0]), "The Graphite remote adapter")
 
@@ -66,6 +126,92 @@ func ParseCommandLine() *Config {
 		"Avoid returning error to promtheus returning empty result instead.").
 		BoolVar(&cfg.Read.IgnoreError)
 
+	a.Flag("read.max-samples",
+		"Maximum number of samples a single query of the HTTP API can load. Default is 50000000").
+		Default(fmt.Sprint(DefaultConfig.Read.MaxSamples)).
+		IntVar(&cfg.Read.MaxSamples)
+
+	a.Flag("read.lookback-delta",
+		"Maximum lookback duration for retrieving samples in queries of the HTTP API. Default is 5m").
+		Default(DefaultConfig.Read.LookbackDelta.String()).
+		DurationVar(&cfg.Read.LookbackDelta)
+
+	a.Flag("graphite-api.remote-read-url",
+		"Prometheus remote read URL serving the Graphite render and find APIs. Disabled if empty.").
+		StringVar(&cfg.GraphiteAPI.RemoteReadURL)
+
+	a.Flag("graphite-api.timeout",
+		"Maximum duration before timing out remote read requests of the Graphite API. Default is 1m").
+		Default(DefaultConfig.GraphiteAPI.Timeout.String()).
+		DurationVar(&cfg.GraphiteAPI.Timeout)
+
+	a.Flag("graphite-api.step",
+		"Step at which the Graphite API consolidates series. Default is 1m").
+		Default(DefaultConfig.GraphiteAPI.Step.String()).
+		DurationVar(&cfg.GraphiteAPI.Step)
+
+	a.Flag("carbon-listener.listen-address",
+		"Address to listen on for carbon plaintext metrics, on TCP and UDP. Disabled if empty.").
+		StringVar(&cfg.CarbonListener.ListenAddress)
+
+	a.Flag("carbon-listener.remote-write-url",
+		"Prometheus remote write URL the metrics received by the carbon listener are forwarded to.").
+		StringVar(&cfg.CarbonListener.RemoteWriteURL)
+
+	a.Command(CommandRun, "Run the remote adapter.").Default()
+
+	var start, end string
+	backfill := a.Command(CommandBackfill,
+		"Replay the series of a time range to Graphite, through the rules and templates of the config file.")
+	backfill.Flag("start", "Start of the time range, as a RFC 3339 date or a Unix timestamp.").
+		Required().StringVar(&start)
+	backfill.Flag("end", "End of the time range, as a RFC 3339 date or a Unix timestamp. Default is now").
+		StringVar(&end)
+	backfill.Flag("match", "Series selector of the replayed series. Can be repeated.").
+		Required().StringsVar(&cfg.Backfill.Match)
+	backfill.Flag("remote-read-url", "Prometheus remote read URL the series are read from.").
+		StringVar(&cfg.Backfill.RemoteReadURL)
+	backfill.Flag("tsdb-path", "Prometheus TSDB directory the series are read from.").
+		StringVar(&cfg.Backfill.TSDBPath)
+	backfill.Flag("window", "Duration of the time windows read and written at once. Default is 1h").
+		Default("1h").DurationVar(&cfg.Backfill.Window)
+	backfill.Flag("batch-size", "Maximum number of samples of a carbon write. Default is 10000").
+		Default("10000").IntVar(&cfg.Backfill.BatchSize)
+	backfill.Flag("rate-limit", "Maximum number of samples written per second. Unlimited if 0.").
+		Float64Var(&cfg.Backfill.RateLimit)
+	backfill.Flag("checkpoint-file", "File recording the progress of the backfill, to resume it from.").
+		StringVar(&cfg.Backfill.CheckpointFile)
+	backfill.Flag("dry-run", "Print the carbon lines instead of writing them.").
+		BoolVar(&cfg.Backfill.DryRun)
+
+	export := a.Command(CommandExport,
+		"Dump the Graphite series of a time range as OpenMetrics text, or as TSDB blocks.")
+	export.Flag("start", "Start of the time range, as a RFC 3339 date or a Unix timestamp.").
+		Required().StringVar(&start)
+	export.Flag("end", "End of the time range, as a RFC 3339 date or a Unix timestamp. Default is now").
+		StringVar(&end)
+	export.Flag("match", "Series selector of the exported series. Can be repeated.").
+		Required().StringsVar(&cfg.Export.Match)
+	export.Flag("prefix", "Graphite prefix the series are read from. Default is the default prefix").
+		StringVar(&cfg.Export.Prefix)
+	export.Flag("window", "Duration of the time windows read at once, and of the TSDB blocks. Default is 2h").
+		Default("2h").DurationVar(&cfg.Export.Window)
+	export.Flag("tsdb-path", "Directory the TSDB blocks are written to, instead of printing OpenMetrics text.").
+		StringVar(&cfg.Export.TSDBPath)
+
+	testRules := a.Command(CommandTestRules,
+		"Run unit tests of the rules and templates of the config file, giving series and their expected carbon lines.")
+	testRules.Arg("test-file", "Rule test files.").Required().ExistingFilesVar(&cfg.TestRules.Files)
+
+	checkConfig := a.Command(CommandCheckConfig,
+		"Check config files, executing the templates of their rules, and print a JSON report.")
+	checkConfig.Arg("config-file", "Config files to check. Default is the config file").
+		StringsVar(&cfg.CheckConfig.Files)
+	checkConfig.Flag("series", "Series selector of a label set the templates are executed with. Can be repeated.").
+		StringsVar(&cfg.CheckConfig.Series)
+	checkConfig.Flag("strict", "Fail on warnings too.").
+		BoolVar(&cfg.CheckConfig.Strict)
+
 	// Add logLevel flag
 	a.Flag(promslogflag.LevelFlagName, promslogflag.LevelFlagHelp).
 		Default("info").SetValue(&cfg.LogLevel)
@@ -73,11 +219,74 @@ func ParseCommandLine() *Config {
 	// Add graphite flag
 	graphite.AddCommandLine(a, &cfg.Graphite)
 
-	_, err := a.Parse(os.Args[1:])
+	cmd, err := a.Parse(os.Args[1:])
+	if err == nil && cmd == CommandBackfill {
+		err = parseBackfillRange(&cfg.Backfill, start, end)
+	}
+	if err == nil && cmd == CommandExport {
+		err = parseExportRange(&cfg.Export, start, end)
+	}
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, errors.Wrapf(err, "Error parsing commandline arguments"))
 		a.Usage(os.Args[1:])
 		os.Exit(2)
 	}
+	cfg.Command = cmd
 	return &cfg
 }
+
+func parseBackfillRange(opts *backfillOptions, start, end string) error {
+	var err error
+	if opts.Start, opts.End, err = parseRange(start, end); err != nil {
+		return err
+	}
+	if (opts.RemoteReadURL == "") == (opts.TSDBPath == "") {
+		return fmt.Errorf("backfill requires either a remote read URL or a TSDB path")
+	}
+	if opts.Window <= 0 || opts.BatchSize <= 0 {
+		return fmt.Errorf("backfill window and batch size must be positive")
+	}
+	return nil
+}
+
+func parseExportRange(opts *exportOptions, start, end string) error {
+	var err error
+	if opts.Start, opts.End, err = parseRange(start, end); err != nil {
+		return err
+	}
+	if opts.Window <= 0 {
+		return fmt.Errorf("export window must be positive")
+	}
+	return nil
+}
+
+// parseRange parses the start and end of a time range, which ends now if
+// end is empty.
+func parseRange(start, end string) (time.Time, time.Time, error) {
+	s, err := parseTime(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	e := time.Now()
+	if end != "" {
+		if e, err = parseTime(end); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if !e.After(s) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end %s is not after start %s", e, s)
+	}
+	return s, e, nil
+}
+
+// parseTime parses a RFC 3339 date or a Unix timestamp in seconds.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	ts, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse %q as a RFC 3339 date or a Unix timestamp", s)
+	}
+	return time.UnixMilli(int64(ts * 1000)), nil
+}
@@ -18,7 +18,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -27,6 +29,8 @@ import (
 	graphite "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
 	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"gopkg.in/yaml.v3"
 )
 
@@ -67,24 +71,62 @@ var DefaultConfig = Config{
 		TelemetryPath: "/metrics",
 	},
 	Read: readOptions{
-		Timeout:     5 * time.Minute,
-		Delay:       1 * time.Hour,
-		IgnoreError: true,
+		Timeout:       5 * time.Minute,
+		Delay:         1 * time.Hour,
+		IgnoreError:   true,
+		MaxSamples:    50000000,
+		LookbackDelta: 5 * time.Minute,
 	},
 	Write: writeOptions{
 		Timeout: 5 * time.Minute,
 	},
+	GraphiteAPI: graphiteAPIOptions{
+		Timeout: 1 * time.Minute,
+		Step:    1 * time.Minute,
+	},
+	OTLP: otlpOptions{
+		AddMetricSuffixes: true,
+	},
+	CarbonListener: carbonListenerOptions{
+		LabelMapping:  CarbonLabelMappingName,
+		Timeout:       30 * time.Second,
+		BatchSize:     1000,
+		FlushInterval: 5 * time.Second,
+		QueueSize:     100000,
+		MaxRetries:    5,
+		RetryBackoff:  1 * time.Second,
+	},
+	Scrape: scrapeOptions{
+		ScrapeInterval: 1 * time.Minute,
+		ScrapeTimeout:  10 * time.Second,
+	},
+	Federation: federationOptions{
+		Interval: 1 * time.Minute,
+		Timeout:  30 * time.Second,
+	},
 	Graphite: graphite.DefaultConfig,
 }
 
 // Config is the top-level configuration.
 type Config struct {
-	ConfigFile string
-	LogLevel   promslog.Level
-	Web        webOptions      `yaml:"web,omitempty" json:"web,omitempty"`
-	Read       readOptions     `yaml:"read,omitempty" json:"read,omitempty"`
-	Write      writeOptions    `yaml:"write,omitempty" json:"write,omitempty"`
-	Graphite   graphite.Config `yaml:"graphite,omitempty" json:"graphite,omitempty"`
+	ConfigFile     string
+	LogLevel       promslog.Level
+	Command        string                `yaml:"-" json:"-"`
+	Backfill       backfillOptions       `yaml:"-" json:"-"`
+	Export         exportOptions         `yaml:"-" json:"-"`
+	TestRules      testRulesOptions      `yaml:"-" json:"-"`
+	CheckConfig    checkConfigOptions    `yaml:"-" json:"-"`
+	Web            webOptions            `yaml:"web,omitempty" json:"web,omitempty"`
+	Read           readOptions           `yaml:"read,omitempty" json:"read,omitempty"`
+	Write          writeOptions          `yaml:"write,omitempty" json:"write,omitempty"`
+	GraphiteAPI    graphiteAPIOptions    `yaml:"graphite_api,omitempty" json:"graphite_api,omitempty"`
+	OTLP           otlpOptions           `yaml:"otlp,omitempty" json:"otlp,omitempty"`
+	CarbonListener carbonListenerOptions `yaml:"carbon_listener,omitempty" json:"carbon_listener,omitempty"`
+	Scrape         scrapeOptions         `yaml:"scrape,omitempty" json:"scrape,omitempty"`
+	Federation     federationOptions     `yaml:"federation,omitempty" json:"federation,omitempty"`
+	Graphite       graphite.Config       `yaml:"graphite,omitempty" json:"graphite,omitempty"`
+	// Clients are built in addition to the ones of the graphite block.
+	Clients []*ClientConfig `yaml:"clients,omitempty" json:"clients,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
@@ -119,6 +161,10 @@ func (c Config) String() string {
 	return str
 }
 
+// reservedClientNames are the names of the clients built from the graphite
+// block, which the entries of the clients list can't take.
+var reservedClientNames = map[string]struct{}{"graphite": {}, "whisper": {}}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type plain Config
@@ -127,6 +173,17 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
 	}
+
+	names := make(map[string]struct{}, len(c.Clients))
+	for _, cc := range c.Clients {
+		if _, ok := reservedClientNames[cc.Name]; ok {
+			return fmt.Errorf("client name %q is reserved for the clients of the graphite block", cc.Name)
+		}
+		if _, ok := names[cc.Name]; ok {
+			return fmt.Errorf("found multiple clients named %q", cc.Name)
+		}
+		names[cc.Name] = struct{}{}
+	}
 	return utils.CheckOverflow(c.XXX, "config")
 }
 
@@ -154,6 +211,9 @@ type readOptions struct {
 	Timeout     time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
 	Delay       time.Duration `yaml:"delay,omitempty" json:"delay,omitempty"`
 	IgnoreError bool          `yaml:"ignore_error,omitempty" json:"ignore_error,omitempty"`
+	// MaxSamples and LookbackDelta apply to PromQL queries of the HTTP API.
+	MaxSamples    int           `yaml:"max_samples,omitempty" json:"max_samples,omitempty"`
+	LookbackDelta time.Duration `yaml:"lookback_delta,omitempty" json:"lookback_delta,omitempty"`
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline" json:"-"`
@@ -189,3 +249,414 @@ func (opts *writeOptions) UnmarshalYAML(unmarshal func(interface{}) error) error
 
 	return utils.CheckOverflow(opts.XXX, "writeOptions")
 }
+
+type graphiteAPIOptions struct {
+	// RemoteReadURL enables the Graphite render and find APIs, reading
+	// series from this Prometheus remote read endpoint.
+	RemoteReadURL string        `yaml:"remote_read_url,omitempty" json:"remote_read_url,omitempty"`
+	Timeout       time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Step          time.Duration `yaml:"step,omitempty" json:"step,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (opts *graphiteAPIOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain graphiteAPIOptions
+
+	*opts = DefaultConfig.GraphiteAPI
+	if err := unmarshal((*plain)(opts)); err != nil {
+		return err
+	}
+
+	return utils.CheckOverflow(opts.XXX, "graphiteAPIOptions")
+}
+
+type otlpOptions struct {
+	// AddMetricSuffixes appends the unit and the _total suffix of counters
+	// to the names of the metrics received on /v1/metrics.
+	AddMetricSuffixes            bool     `yaml:"add_metric_suffixes" json:"add_metric_suffixes"`
+	PromoteResourceAttributes    []string `yaml:"promote_resource_attributes,omitempty" json:"promote_resource_attributes,omitempty"`
+	PromoteAllResourceAttributes bool     `yaml:"promote_all_resource_attributes,omitempty" json:"promote_all_resource_attributes,omitempty"`
+	PromoteScopeMetadata         bool     `yaml:"promote_scope_metadata,omitempty" json:"promote_scope_metadata,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (opts *otlpOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain otlpOptions
+
+	*opts = DefaultConfig.OTLP
+	if err := unmarshal((*plain)(opts)); err != nil {
+		return err
+	}
+
+	return utils.CheckOverflow(opts.XXX, "otlpOptions")
+}
+
+// Label mappings of the carbon listener, applied to the plain paths which
+// match no mapping rule.
+const (
+	// CarbonLabelMappingName uses the whole path as the metric name, with
+	// the characters invalid in Prometheus names replaced by '_'.
+	CarbonLabelMappingName = "name"
+	// CarbonLabelMappingPath parses the paths written by the adapter:
+	// <name>.<labelName>.<labelValue>...
+	CarbonLabelMappingPath = "path"
+)
+
+type carbonListenerOptions struct {
+	// ListenAddress enables the carbon plaintext listener, on TCP and UDP.
+	ListenAddress string `yaml:"listen_address,omitempty" json:"listen_address,omitempty"`
+	// RemoteWriteURL is the Prometheus remote write endpoint the received
+	// samples are forwarded to.
+	RemoteWriteURL string           `yaml:"remote_write_url,omitempty" json:"remote_write_url,omitempty"`
+	Prefix         string           `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	LabelMapping   string           `yaml:"label_mapping,omitempty" json:"label_mapping,omitempty"`
+	Mappings       []*CarbonMapping `yaml:"mappings,omitempty" json:"mappings,omitempty"`
+	Timeout        time.Duration    `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	BatchSize      int              `yaml:"batch_size,omitempty" json:"batch_size,omitempty"`
+	FlushInterval  time.Duration    `yaml:"flush_interval,omitempty" json:"flush_interval,omitempty"`
+	QueueSize      int              `yaml:"queue_size,omitempty" json:"queue_size,omitempty"`
+	MaxRetries     int              `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	RetryBackoff   time.Duration    `yaml:"retry_backoff,omitempty" json:"retry_backoff,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (opts *carbonListenerOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain carbonListenerOptions
+
+	*opts = DefaultConfig.CarbonListener
+	if err := unmarshal((*plain)(opts)); err != nil {
+		return err
+	}
+	switch opts.LabelMapping {
+	case CarbonLabelMappingName, CarbonLabelMappingPath:
+	default:
+		return fmt.Errorf("unknown carbon listener label mapping %q", opts.LabelMapping)
+	}
+
+	return utils.CheckOverflow(opts.XXX, "carbonListenerOptions")
+}
+
+// CarbonMapping maps the carbon paths matching Match to a metric name and
+// labels, like the mapping rules of the graphite_exporter. Match is a glob
+// where '*' matches a single node, or a regular expression if MatchType is
+// "regex". Name and Labels may refer to the captured groups: $1, ${2}...
+type CarbonMapping struct {
+	Match     string            `yaml:"match" json:"match"`
+	MatchType string            `yaml:"match_type,omitempty" json:"match_type,omitempty"`
+	Name      string            `yaml:"name" json:"name"`
+	Labels    map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Regex is compiled from Match.
+	Regex *regexp.Regexp `yaml:"-" json:"-"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *CarbonMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CarbonMapping
+	if err := unmarshal((*plain)(m)); err != nil {
+		return err
+	}
+	if m.Match == "" || m.Name == "" {
+		return fmt.Errorf("carbon mapping requires a match and a name")
+	}
+
+	expr := m.Match
+	switch m.MatchType {
+	case "", "glob":
+		expr = strings.ReplaceAll(regexp.QuoteMeta(m.Match), `\*`, `([^.]*)`)
+	case "regex":
+	default:
+		return fmt.Errorf("unknown carbon mapping match type %q", m.MatchType)
+	}
+	regex, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return err
+	}
+	m.Regex = regex
+
+	return utils.CheckOverflow(m.XXX, "carbon mapping")
+}
+
+type scrapeOptions struct {
+	// ScrapeInterval and ScrapeTimeout are the defaults of the scrape configs.
+	ScrapeInterval time.Duration   `yaml:"scrape_interval,omitempty" json:"scrape_interval,omitempty"`
+	ScrapeTimeout  time.Duration   `yaml:"scrape_timeout,omitempty" json:"scrape_timeout,omitempty"`
+	ScrapeConfigs  []*ScrapeConfig `yaml:"scrape_configs,omitempty" json:"scrape_configs,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (opts *scrapeOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain scrapeOptions
+
+	*opts = DefaultConfig.Scrape
+	if err := unmarshal((*plain)(opts)); err != nil {
+		return err
+	}
+	if opts.ScrapeInterval <= 0 || opts.ScrapeTimeout <= 0 {
+		return fmt.Errorf("scrape interval %s and timeout %s must be positive", opts.ScrapeInterval, opts.ScrapeTimeout)
+	}
+	if opts.ScrapeTimeout > opts.ScrapeInterval {
+		return fmt.Errorf("scrape timeout %s greater than scrape interval %s", opts.ScrapeTimeout, opts.ScrapeInterval)
+	}
+
+	jobs := make(map[string]struct{}, len(opts.ScrapeConfigs))
+	for _, sc := range opts.ScrapeConfigs {
+		if _, ok := jobs[sc.JobName]; ok {
+			return fmt.Errorf("found multiple scrape configs with job name %q", sc.JobName)
+		}
+		jobs[sc.JobName] = struct{}{}
+
+		if sc.ScrapeInterval == 0 {
+			sc.ScrapeInterval = opts.ScrapeInterval
+		}
+		if sc.ScrapeTimeout == 0 {
+			sc.ScrapeTimeout = min(opts.ScrapeTimeout, sc.ScrapeInterval)
+		}
+		if sc.ScrapeInterval <= 0 || sc.ScrapeTimeout <= 0 {
+			return fmt.Errorf("scrape interval %s and timeout %s must be positive for job %q",
+				sc.ScrapeInterval, sc.ScrapeTimeout, sc.JobName)
+		}
+		if sc.ScrapeTimeout > sc.ScrapeInterval {
+			return fmt.Errorf("scrape timeout %s greater than scrape interval %s for job %q",
+				sc.ScrapeTimeout, sc.ScrapeInterval, sc.JobName)
+		}
+	}
+
+	return utils.CheckOverflow(opts.XXX, "scrapeOptions")
+}
+
+// ScrapeConfig configures the scraping of a job: the targets whose
+// Prometheus or OpenMetrics exposition is scraped, and how.
+type ScrapeConfig struct {
+	JobName        string        `yaml:"job_name" json:"job_name"`
+	ScrapeInterval time.Duration `yaml:"scrape_interval,omitempty" json:"scrape_interval,omitempty"`
+	ScrapeTimeout  time.Duration `yaml:"scrape_timeout,omitempty" json:"scrape_timeout,omitempty"`
+	MetricsPath    string        `yaml:"metrics_path,omitempty" json:"metrics_path,omitempty"`
+	Scheme         string        `yaml:"scheme,omitempty" json:"scheme,omitempty"`
+	// HonorLabels keeps the scraped labels conflicting with the target
+	// labels, instead of renaming them to exported_<name>.
+	HonorLabels   bool            `yaml:"honor_labels,omitempty" json:"honor_labels,omitempty"`
+	StaticConfigs []*TargetGroup  `yaml:"static_configs,omitempty" json:"static_configs,omitempty"`
+	FileSDConfigs []*FileSDConfig `yaml:"file_sd_configs,omitempty" json:"file_sd_configs,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ScrapeConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ScrapeConfig
+
+	*c = ScrapeConfig{MetricsPath: "/metrics", Scheme: "http"}
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.JobName == "" {
+		return fmt.Errorf("scrape config requires a job name")
+	}
+	if c.Scheme != "http" && c.Scheme != "https" {
+		return fmt.Errorf("unknown scrape scheme %q for job %q", c.Scheme, c.JobName)
+	}
+
+	return utils.CheckOverflow(c.XXX, "scrape config")
+}
+
+// TargetGroup is a group of targets sharing labels, in the format of the
+// Prometheus static configs and file service discovery.
+type TargetGroup struct {
+	Targets []string          `yaml:"targets" json:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (g *TargetGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TargetGroup
+	if err := unmarshal((*plain)(g)); err != nil {
+		return err
+	}
+
+	return utils.CheckOverflow(g.XXX, "target group")
+}
+
+// FileSDConfig reads target groups from JSON or YAML files, re-read every
+// RefreshInterval. The last path element of Files may be a glob.
+type FileSDConfig struct {
+	Files           []string      `yaml:"files" json:"files"`
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty" json:"refresh_interval,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *FileSDConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain FileSDConfig
+
+	*c = FileSDConfig{RefreshInterval: 5 * time.Minute}
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Files) == 0 {
+		return fmt.Errorf("file service discovery config requires files")
+	}
+	if c.RefreshInterval <= 0 {
+		return fmt.Errorf("file service discovery refresh interval %s must be positive", c.RefreshInterval)
+	}
+
+	return utils.CheckOverflow(c.XXX, "file_sd_config")
+}
+
+type federationOptions struct {
+	// Interval and Timeout are the defaults of the sources.
+	Interval time.Duration       `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout  time.Duration       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Sources  []*FederationSource `yaml:"sources,omitempty" json:"sources,omitempty"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (opts *federationOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain federationOptions
+
+	*opts = DefaultConfig.Federation
+	if err := unmarshal((*plain)(opts)); err != nil {
+		return err
+	}
+	if opts.Interval <= 0 || opts.Timeout <= 0 {
+		return fmt.Errorf("federation interval %s and timeout %s must be positive", opts.Interval, opts.Timeout)
+	}
+
+	names := make(map[string]struct{}, len(opts.Sources))
+	for _, src := range opts.Sources {
+		if _, ok := names[src.Name]; ok {
+			return fmt.Errorf("found multiple federation sources named %q", src.Name)
+		}
+		names[src.Name] = struct{}{}
+
+		if src.Interval == 0 {
+			src.Interval = opts.Interval
+		}
+		if src.Timeout == 0 {
+			src.Timeout = min(opts.Timeout, src.Interval)
+		}
+		if src.Interval <= 0 || src.Timeout <= 0 {
+			return fmt.Errorf("federation interval %s and timeout %s must be positive for source %q",
+				src.Interval, src.Timeout, src.Name)
+		}
+	}
+
+	return utils.CheckOverflow(opts.XXX, "federationOptions")
+}
+
+// FederationSource is a Prometheus server whose /federate endpoint is pulled
+// with the Match selectors.
+type FederationSource struct {
+	// Name identifies the source in the logs and metrics. It defaults to
+	// the host of URL.
+	Name     string        `yaml:"name,omitempty" json:"name,omitempty"`
+	URL      string        `yaml:"url" json:"url"`
+	Match    []string      `yaml:"match" json:"match"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// HonorLabels keeps the job and instance labels of the federated series.
+	// Otherwise they are renamed to exported_job and exported_instance, and
+	// replaced by the name and the host of the source.
+	HonorLabels bool `yaml:"honor_labels" json:"honor_labels"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *FederationSource) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain FederationSource
+
+	*s = FederationSource{HonorLabels: true}
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid federation source URL %q", s.URL)
+	}
+	if len(s.Match) == 0 {
+		return fmt.Errorf("federation source %q requires match selectors", s.URL)
+	}
+	if s.Name == "" {
+		s.Name = u.Host
+	}
+
+	return utils.CheckOverflow(s.XXX, "federation source")
+}
+
+// CheckClient checks the type and the settings of an entry of the clients
+// list when it loads. It is set by the client package, where the types are
+// registered.
+var CheckClient func(c *ClientConfig) error
+
+// ClientConfig is an entry of the clients list: a client of a registered
+// type, whose Settings are decoded by that type.
+type ClientConfig struct {
+	// Name identifies the client in the logs, the metrics and the status page.
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+	// Selectors restrict the written samples to the ones matching any of
+	// them, e.g. '{job="node"}'. All the samples are written if empty.
+	Selectors []string  `yaml:"selectors,omitempty" json:"selectors,omitempty"`
+	Settings  yaml.Node `yaml:"settings,omitempty" json:"-"`
+
+	// Matchers are parsed from Selectors.
+	Matchers [][]*labels.Matcher `yaml:"-" json:"-"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ClientConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ClientConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" || c.Type == "" {
+		return fmt.Errorf("client requires a name and a type")
+	}
+	for _, selector := range c.Selectors {
+		matchers, err := parser.ParseMetricSelector(selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q of client %q: %w", selector, c.Name, err)
+		}
+		c.Matchers = append(c.Matchers, matchers)
+	}
+	if c.Settings.Kind == 0 {
+		// Types apply their defaults to empty settings.
+		c.Settings = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	if CheckClient != nil {
+		if err := CheckClient(c); err != nil {
+			return err
+		}
+	}
+
+	return utils.CheckOverflow(c.XXX, "client")
+}
@@ -25,6 +25,8 @@ import (
 
 	graphite "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
 	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var expectedConf = &Config{
@@ -34,13 +36,82 @@ var expectedConf = &Config{
 		TelemetryPath: "/coolMetrics",
 	},
 	Read: readOptions{
-		Timeout:     18 * time.Minute,
-		Delay:       42 * time.Minute,
-		IgnoreError: true,
+		Timeout:       18 * time.Minute,
+		Delay:         42 * time.Minute,
+		IgnoreError:   true,
+		MaxSamples:    1000000,
+		LookbackDelta: 5 * time.Minute,
 	},
 	Write: writeOptions{
 		Timeout: 18 * time.Minute,
 	},
+	GraphiteAPI: graphiteAPIOptions{
+		RemoteReadURL: "http://prometheus:9090/api/v1/read",
+		Timeout:       1 * time.Minute,
+		Step:          30 * time.Second,
+	},
+	OTLP: otlpOptions{
+		AddMetricSuffixes:         false,
+		PromoteResourceAttributes: []string{"k8s.namespace.name"},
+	},
+	CarbonListener: carbonListenerOptions{
+		ListenAddress:  ":2003",
+		RemoteWriteURL: "http://prometheus:9090/api/v1/write",
+		LabelMapping:   CarbonLabelMappingPath,
+		Timeout:        30 * time.Second,
+		BatchSize:      1000,
+		FlushInterval:  5 * time.Second,
+		QueueSize:      100000,
+		MaxRetries:     5,
+		RetryBackoff:   1 * time.Second,
+	},
+	Scrape: scrapeOptions{
+		ScrapeInterval: 30 * time.Second,
+		ScrapeTimeout:  10 * time.Second,
+		ScrapeConfigs: []*ScrapeConfig{
+			{
+				JobName:        "node",
+				ScrapeInterval: 30 * time.Second,
+				ScrapeTimeout:  10 * time.Second,
+				MetricsPath:    "/metrics",
+				Scheme:         "http",
+				StaticConfigs: []*TargetGroup{
+					{Targets: []string{"node:9100"}, Labels: map[string]string{"env": "prod"}},
+				},
+			},
+			{
+				JobName:        "app",
+				ScrapeInterval: 5 * time.Second,
+				ScrapeTimeout:  5 * time.Second,
+				MetricsPath:    "/internal/metrics",
+				Scheme:         "http",
+				FileSDConfigs: []*FileSDConfig{
+					{Files: []string{"/etc/targets/*.json"}, RefreshInterval: 5 * time.Minute},
+				},
+			},
+		},
+	},
+	Federation: federationOptions{
+		Interval: 30 * time.Second,
+		Timeout:  30 * time.Second,
+		Sources: []*FederationSource{
+			{
+				Name:        "prometheus-a:9090",
+				URL:         "http://prometheus-a:9090/federate",
+				Match:       []string{`{job="node"}`},
+				Interval:    30 * time.Second,
+				Timeout:     30 * time.Second,
+				HonorLabels: true,
+			},
+			{
+				Name:     "b",
+				URL:      "http://prometheus-b:9090/federate",
+				Match:    []string{`{__name__=~"up|node_load1"}`},
+				Interval: 15 * time.Second,
+				Timeout:  15 * time.Second,
+			},
+		},
+	},
 	Graphite: graphite.DefaultConfig,
 	original: "",
 }
@@ -57,3 +128,112 @@ func TestLoadConfigFile(t *testing.T) {
 			"testdata/conf.good.yml", c.String(), expectedConf.String())
 	}
 }
+
+func TestParseBackfillRange(t *testing.T) {
+	opts := backfillOptions{RemoteReadURL: "http://prometheus:9090/api/v1/read", Window: time.Hour, BatchSize: 1}
+	if err := parseBackfillRange(&opts, "2023-11-14T22:13:20Z", "1700003600.5"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Start.UnixMilli() != 1700000000000 || opts.End.UnixMilli() != 1700003600500 {
+		t.Fatalf("unexpected range %s to %s", opts.Start, opts.End)
+	}
+
+	if err := parseBackfillRange(&opts, "1700003600", "1700000000"); err == nil {
+		t.Fatal("expected an error for an end before the start")
+	}
+	opts.TSDBPath = "data/"
+	if err := parseBackfillRange(&opts, "1700000000", ""); err == nil {
+		t.Fatal("expected an error for both a remote read URL and a TSDB path")
+	}
+}
+
+func TestParseExportRange(t *testing.T) {
+	opts := exportOptions{Window: 2 * time.Hour}
+	if err := parseExportRange(&opts, "1700000000", "2023-11-14T23:13:20Z"); err != nil {
+		t.Fatal(err)
+	}
+	if opts.End.Sub(opts.Start) != time.Hour {
+		t.Fatalf("unexpected range %s to %s", opts.Start, opts.End)
+	}
+
+	opts.Window = 0
+	if err := parseExportRange(&opts, "1700000000", ""); err == nil {
+		t.Fatal("expected an error for an empty window")
+	}
+}
+
+func TestLoadClients(t *testing.T) {
+	c, err := Load(`
+clients:
+  - name: eu
+    type: graphite
+    selectors: ['{job="node"}', '{__name__=~"up|scrape_.*"}']
+    settings:
+      default_prefix: eu.
+  - name: us
+    type: graphite
+`)
+	require.NoError(t, err)
+	require.Len(t, c.Clients, 2)
+
+	eu := c.Clients[0]
+	assert.Equal(t, "graphite", eu.Type)
+	require.Len(t, eu.Matchers, 2)
+	assert.Equal(t, `job="node"`, eu.Matchers[0][0].String())
+	var settings struct {
+		DefaultPrefix string `yaml:"default_prefix"`
+	}
+	require.NoError(t, eu.Settings.Decode(&settings))
+	assert.Equal(t, "eu.", settings.DefaultPrefix)
+
+	// Missing settings are decoded like empty ones.
+	settings.DefaultPrefix = ""
+	require.NoError(t, c.Clients[1].Settings.Decode(&settings))
+	assert.Empty(t, settings.DefaultPrefix)
+
+	for _, tc := range []struct {
+		name string
+		yml  string
+	}{
+		{"duplicate name", "clients: [{name: a, type: graphite}, {name: a, type: whisper}]"},
+		{"reserved name", "clients: [{name: whisper, type: whisper}]"},
+		{"missing type", "clients: [{name: a}]"},
+		{"invalid selector", "clients: [{name: a, type: graphite, selectors: ['{job=']}]"},
+		{"unknown field", "clients: [{name: a, type: graphite, prefix: a.}]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.yml)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadNonPositiveDurations(t *testing.T) {
+	_, err := Load(`
+scrape:
+  scrape_configs:
+  - job_name: node
+    file_sd_configs: [{files: [targets.yml]}]
+federation:
+  sources: [{url: 'http://prometheus:9090', match: ['{job="node"}']}]
+`)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		yml  string
+	}{
+		{"scrape interval", "scrape: {scrape_interval: 0s}"},
+		{"scrape timeout", "scrape: {scrape_timeout: -1s}"},
+		{"job scrape interval", "scrape: {scrape_configs: [{job_name: node, scrape_interval: -1m}]}"},
+		{"job scrape timeout", "scrape: {scrape_configs: [{job_name: node, scrape_timeout: -1s}]}"},
+		{"refresh interval", "scrape: {scrape_configs: [{job_name: node, file_sd_configs: [{files: [targets.yml], refresh_interval: 0s}]}]}"},
+		{"federation interval", "federation: {interval: 0s}"},
+		{"source timeout", "federation: {sources: [{url: 'http://prometheus:9090', match: ['{job=\"node\"}'], timeout: -1s}]}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.yml)
+			assert.Error(t, err)
+		})
+	}
+}
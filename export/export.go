@@ -0,0 +1,193 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package export dumps the series of Graphite, read back through the read
+// path of the adapter, as OpenMetrics text accepted by promtool tsdb
+// create-blocks-from openmetrics, or directly as TSDB blocks.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+	promremote "github.com/prometheus/prometheus/storage/remote"
+)
+
+// Reader renders the Graphite series of remote read queries.
+type Reader interface {
+	Targets(ctx context.Context, query *prompb.Query, graphitePrefix string) ([]string, error)
+	TargetToTimeseries(ctx context.Context, target string, from string, until string, graphitePrefix string) ([]*prompb.TimeSeries, error)
+}
+
+// Run runs the export command: it reads the series matching the selectors
+// of the config from Graphite, and prints them to out as OpenMetrics text,
+// or writes them as TSDB blocks.
+func Run(ctx context.Context, cfg *config.Config, out io.Writer, logger *slog.Logger) error {
+	opts := cfg.Export
+	if cfg.Graphite.Read.URL == "" {
+		return errors.New("export requires a Graphite read URL")
+	}
+	c := graphite.NewClient(cfg, logger)
+	defer c.Shutdown()
+
+	var w seriesWriter
+	if opts.TSDBPath != "" {
+		if err := os.MkdirAll(opts.TSDBPath, 0o755); err != nil {
+			return err
+		}
+		w = newBlockWriter(opts.TSDBPath, opts.Window, logger)
+	} else {
+		w = newOpenMetricsWriter(out)
+	}
+
+	e, err := newExporter(cfg, c, w, logger)
+	if err != nil {
+		return err
+	}
+	return e.run(ctx)
+}
+
+// exporter reads the series window by window, and writes each of them as
+// soon as it's rendered.
+type exporter struct {
+	logger *slog.Logger
+	reader Reader
+	writer seriesWriter
+
+	matchers [][]*prompb.LabelMatcher
+	prefix   string
+	start    time.Time
+	end      time.Time
+	window   time.Duration
+}
+
+func newExporter(cfg *config.Config, r Reader, w seriesWriter, logger *slog.Logger) (*exporter, error) {
+	opts := cfg.Export
+	e := &exporter{
+		logger: logger,
+		reader: r,
+		writer: w,
+		prefix: opts.Prefix,
+		start:  opts.Start,
+		end:    opts.End,
+		window: opts.Window,
+	}
+	if e.prefix == "" {
+		e.prefix = cfg.Graphite.DefaultPrefix
+	}
+	for _, m := range opts.Match {
+		matchers, err := parser.ParseMetricSelector(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid series selector %q: %w", m, err)
+		}
+		query, err := promremote.ToQuery(opts.Start.UnixMilli(), opts.End.UnixMilli(), matchers, nil)
+		if err != nil {
+			return nil, err
+		}
+		e.matchers = append(e.matchers, query.Matchers)
+	}
+	return e, nil
+}
+
+func (e *exporter) run(ctx context.Context) error {
+	targets, err := e.targets(ctx)
+	if err != nil {
+		return err
+	}
+	e.logger.Info("Exporting series", "num_targets", len(targets), "start", e.start, "end", e.end)
+
+	for ws := e.start; ws.Before(e.end); ws = ws.Add(e.window) {
+		we := ws.Add(e.window)
+		if we.After(e.end) {
+			we = e.end
+		}
+		n, err := e.exportWindow(ctx, targets, ws, we)
+		if err != nil {
+			return fmt.Errorf("error exporting %s to %s: %w", ws, we, err)
+		}
+		e.logger.Info("Exported window", "start", ws, "end", we, "num_samples", n)
+	}
+	return e.writer.close()
+}
+
+// targets returns the Graphite targets of the selectors, without duplicates.
+func (e *exporter) targets(ctx context.Context) ([]string, error) {
+	var targets []string
+	seen := make(map[string]struct{})
+	for _, matchers := range e.matchers {
+		query := &prompb.Query{
+			StartTimestampMs: e.start.UnixMilli(),
+			EndTimestampMs:   e.end.UnixMilli(),
+			Matchers:         matchers,
+		}
+		t, err := e.reader.Targets(ctx, query, e.prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range t {
+			if _, ok := seen[target]; ok {
+				continue
+			}
+			seen[target] = struct{}{}
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// exportWindow writes the samples between ws included and we excluded, and
+// returns their number.
+func (e *exporter) exportWindow(ctx context.Context, targets []string, ws, we time.Time) (int, error) {
+	from := strconv.FormatInt(ws.Unix(), 10)
+	until := strconv.FormatInt(we.Unix(), 10)
+	mint, maxt := ws.UnixMilli(), we.UnixMilli()
+
+	n := 0
+	for _, target := range targets {
+		series, err := e.reader.TargetToTimeseries(ctx, target, from, until, e.prefix)
+		if err != nil {
+			return 0, err
+		}
+		for _, ts := range series {
+			// Graphite renders the points of whole steps, which may be
+			// outside of the window.
+			var samples []prompb.Sample
+			for _, s := range ts.Samples {
+				if s.Timestamp >= mint && s.Timestamp < maxt {
+					samples = append(samples, s)
+				}
+			}
+			if len(samples) == 0 {
+				continue
+			}
+			if err := e.writer.write(ctx, ts.Labels, samples); err != nil {
+				return 0, err
+			}
+			n += len(samples)
+		}
+	}
+	return n, e.writer.flush(ctx)
+}
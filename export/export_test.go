@@ -0,0 +1,136 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStart = time.Unix(1700000000, 0)
+
+// fakeReader renders a point of each target every minute, from one minute
+// before the requested range, like Graphite rounding it to its steps.
+type fakeReader struct {
+	queries []string
+	renders [][2]string
+}
+
+func (r *fakeReader) Targets(_ context.Context, query *prompb.Query, prefix string) ([]string, error) {
+	name := query.Matchers[0].Value
+	r.queries = append(r.queries, prefix+name)
+	return []string{prefix + name + ".job.node", prefix + "up.job.node"}, nil
+}
+
+func (r *fakeReader) TargetToTimeseries(_ context.Context, target, from, until, _ string) ([]*prompb.TimeSeries, error) {
+	r.renders = append(r.renders, [2]string{from, until})
+	f, _ := strconv.ParseInt(from, 10, 64)
+	u, _ := strconv.ParseInt(until, 10, 64)
+	ts := &prompb.TimeSeries{Labels: []prompb.Label{
+		{Name: "__name__", Value: target[len("prom.") : len(target)-len(".job.node")]},
+		{Name: "job", Value: `node "1"`},
+	}}
+	for t := f - 60; t <= u; t += 60 {
+		ts.Samples = append(ts.Samples, prompb.Sample{Value: 0.5, Timestamp: t * 1000})
+	}
+	return []*prompb.TimeSeries{ts}, nil
+}
+
+func testConfig() *config.Config {
+	cfg := config.DefaultConfig
+	cfg.Graphite.DefaultPrefix = "prom."
+	cfg.Export.Start = testStart
+	cfg.Export.End = testStart.Add(3 * time.Minute)
+	cfg.Export.Match = []string{"up", `{__name__="load"}`}
+	cfg.Export.Window = 2 * time.Minute
+	return &cfg
+}
+
+func TestExportOpenMetrics(t *testing.T) {
+	cfg := testConfig()
+	r := &fakeReader{}
+	var out bytes.Buffer
+
+	e, err := newExporter(cfg, r, newOpenMetricsWriter(&out), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, e.run(context.Background()))
+
+	assert.Equal(t, []string{"prom.up", "prom.load"}, r.queries)
+	// The duplicated target is rendered once per window.
+	assert.Equal(t, [][2]string{
+		{"1700000000", "1700000120"}, {"1700000000", "1700000120"},
+		{"1700000120", "1700000180"}, {"1700000120", "1700000180"},
+	}, r.renders)
+	assert.Equal(t, `up{job="node \"1\""} 0.5 1700000000
+up{job="node \"1\""} 0.5 1700000060
+load{job="node \"1\""} 0.5 1700000000
+load{job="node \"1\""} 0.5 1700000060
+up{job="node \"1\""} 0.5 1700000120
+load{job="node \"1\""} 0.5 1700000120
+# EOF
+`, out.String())
+}
+
+func TestExportTSDB(t *testing.T) {
+	cfg := testConfig()
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	e, err := newExporter(cfg, &fakeReader{}, newBlockWriter(dir, cfg.Export.Window, logger), logger)
+	require.NoError(t, err)
+	require.NoError(t, e.run(context.Background()))
+
+	db, err := tsdb.Open(dir, nil, nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.Len(t, db.Blocks(), 2)
+
+	q, err := db.Querier(context.Background(), 0, testStart.Add(time.Hour).UnixMilli())
+	require.NoError(t, err)
+	defer func() { _ = q.Close() }()
+	set := q.Select(true, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", "load"))
+	require.True(t, set.Next())
+	assert.Equal(t, `{__name__="load", job="node \"1\""}`, set.At().Labels().String())
+	var timestamps []int64
+	it := set.At().Iterator()
+	for it.Next() {
+		ts, v := it.At()
+		assert.Equal(t, 0.5, v)
+		timestamps = append(timestamps, ts)
+	}
+	assert.Equal(t, []int64{1700000000000, 1700000060000, 1700000120000}, timestamps)
+	assert.False(t, set.Next())
+	require.NoError(t, set.Err())
+}
+
+func TestExportInvalidSelector(t *testing.T) {
+	cfg := testConfig()
+	cfg.Export.Match = []string{"{"}
+	_, err := newExporter(cfg, &fakeReader{}, newOpenMetricsWriter(io.Discard), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package export
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+)
+
+// seriesWriter writes the exported series.
+type seriesWriter interface {
+	// write writes the samples of a series, in time order.
+	write(ctx context.Context, ls []prompb.Label, samples []prompb.Sample) error
+	// flush ends a time window.
+	flush(ctx context.Context) error
+	// close ends the export.
+	close() error
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// openMetricsWriter prints the series as OpenMetrics text, without metadata.
+type openMetricsWriter struct {
+	w *bufio.Writer
+}
+
+func newOpenMetricsWriter(out io.Writer) *openMetricsWriter {
+	return &openMetricsWriter{w: bufio.NewWriter(out)}
+}
+
+func (w *openMetricsWriter) write(_ context.Context, ls []prompb.Label, samples []prompb.Sample) error {
+	var name string
+	var b strings.Builder
+	for _, l := range ls {
+		if l.Name == model.MetricNameLabel {
+			name = l.Value
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		labelValueEscaper.WriteString(&b, l.Value)
+		b.WriteByte('"')
+	}
+	series := name
+	if b.Len() > 0 {
+		series += "{" + b.String() + "}"
+	}
+
+	for _, s := range samples {
+		// OpenMetrics timestamps are in seconds.
+		line := series + " " + strconv.FormatFloat(s.Value, 'g', -1, 64) + " " +
+			strconv.FormatFloat(float64(s.Timestamp)/1000, 'f', -1, 64) + "\n"
+		if _, err := w.w.WriteString(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *openMetricsWriter) flush(context.Context) error {
+	return w.w.Flush()
+}
+
+func (w *openMetricsWriter) close() error {
+	if _, err := w.w.WriteString("# EOF\n"); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// blockWriter writes a TSDB block for each time window with samples.
+type blockWriter struct {
+	logger    *slog.Logger
+	dir       string
+	blockSize int64
+
+	w   *tsdb.BlockWriter
+	app storage.Appender
+}
+
+func newBlockWriter(dir string, window time.Duration, logger *slog.Logger) *blockWriter {
+	return &blockWriter{logger: logger, dir: dir, blockSize: window.Milliseconds()}
+}
+
+func (w *blockWriter) write(ctx context.Context, ls []prompb.Label, samples []prompb.Sample) error {
+	if w.w == nil {
+		bw, err := tsdb.NewBlockWriter(log.NewNopLogger(), w.dir, w.blockSize)
+		if err != nil {
+			return err
+		}
+		w.w = bw
+		w.app = bw.Appender(ctx)
+	}
+
+	lset := make(labels.Labels, 0, len(ls))
+	for _, l := range ls {
+		lset = append(lset, labels.Label{Name: l.Name, Value: l.Value})
+	}
+	lset = labels.New(lset...)
+
+	var ref uint64
+	for _, s := range samples {
+		var err error
+		if ref, err = w.app.Append(ref, lset, s.Timestamp, s.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *blockWriter) flush(ctx context.Context) error {
+	if w.w == nil {
+		return nil
+	}
+	defer func() {
+		_ = w.w.Close()
+		w.w, w.app = nil, nil
+	}()
+
+	if err := w.app.Commit(); err != nil {
+		return err
+	}
+	id, err := w.w.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	w.logger.Info("Wrote TSDB block", "dir", w.dir, "ulid", id)
+	return nil
+}
+
+func (w *blockWriter) close() error {
+	return w.flush(context.Background())
+}
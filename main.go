@@ -18,6 +18,7 @@
 package main
 
 import (
+	"context"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
@@ -26,7 +27,17 @@ import (
 	"log/slog"
 
 	"dario.cat/mergo"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/backfill"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/carbon"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/checkconfig"
+	// Registers the remote_write type of the clients list.
+	_ "github.com/Netcracker/qubership-graphite-remote-adapter/client/forward"
+	// Registers the sink type of the clients list.
+	_ "github.com/Netcracker/qubership-graphite-remote-adapter/client/sink"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/export"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/scrape"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/testrules"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/web"
 	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/version"
@@ -79,6 +90,15 @@ func main() {
 		return
 	}
 
+	// The config files are checked, not loaded.
+	if cliCfg.Command == config.CommandCheckConfig {
+		if err = checkconfig.Run(cliCfg, os.Stdout, logger.With("component", "checkconfig")); err != nil {
+			logger.Error("Error checking config", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load the config once.
 	cfg, err := reload(cliCfg, logger)
 	if err != nil {
@@ -86,12 +106,61 @@ func main() {
 		return
 	}
 
+	if cfg.Command == config.CommandBackfill {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err = backfill.Run(ctx, cfg, os.Stdout, logger.With("component", "backfill")); err != nil {
+			logger.Error("Error backfilling", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.Command == config.CommandExport {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err = export.Run(ctx, cfg, os.Stdout, logger.With("component", "export")); err != nil {
+			logger.Error("Error exporting", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.Command == config.CommandTestRules {
+		if err = testrules.Run(cfg, os.Stdout, logger.With("component", "testrules")); err != nil {
+			logger.Error("Error testing rules", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	webHandler := web.New(logger.With("component", "web"), cfg)
 	if err = webHandler.ApplyConfig(cfg); err != nil {
 		logger.Error("Error applying webHandler config", "err", err)
 		return
 	}
 
+	carbonListener := carbon.New(logger.With("component", "carbon"))
+	if err = carbonListener.ApplyConfig(cfg); err != nil {
+		logger.Error("Error applying carbon listener config", "err", err)
+		return
+	}
+	defer carbonListener.Stop()
+
+	scrapeManager := scrape.New(logger.With("component", "scrape"), webHandler)
+	if err = scrapeManager.ApplyConfig(cfg); err != nil {
+		logger.Error("Error applying scrape manager config", "err", err)
+		return
+	}
+	defer scrapeManager.Stop()
+
+	federation := scrape.NewFederation(logger.With("component", "federation"), webHandler)
+	if err = federation.ApplyConfig(cfg); err != nil {
+		logger.Error("Error applying federation config", "err", err)
+		return
+	}
+	defer federation.Stop()
+
 	// Tooling to dynamically reload the config for each clients.
 	hup := make(chan os.Signal, 1)
 	signal.Notify(hup, syscall.SIGHUP)
@@ -108,6 +177,18 @@ func main() {
 					logger.Error("Error applying webHandler config", "err", err)
 					continue
 				}
+				if err = carbonListener.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying carbon listener config", "err", err)
+					continue
+				}
+				if err = scrapeManager.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying scrape manager config", "err", err)
+					continue
+				}
+				if err = federation.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying federation config", "err", err)
+					continue
+				}
 				logger.Info("Reloaded config file")
 			case rc := <-webHandler.Reload():
 				cfg, err = reload(cliCfg, logger)
@@ -117,6 +198,15 @@ func main() {
 				} else if err = webHandler.ApplyConfig(cfg); err != nil {
 					logger.Error("Error applying webHandler config", "err", err)
 					rc <- err
+				} else if err = carbonListener.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying carbon listener config", "err", err)
+					rc <- err
+				} else if err = scrapeManager.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying scrape manager config", "err", err)
+					rc <- err
+				} else if err = federation.ApplyConfig(cfg); err != nil {
+					logger.Error("Error applying federation config", "err", err)
+					rc <- err
 				} else {
 					logger.Info("Reloaded config file")
 					rc <- nil
@@ -0,0 +1,188 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+const federationSubsystem = "federation"
+
+var (
+	federationPulls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: federationSubsystem,
+			Name:      "pulls_total",
+			Help:      "Total number of pulls of the federation sources.",
+		},
+		[]string{"source"},
+	)
+	federationFailedPulls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: federationSubsystem,
+			Name:      "failed_pulls_total",
+			Help:      "Total number of pulls of the federation sources which failed.",
+		},
+		[]string{"source"},
+	)
+	federationPulledSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: federationSubsystem,
+			Name:      "pulled_samples_total",
+			Help:      "Total number of samples pulled from the federation sources.",
+		},
+		[]string{"source"},
+	)
+	federationFailedSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: federationSubsystem,
+			Name:      "failed_samples_total",
+			Help:      "Total number of pulled samples which failed to be appended.",
+		},
+		[]string{"source"},
+	)
+)
+
+// Federation periodically pulls the /federate endpoint of Prometheus servers
+// and appends the pulled samples.
+type Federation struct {
+	logger   *slog.Logger
+	appender Appender
+	client   *http.Client
+
+	lock    sync.Mutex
+	cancel  context.CancelFunc
+	sources sync.WaitGroup
+}
+
+// NewFederation returns a new Federation, started by ApplyConfig.
+func NewFederation(logger *slog.Logger, appender Appender) *Federation {
+	return &Federation{logger: logger, appender: appender, client: &http.Client{}}
+}
+
+// ApplyConfig stops the running pulls, if any, and starts pulling the
+// federation sources.
+func (f *Federation) ApplyConfig(cfg *config.Config) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.stop()
+	if len(cfg.Federation.Sources) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	for _, src := range cfg.Federation.Sources {
+		u, err := url.Parse(src.URL)
+		if err != nil {
+			f.stop()
+			return err
+		}
+		q := u.Query()
+		for _, m := range src.Match {
+			q.Add("match[]", m)
+		}
+		u.RawQuery = q.Encode()
+		t := &target{
+			url: u.String(),
+			labels: model.LabelSet{
+				model.JobLabel:      model.LabelValue(src.Name),
+				model.InstanceLabel: model.LabelValue(u.Host),
+			},
+		}
+
+		f.sources.Add(1)
+		go func(src *config.FederationSource) {
+			defer f.sources.Done()
+			f.run(ctx, src, t, f.logger.With("source", src.Name))
+		}(src)
+	}
+	return nil
+}
+
+// Stop stops the running pulls.
+func (f *Federation) Stop() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.stop()
+}
+
+func (f *Federation) stop() {
+	if f.cancel != nil {
+		f.cancel()
+		f.sources.Wait()
+		f.cancel = nil
+	}
+}
+
+// run pulls the source every interval, until ctx is done.
+func (f *Federation) run(ctx context.Context, src *config.FederationSource, t *target, logger *slog.Logger) {
+	ticker := time.NewTicker(src.Interval)
+	defer ticker.Stop()
+	for {
+		f.pull(ctx, src, t, logger)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pull pulls the source and appends the pulled samples. They keep the
+// timestamps given by the source.
+func (f *Federation) pull(ctx context.Context, src *config.FederationSource, t *target, logger *slog.Logger) {
+	federationPulls.WithLabelValues(src.Name).Inc()
+	body, contentType, err := fetch(ctx, f.client, t.url, src.Timeout)
+	var samples model.Samples
+	if err == nil {
+		samples, err = parse(body, contentType, t.labels, src.HonorLabels, model.Now())
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("Error pulling federation source", "err", err)
+		federationFailedPulls.WithLabelValues(src.Name).Inc()
+		return
+	}
+	federationPulledSamples.WithLabelValues(src.Name).Add(float64(len(samples)))
+	if len(samples) == 0 {
+		return
+	}
+
+	if err := f.appender.Append(ctx, samples); err != nil {
+		logger.Warn("Error appending pulled samples", "num_samples", len(samples), "err", err)
+		federationFailedSamples.WithLabelValues(src.Name).Add(float64(len(samples)))
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederationPullsSources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/federate", r.URL.Path)
+		assert.Equal(t, []string{`{job="node"}`, "up"}, r.URL.Query()["match[]"])
+		_, _ = w.Write([]byte(`up{job="node",instance="node-1:9100"} 1 1700000000000` + "\n"))
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	cfg := config.DefaultConfig
+	cfg.Federation.Sources = []*config.FederationSource{
+		{
+			Name:        "honored",
+			URL:         srv.URL + "/federate",
+			Match:       []string{`{job="node"}`, "up"},
+			Interval:    20 * time.Millisecond,
+			Timeout:     20 * time.Millisecond,
+			HonorLabels: true,
+		},
+		{
+			Name:     "renamed",
+			URL:      srv.URL + "/federate",
+			Match:    []string{`{job="node"}`, "up"},
+			Interval: 20 * time.Millisecond,
+			Timeout:  20 * time.Millisecond,
+		},
+		{
+			Name:     "down",
+			URL:      "http://127.0.0.1:1/federate",
+			Match:    []string{"up"},
+			Interval: 20 * time.Millisecond,
+			Timeout:  20 * time.Millisecond,
+		},
+	}
+
+	appender := &fakeAppender{}
+	f := NewFederation(slog.New(slog.NewTextHandler(io.Discard, nil)), appender)
+	require.NoError(t, f.ApplyConfig(&cfg))
+	defer f.Stop()
+
+	require.Eventually(t, func() bool {
+		values := appender.appended()
+		_, honored := values["up/node-1:9100"]
+		_, renamed := values["up/"+host]
+		return honored && renamed && testutil.ToFloat64(federationFailedPulls.WithLabelValues("down")) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	appender.lock.Lock()
+	for _, s := range appender.samples {
+		assert.Equal(t, int64(1700000000000), int64(s.Timestamp))
+		if s.Metric["instance"] == "node-1:9100" {
+			assert.Equal(t, "node", string(s.Metric["job"]))
+		} else {
+			assert.Equal(t, "renamed", string(s.Metric["job"]))
+			assert.Equal(t, "node", string(s.Metric["exported_job"]))
+			assert.Equal(t, "node-1:9100", string(s.Metric["exported_instance"]))
+		}
+	}
+	appender.lock.Unlock()
+}
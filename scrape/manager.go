@@ -0,0 +1,263 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package scrape scrapes the Prometheus and OpenMetrics exposition of
+// static and file discovered targets, or the /federate endpoint of
+// Prometheus servers, and appends the scraped samples to the write pipeline
+// of the adapter.
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	namespace = "remote_adapter"
+	subsystem = "scrape"
+)
+
+var (
+	targets = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "targets",
+			Help:      "Number of scraped targets.",
+		},
+		[]string{"job"},
+	)
+	scrapes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "scrapes_total",
+			Help:      "Total number of scrapes.",
+		},
+		[]string{"job"},
+	)
+	failedScrapes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "failed_scrapes_total",
+			Help:      "Total number of scrapes which failed.",
+		},
+		[]string{"job"},
+	)
+	failedSamples = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "failed_samples_total",
+			Help:      "Total number of scraped samples which failed to be appended.",
+		},
+		[]string{"job"},
+	)
+)
+
+// Appender writes samples to the remote storages.
+type Appender interface {
+	Append(ctx context.Context, samples model.Samples) error
+}
+
+// Manager scrapes the targets of the scrape configs.
+type Manager struct {
+	logger   *slog.Logger
+	appender Appender
+	client   *http.Client
+
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	jobs   sync.WaitGroup
+}
+
+// New returns a new Manager, started by ApplyConfig.
+func New(logger *slog.Logger, appender Appender) *Manager {
+	return &Manager{logger: logger, appender: appender, client: &http.Client{}}
+}
+
+// ApplyConfig stops the running scrapes, if any, and starts scraping the
+// targets of the scrape configs.
+func (m *Manager) ApplyConfig(cfg *config.Config) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stop()
+	if len(cfg.Scrape.ScrapeConfigs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	for _, sc := range cfg.Scrape.ScrapeConfigs {
+		m.jobs.Add(1)
+		go func(sc *config.ScrapeConfig) {
+			defer m.jobs.Done()
+			m.runJob(ctx, sc)
+		}(sc)
+	}
+	return nil
+}
+
+// Stop stops the running scrapes.
+func (m *Manager) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stop()
+}
+
+func (m *Manager) stop() {
+	if m.cancel != nil {
+		m.cancel()
+		m.jobs.Wait()
+		m.cancel = nil
+	}
+}
+
+// runJob runs a scrape loop for each target of the job, until ctx is done.
+// The loops are synced with the target groups on each refresh of the file
+// service discovery.
+func (m *Manager) runJob(ctx context.Context, sc *config.ScrapeConfig) {
+	logger := m.logger.With("job", sc.JobName)
+	loops := make(map[string]context.CancelFunc)
+	var wg sync.WaitGroup
+	defer func() {
+		for _, cancel := range loops {
+			cancel()
+		}
+		wg.Wait()
+		targets.DeleteLabelValues(sc.JobName)
+	}()
+
+	// The groups of each file service discovery config, kept when the files
+	// can't be read.
+	fileGroups := make([][]*config.TargetGroup, len(sc.FileSDConfigs))
+	var refresh <-chan time.Time
+	if len(sc.FileSDConfigs) > 0 {
+		refreshInterval := sc.FileSDConfigs[0].RefreshInterval
+		for _, sd := range sc.FileSDConfigs[1:] {
+			refreshInterval = min(refreshInterval, sd.RefreshInterval)
+		}
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		groups := append([]*config.TargetGroup{}, sc.StaticConfigs...)
+		for i, sd := range sc.FileSDConfigs {
+			g, err := readFiles(sd.Files)
+			if err != nil {
+				logger.Error("Error reading file service discovery", "err", err)
+			} else {
+				fileGroups[i] = g
+			}
+			groups = append(groups, fileGroups[i]...)
+		}
+
+		active := make(map[string]struct{})
+		for _, t := range targetsFromGroups(sc, groups) {
+			key := t.key()
+			active[key] = struct{}{}
+			if _, ok := loops[key]; ok {
+				continue
+			}
+			loopCtx, cancel := context.WithCancel(ctx)
+			loops[key] = cancel
+			wg.Add(1)
+			go func(t *target) {
+				defer wg.Done()
+				m.runLoop(loopCtx, sc, t, logger.With("target", t.url))
+			}(t)
+		}
+		for key, cancel := range loops {
+			if _, ok := active[key]; !ok {
+				cancel()
+				delete(loops, key)
+			}
+		}
+		targets.WithLabelValues(sc.JobName).Set(float64(len(loops)))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh:
+		}
+	}
+}
+
+// runLoop scrapes the target every scrape interval, until ctx is done. The
+// first scrape is delayed by an offset derived from the target, to spread
+// the scrapes of the targets over the interval.
+func (m *Manager) runLoop(ctx context.Context, sc *config.ScrapeConfig, t *target, logger *slog.Logger) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(t.offset(sc.ScrapeInterval)):
+	}
+
+	ticker := time.NewTicker(sc.ScrapeInterval)
+	defer ticker.Stop()
+	for {
+		m.scrapeAndAppend(ctx, sc, t, logger)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeAndAppend scrapes the target and appends the scraped samples, along
+// with the up and scrape_duration_seconds series of the target.
+func (m *Manager) scrapeAndAppend(ctx context.Context, sc *config.ScrapeConfig, t *target, logger *slog.Logger) {
+	scrapes.WithLabelValues(sc.JobName).Inc()
+	start := time.Now()
+	samples, err := scrape(ctx, m.client, sc, t, start)
+	duration := time.Since(start)
+
+	up := 1.0
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("Error scraping target", "err", err)
+		failedScrapes.WithLabelValues(sc.JobName).Inc()
+		samples = nil
+		up = 0
+	}
+	ts := model.TimeFromUnixNano(start.UnixNano())
+	samples = append(samples,
+		t.sample("up", up, ts),
+		t.sample("scrape_duration_seconds", duration.Seconds(), ts),
+		t.sample("scrape_samples_scraped", float64(len(samples)), ts),
+	)
+
+	if err := m.appender.Append(ctx, samples); err != nil {
+		logger.Warn("Error appending scraped samples", "num_samples", len(samples), "err", err)
+		failedSamples.WithLabelValues(sc.JobName).Add(float64(len(samples)))
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAppender struct {
+	lock    sync.Mutex
+	samples model.Samples
+}
+
+func (a *fakeAppender) Append(_ context.Context, samples model.Samples) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.samples = append(a.samples, samples...)
+	return nil
+}
+
+// appended returns the appended samples by metric name and instance.
+func (a *fakeAppender) appended() map[string]model.SampleValue {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	values := make(map[string]model.SampleValue)
+	for _, s := range a.samples {
+		values[string(s.Metric[model.MetricNameLabel])+"/"+string(s.Metric[model.InstanceLabel])] = s.Value
+	}
+	return values
+}
+
+func TestManagerScrapesTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"))
+		_, _ = w.Write([]byte("requests_total 3\n"))
+	}))
+	defer srv.Close()
+	up := strings.TrimPrefix(srv.URL, "http://")
+
+	cfg := config.DefaultConfig
+	cfg.Scrape.ScrapeConfigs = []*config.ScrapeConfig{{
+		JobName:        "app",
+		ScrapeInterval: 20 * time.Millisecond,
+		ScrapeTimeout:  20 * time.Millisecond,
+		MetricsPath:    "/metrics",
+		Scheme:         "http",
+		StaticConfigs:  []*config.TargetGroup{{Targets: []string{up, "127.0.0.1:1"}}},
+	}}
+
+	appender := &fakeAppender{}
+	m := New(slog.New(slog.NewTextHandler(io.Discard, nil)), appender)
+	require.NoError(t, m.ApplyConfig(&cfg))
+	defer m.Stop()
+
+	require.Eventually(t, func() bool {
+		values := appender.appended()
+		_, ok := values["up/127.0.0.1:1"]
+		return ok && values["requests_total/"+up] == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	values := appender.appended()
+	assert.Equal(t, model.SampleValue(1), values["up/"+up])
+	assert.Equal(t, model.SampleValue(1), values["scrape_samples_scraped/"+up])
+	assert.Equal(t, model.SampleValue(0), values["up/127.0.0.1:1"])
+	assert.Contains(t, values, "scrape_duration_seconds/"+up)
+
+	// An empty config stops the scrapes.
+	require.NoError(t, m.ApplyConfig(&config.DefaultConfig))
+	appender.lock.Lock()
+	appender.samples = nil
+	appender.lock.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, appender.appended())
+}
@@ -0,0 +1,116 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+)
+
+const acceptHeader = `application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1`
+
+// scrape scrapes the target and returns its samples. The samples without a
+// timestamp are timestamped with ts.
+func scrape(ctx context.Context, client *http.Client, sc *config.ScrapeConfig, t *target, ts time.Time) (model.Samples, error) {
+	body, contentType, err := fetch(ctx, client, t.url, sc.ScrapeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return parse(body, contentType, t.labels, sc.HonorLabels, model.TimeFromUnixNano(ts.UnixNano()))
+}
+
+// fetch gets the exposition served at url and returns it with its content
+// type.
+func fetch(ctx context.Context, client *http.Client, url string, timeout time.Duration) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("User-Agent", "graphite-remote-adapter/"+version.Version)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// parse parses a Prometheus text or OpenMetrics exposition and attaches the
+// target labels to its samples. The scraped labels conflicting with them are
+// renamed to exported_<name>, unless honorLabels is set.
+func parse(body []byte, contentType string, targetLabels model.LabelSet, honorLabels bool, ts model.Time) (model.Samples, error) {
+	p := textparse.New(body, contentType)
+	var samples model.Samples
+	for {
+		entry, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry != textparse.EntrySeries {
+			continue
+		}
+
+		_, sampleTs, value := p.Series()
+		var lset labels.Labels
+		p.Metric(&lset)
+		metric := make(model.Metric, len(lset)+len(targetLabels))
+		for _, l := range lset {
+			metric[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		for ln, lv := range targetLabels {
+			if scraped, ok := metric[ln]; ok && scraped != "" {
+				if honorLabels {
+					continue
+				}
+				metric["exported_"+ln] = scraped
+			}
+			metric[ln] = lv
+		}
+
+		s := &model.Sample{Metric: metric, Value: model.SampleValue(value), Timestamp: ts}
+		if sampleTs != nil {
+			s.Timestamp = model.Time(*sampleTs)
+		}
+		samples = append(samples, s)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testScrapeConfig = &config.ScrapeConfig{
+	JobName:        "node",
+	ScrapeInterval: time.Minute,
+	ScrapeTimeout:  10 * time.Second,
+	MetricsPath:    "/metrics",
+	Scheme:         "http",
+}
+
+// sampleStrings returns the samples as sorted "<metric> <value> @<timestamp>" strings.
+func sampleStrings(samples model.Samples) []string {
+	var strs []string
+	for _, s := range samples {
+		strs = append(strs, fmt.Sprintf("%s %s @%d", s.Metric, s.Value, s.Timestamp))
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+func TestTargetsFromGroups(t *testing.T) {
+	targets := targetsFromGroups(testScrapeConfig, []*config.TargetGroup{
+		{Targets: []string{"a:9100", "b:9100"}, Labels: map[string]string{"env": "prod", "__hidden": "x"}},
+		{Targets: []string{"c:9100"}, Labels: map[string]string{"instance": "c"}},
+	})
+
+	require.Len(t, targets, 3)
+	assert.Equal(t, "http://a:9100/metrics", targets[0].url)
+	assert.Equal(t, model.LabelSet{"job": "node", "instance": "a:9100", "env": "prod"}, targets[0].labels)
+	assert.Equal(t, model.LabelSet{"job": "node", "instance": "c"}, targets[2].labels)
+	assert.Less(t, targets[0].offset(time.Minute), time.Minute)
+}
+
+func TestReadFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"),
+		[]byte(`[{"targets": ["a:9100"], "labels": {"env": "prod"}}]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"),
+		[]byte("- targets: [b:9100, c:9100]\n"), 0o600))
+
+	groups, err := readFiles([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")})
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, []string{"a:9100"}, groups[0].Targets)
+	assert.Equal(t, map[string]string{"env": "prod"}, groups[0].Labels)
+	assert.Equal(t, []string{"b:9100", "c:9100"}, groups[1].Targets)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte(`[{"unknown": 1}]`), 0o600))
+	_, err = readFiles([]string{filepath.Join(dir, "*.json")})
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	tgt := targetsFromGroups(testScrapeConfig, []*config.TargetGroup{{Targets: []string{"a:9100"}}})[0]
+	body := []byte(`# HELP http_requests_total Requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",instance="pod"} 12
+go_goroutines 7 1700000000000
+`)
+
+	samples, err := parse(body, "text/plain; version=0.0.4", tgt.labels, false, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`go_goroutines{instance="a:9100", job="node"} 7 @1700000000000`,
+		`http_requests_total{code="200", exported_instance="pod", instance="a:9100", job="node"} 12 @1000`,
+	}, sampleStrings(samples))
+
+	samples, err = parse(body, "text/plain; version=0.0.4", tgt.labels, true, 1000)
+	require.NoError(t, err)
+	assert.Contains(t, sampleStrings(samples), `http_requests_total{code="200", instance="pod", job="node"} 12 @1000`)
+
+	samples, err = parse([]byte(`# TYPE temp gauge
+temp{room="a"} 21.5 1700000000.5
+# EOF
+`), "application/openmetrics-text; version=1.0.0", tgt.labels, false, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`temp{instance="a:9100", job="node", room="a"} 21.5 @1700000000500`}, sampleStrings(samples))
+
+	_, err = parse([]byte("invalid metric line\n"), "text/plain", tgt.labels, false, 1000)
+	assert.Error(t, err)
+}
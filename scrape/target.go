@@ -0,0 +1,108 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package scrape
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+// target is a scraped endpoint.
+type target struct {
+	url string
+	// labels are the job and instance labels, and the labels of the target
+	// group, attached to the scraped samples.
+	labels model.LabelSet
+}
+
+// targetsFromGroups returns the targets of the target groups of a job. The
+// labels of a group may override the job and instance labels, and the ones
+// starting with "__" are dropped.
+func targetsFromGroups(sc *config.ScrapeConfig, groups []*config.TargetGroup) []*target {
+	var targets []*target
+	for _, g := range groups {
+		for _, addr := range g.Targets {
+			labels := model.LabelSet{
+				model.JobLabel:      model.LabelValue(sc.JobName),
+				model.InstanceLabel: model.LabelValue(addr),
+			}
+			for ln, lv := range g.Labels {
+				if strings.HasPrefix(ln, model.ReservedLabelPrefix) {
+					continue
+				}
+				labels[model.LabelName(ln)] = model.LabelValue(lv)
+			}
+			u := url.URL{Scheme: sc.Scheme, Host: addr, Path: sc.MetricsPath}
+			targets = append(targets, &target{url: u.String(), labels: labels})
+		}
+	}
+	return targets
+}
+
+// key identifies the target among the targets of a job.
+func (t *target) key() string {
+	return t.url + t.labels.String()
+}
+
+// offset returns the offset of the scrapes of the target in the interval.
+func (t *target) offset(interval time.Duration) time.Duration {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(t.key()))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// sample returns a sample of a series named name with the target labels.
+func (t *target) sample(name string, value float64, ts model.Time) *model.Sample {
+	metric := make(model.Metric, len(t.labels)+1)
+	for ln, lv := range t.labels {
+		metric[ln] = lv
+	}
+	metric[model.MetricNameLabel] = model.LabelValue(name)
+	return &model.Sample{Metric: metric, Value: model.SampleValue(value), Timestamp: ts}
+}
+
+// readFiles reads the target groups of the JSON or YAML files matching the
+// patterns.
+func readFiles(patterns []string) ([]*config.TargetGroup, error) {
+	var groups []*config.TargetGroup
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			var fileGroups []*config.TargetGroup
+			if err := yaml.Unmarshal(content, &fileGroups); err != nil {
+				return nil, fmt.Errorf("error parsing %s: %w", file, err)
+			}
+			groups = append(groups, fileGroups...)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package testrules runs unit tests of the write rules of the config, like
+// promtool test rules: each test gives input series and the carbon lines
+// they are expected to be written as.
+package testrules
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/utils"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// testFile is a file of rule tests.
+type testFile struct {
+	Tests []testGroup `yaml:"tests"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (f *testFile) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain testFile
+	if err := unmarshal((*plain)(f)); err != nil {
+		return err
+	}
+	return utils.CheckOverflow(f.XXX, "test file")
+}
+
+// testGroup is a test of series written with a prefix, the default prefix
+// if empty.
+type testGroup struct {
+	Name   string       `yaml:"name"`
+	Prefix string       `yaml:"prefix,omitempty"`
+	Input  []testSeries `yaml:"input"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (g *testGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain testGroup
+	if err := unmarshal((*plain)(g)); err != nil {
+		return err
+	}
+	if len(g.Input) == 0 {
+		return fmt.Errorf("test %q has no input series", g.Name)
+	}
+	return utils.CheckOverflow(g.XXX, "test")
+}
+
+// testSeries is a sample of a series, and the carbon lines it is expected
+// to be written as, or dropped. The timestamp is in seconds.
+type testSeries struct {
+	Series    string   `yaml:"series"`
+	Value     float64  `yaml:"value"`
+	Timestamp int64    `yaml:"timestamp,omitempty"`
+	Expected  []string `yaml:"expected,omitempty"`
+	Dropped   bool     `yaml:"dropped,omitempty"`
+
+	metric model.Metric
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *testSeries) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain testSeries
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	lset, err := parser.ParseMetric(s.Series)
+	if err != nil {
+		return fmt.Errorf("invalid series %q: %w", s.Series, err)
+	}
+	s.metric = make(model.Metric, len(lset))
+	for _, l := range lset {
+		s.metric[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	if s.Dropped == (len(s.Expected) > 0) {
+		return fmt.Errorf("series %q must either have expected lines or be dropped", s.Series)
+	}
+	return utils.CheckOverflow(s.XXX, "input series")
+}
+
+// Run runs the test files of the config against its write rules, and
+// prints a report to out. It fails if a test fails.
+func Run(cfg *config.Config, out io.Writer, logger *slog.Logger) error {
+	failed := 0
+	for _, filename := range cfg.TestRules.Files {
+		logger.Debug("Running rule tests", "file", filename)
+		n, err := runFile(&cfg.Graphite, filename, out)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		failed += n
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d rule tests failed", failed)
+	}
+	return nil
+}
+
+// runFile runs the tests of a file and returns the number of failed ones.
+func runFile(cfg *graphiteCfg.Config, filename string, out io.Writer) (int, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	var f testFile
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return 0, err
+	}
+
+	_, _ = fmt.Fprintln(out, filename)
+	failed := 0
+	for _, g := range f.Tests {
+		diffs := runGroup(cfg, g)
+		if len(diffs) == 0 {
+			_, _ = fmt.Fprintf(out, "  PASS %s\n", g.Name)
+			continue
+		}
+		failed++
+		_, _ = fmt.Fprintf(out, "  FAIL %s\n", g.Name)
+		for _, diff := range diffs {
+			_, _ = fmt.Fprintf(out, "    %s\n", strings.ReplaceAll(diff, "\n", "\n    "))
+		}
+	}
+	return failed, nil
+}
+
+// runGroup writes the series of a test like the graphite client does, and
+// returns the diffs with the expected lines of each series.
+func runGroup(cfg *graphiteCfg.Config, g testGroup) []string {
+	prefix := g.Prefix
+	if prefix == "" {
+		prefix = cfg.DefaultPrefix
+	}
+	format := paths.FormatForPrefix(cfg, prefix)
+
+	var diffs []string
+	for _, in := range g.Input {
+		s := &model.Sample{Metric: in.metric, Value: model.SampleValue(in.Value), Timestamp: model.TimeFromUnix(in.Timestamp)}
+		if cfg.NameEscaping == graphiteCfg.NameEscapingUnderscores {
+			s = &model.Sample{Metric: paths.UnderscoreNames(s.Metric), Value: s.Value, Timestamp: s.Timestamp}
+		}
+		datapoints, _, err := paths.ToDatapoints(s, format, prefix, cfg.Write.Rules, cfg.Write.TemplateData, nil)
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: error: %s", in.Series, err))
+			continue
+		}
+		got := make([]string, 0, len(datapoints))
+		for _, dp := range datapoints {
+			got = append(got, strings.TrimSuffix(string(dp), "\n"))
+		}
+		want := make([]string, 0, len(in.Expected))
+		for _, line := range in.Expected {
+			want = append(want, strings.TrimSpace(line))
+		}
+		if diff := diffLines(want, got); diff != "" {
+			if in.Dropped {
+				diff = "expected to be dropped\n" + diff
+			}
+			diffs = append(diffs, in.Series+":\n"+diff)
+		}
+	}
+	return diffs
+}
+
+// diffLines returns the lines of want missing from got prefixed by "-", and
+// the ones of got not wanted prefixed by "+", regardless of their order.
+func diffLines(want, got []string) string {
+	counts := make(map[string]int, len(want))
+	for _, line := range want {
+		counts[line]++
+	}
+	var unexpected []string
+	for _, line := range got {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			unexpected = append(unexpected, line)
+		}
+	}
+	var b strings.Builder
+	for _, line := range want {
+		if counts[line] > 0 {
+			counts[line]--
+			b.WriteString("- " + line + "\n")
+		}
+	}
+	for _, line := range unexpected {
+		b.WriteString("+ " + line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
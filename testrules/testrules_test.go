@@ -0,0 +1,122 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package testrules
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T, tests ...string) *config.Config {
+	cfg, err := config.Load(`
+graphite:
+  default_prefix: prom.
+  write:
+    rules:
+      - match:
+          job: node
+        template: 'nodes.{{.labels.instance}}.{{.labels.__name__}}'
+        continue: true
+      - match:
+          job: blackhole
+        continue: false
+`)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	for i, test := range tests {
+		filename := filepath.Join(dir, string(rune('a'+i))+".yml")
+		require.NoError(t, os.WriteFile(filename, []byte(test), 0o644))
+		cfg.TestRules.Files = append(cfg.TestRules.Files, filename)
+	}
+	return cfg
+}
+
+func TestRun(t *testing.T) {
+	cfg := testConfig(t, `
+tests:
+  - name: nodes are templated
+    input:
+      - series: 'up{job="node",instance="a"}'
+        value: 1
+        timestamp: 1700000000
+        expected:
+          - prom.up.instance.a.job.node 1.000000 1700000000
+          - nodes.a.up 1.000000 1700000000
+  - name: blackhole is dropped
+    prefix: tenant.
+    input:
+      - series: 'up{job="blackhole"}'
+        dropped: true
+      - series: 'up{job="api"}'
+        expected:
+          - tenant.up.job.api 0.000000 0
+`)
+	var out bytes.Buffer
+	require.NoError(t, Run(cfg, &out, slog.New(slog.DiscardHandler)))
+	assert.Equal(t, cfg.TestRules.Files[0]+"\n"+
+		"  PASS nodes are templated\n"+
+		"  PASS blackhole is dropped\n", out.String())
+}
+
+func TestRunFailures(t *testing.T) {
+	cfg := testConfig(t, `
+tests:
+  - name: nodes are templated
+    input:
+      - series: 'up{job="node",instance="a"}'
+        value: 1
+        expected:
+          - nodes.up 1.000000 0
+          - prom.up.instance.a.job.node 1.000000 0
+  - name: node is dropped
+    input:
+      - series: 'up{job="node",instance="a"}'
+        dropped: true
+`)
+	var out bytes.Buffer
+	assert.EqualError(t, Run(cfg, &out, slog.New(slog.DiscardHandler)), "2 rule tests failed")
+	assert.Equal(t, cfg.TestRules.Files[0]+"\n"+
+		"  FAIL nodes are templated\n"+
+		"    up{job=\"node\",instance=\"a\"}:\n"+
+		"    - nodes.up 1.000000 0\n"+
+		"    + nodes.a.up 1.000000 0\n"+
+		"  FAIL node is dropped\n"+
+		"    up{job=\"node\",instance=\"a\"}:\n"+
+		"    expected to be dropped\n"+
+		"    + nodes.a.up 0.000000 0\n"+
+		"    + prom.up.instance.a.job.node 0.000000 0\n", out.String())
+}
+
+func TestRunInvalidFiles(t *testing.T) {
+	for _, test := range []string{
+		"tests: [{name: empty}]",
+		"tests: [{name: t, input: [{series: 'up{', dropped: true}]}]",
+		"tests: [{name: t, input: [{series: up}]}]",
+		"tests: [{name: t, input: [{series: up, dropped: true, expected: [up 0.000000 0]}]}]",
+		"tests: [{name: t, input: [{series: up, dropped: true, values: 1}]}]",
+		"rules: []",
+	} {
+		cfg := testConfig(t, test)
+		assert.Error(t, Run(cfg, &bytes.Buffer{}, slog.New(slog.DiscardHandler)), test)
+	}
+}
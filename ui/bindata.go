@@ -110,7 +110,7 @@ func templates_baseHtml() (*asset, error) {
 	return a, nil
 }
 
-var _templatesSimulationHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x54\xe1\x6f\x1a\xc7\x13\xfd\x7e\x7f\xc5\xfc\xd6\x1f\x92\x48\x86\x3b\xf0\x2f\x8e\x9a\x02\x2a\x25\x6e\x8b\x1a\x81\x64\x48\xad\x7c\x8a\x96\xbd\xe1\x6e\xe3\xbd\x9d\xeb\xec\x9c\xcf\xc8\xf2\xff\x5e\xed\x01\x95\x69\x50\x5b\x95\x4f\xec\xcc\xe3\xed\xdb\x79\x6f\x18\xfd\xaf\xd7\x4b\x60\x46\xf5\x8e\x6d\x51\x0a\x0c\xb3\xc1\x3b\x58\x97\x76\xa3\x1b\x27\x30\x2b\xb5\x68\x5b\x78\x64\x18\xc9\xa1\xd8\x37\x7f\x16\x7f\x28\x2a\x6d\x5d\xdf\x50\x35\x39\xe5\x18\xfe\xbf\x37\xcc\x86\x6f\x61\x81\x32\x63\x6d\xee\x91\x61\x8d\xa6\xf4\xe4\xa8\xd8\xc1\x8c\xb8\x26\xd6\x62\xc9\x27\x09\x7c\xb4\x06\x7d\xc0\x1c\x1a\x9f\x23\x83\x94\x08\xd3\x5a\x9b\x12\x8f\x9d\x4b\xf8\x0d\x39\x58\xf2\x30\xec\x67\xf0\x3a\x02\xd4\xa1\xa5\xde\x7c\x9f\xc0\x8e\x1a\xa8\xf4\x0e\x3c\x09\x34\x01\x41\x4a\x1b\x60\x6b\x1d\x02\x3e\x1a\xac\x05\xac\x07\x43\x55\xed\xac\xf6\x06\xa1\xb5\x52\x76\xb7\x1c\x38\xfa\x09\x7c\x3e\x30\xd0\x46\xb4\xf5\xa0\xc1\x50\xbd\x03\xda\xbe\x84\x81\x96\x24\x81\xee\x53\x8a\xd4\xef\xd3\xb4\x6d\xdb\xbe\xee\x94\xf6\x89\x8b\xd4\xed\x71\x21\xfd\x38\x9f\xdd\x2c\x56\x37\xbd\x61\x3f\x4b\x12\xf8\xe4\x1d\x86\x00\x8c\xbf\x37\x96\x31\x87\xcd\x0e\x74\x5d\x3b\x6b\xf4\xc6\x21\x38\xdd\x02\x31\xe8\x82\x11\x73\x10\x8a\x5a\x5b\xb6\x62\x7d\x71\x09\x81\xb6\xd2\x6a\xc6\x04\x72\x1b\x84\xed\xa6\x91\x93\x31\x1d\x95\xd9\x70\x02\x20\x0f\xda\x83\x9a\xae\x60\xbe\x52\xf0\xe3\x74\x35\x5f\x5d\x26\x70\x37\x5f\xff\xb2\xfc\xb4\x86\xbb\xe9\xed\xed\x74\xb1\x9e\xdf\xac\x60\x79\x0b\xb3\xe5\xe2\xc3\x7c\x3d\x5f\x2e\x56\xb0\xfc\x09\xa6\x8b\xcf\xf0\xeb\x7c\xf1\xe1\x12\xd0\x4a\x89\x0c\xf8\x58\x73\x54\x4f\x0c\x36\x0e\x10\xf3\x7e\x02\x2b\xc4\x93\xeb\xb7\xb4\x97\x13\x6a\x34\x76\x6b\x0d\x38\xed\x8b\x46\x17\x08\x05\x3d\x20\x7b\xeb\x0b\xa8\x91\x2b\x1b\xa2\x89\x01\xb4\xcf\x13\x70\xb6\xb2\xd2\x45\x20\x7c\xfb\xa2\x7e\xd2\xeb\x4d\x92\xe4\xe9\x29\xc7\xad\xf5\x08\x0a\x1f\x85\x75\xaf\x44\x9d\xab\xe7\xe7\xce\x85\x51\x30\x6c\x6b\x81\xc0\x66\xac\x42\x64\x32\xe9\xd7\x90\xea\xda\xf6\xbf\x06\x35\x19\xa5\xfb\xfe\x24\x79\x7a\x42\x9f\x3f\x3f\xbf\x20\x33\xe4\x05\xbd\x44\xa6\x51\x39\x98\xac\x6c\xd5\xb8\x4e\xc9\x28\x2d\x07\x93\x3d\x7b\x6e\x1f\xc0\x38\x1d\xc2\x58\x31\xb5\x6a\x72\x70\xfe\xb4\xb3\x25\xae\x7a\x05\x53\x53\x83\x21\xd7\x1b\x0c\x5f\xe0\x3a\xec\xd6\xa2\xcb\x03\xca\x69\xb9\x6b\x39\x2c\xd0\xe7\x93\xb9\xaf\x1b\x09\xa3\xf4\x70\xfc\x16\x27\xf8\x28\x9a\x51\x9f\xdc\x19\x5f\xc0\xe4\x14\xd8\x7c\xac\x6c\xa4\x50\xc0\xd4\x86\xf1\x20\x83\xda\x69\x83\x25\xb9\x1c\x79\xfc\xea\x02\x7e\xb6\x0f\x08\x1a\x9c\x0d\x12\x03\x5d\xa1\xb0\x35\x01\x9a\xd0\xb9\xc2\x54\xa1\x94\xd8\x84\xe8\x34\xb1\x44\x2f\x2b\x2d\xc9\x05\x4c\x9d\x83\x2d\x39\x47\x6d\x04\xba\x38\xb8\xd6\x3a\x07\x2d\xf1\x7d\x78\x9f\x24\x71\x07\xbe\xc4\x50\x63\x90\xf0\x45\x48\xb4\x7b\x8a\x5c\x94\x8f\x55\x4d\x41\xd4\xa5\xa1\x1c\xc7\x6a\x98\x65\xea\x19\x06\xd9\xf0\x1d\x0c\xae\xbe\x7b\x9b\x5d\x5f\x5f\x5d\x5f\x65\x59\xf6\x1f\x08\xce\xfd\x64\xdf\xb8\x80\x9b\x07\xec\x96\xbc\x42\x2f\x9d\xda\x00\x5b\xa6\x2a\xfe\x37\x30\xa4\x87\x57\xbf\x9a\x8c\xd2\xe3\x3c\xcf\x8c\x7a\xc3\xe9\xb9\x6a\x23\x42\x1e\x64\x57\xe3\x58\xed\x0f\xea\x68\xc6\x46\x3c\x6c\xc4\xf7\x02\x1a\xf2\xb9\xe6\x9d\x02\xf2\xc6\x59\x73\x3f\x56\x21\xc6\xea\x8e\xad\xe0\xeb\x37\xea\x18\x32\xec\x96\x1b\x47\xe9\x9e\xe8\x2f\x71\x49\xcf\xe7\xe5\x5f\xc4\x68\xd9\xc8\x3f\xe4\x28\xc6\x36\xa6\x85\xf6\xc8\xb8\x21\xb9\x7d\xf8\x1b\x20\x32\x13\xf7\xaa\x50\x9c\x85\x9e\xd3\xfa\x02\x76\xf8\x7a\xdc\xbd\x3f\x02\x00\x00\xff\xff\x9a\x93\xda\xdf\x65\x06\x00\x00")
+var _templatesSimulationHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xad\x54\x6d\x6f\xdb\x36\x10\xfe\xae\x5f\x71\x63\x3e\xb4\x05\x2c\xcb\x76\xd6\x14\xcb\x6c\x63\x9e\x9b\x75\xc6\x0a\x1b\x88\xdd\x05\xfd\x54\xd0\xd4\x59\x62\x43\x91\x2a\x49\x59\x31\x82\xfc\xf7\x1e\x25\xa5\x8b\x51\xef\xad\x9b\x81\x38\x26\xef\xe1\xdd\x73\x6f\xcf\xf8\xbb\x38\x8e\x60\x6e\xca\x83\x95\x59\xee\x61\x34\x18\xbe\x82\x4d\x2e\xb7\xbc\x52\x1e\xe6\x39\xf7\x5c\x66\x1a\x2d\x8c\x7d\x77\xd9\x17\x5f\x2e\x7f\xca\x0a\x2e\x55\x5f\x98\x62\x7a\xec\x63\xf4\x7d\x4c\x5f\x17\xb0\x44\x3f\xb7\x5c\xdc\xd2\xfb\x0d\x8a\x5c\x1b\x65\xb2\x03\x21\x6d\x69\x2c\xf7\xd2\xe8\x28\x82\xb7\x52\xa0\x76\x98\x42\xa5\x53\xc2\xf9\x1c\x61\x56\x72\x41\xff\x3a\x4b\x0f\x7e\x47\xeb\x08\x0c\xa3\xfe\x00\x9e\x07\x00\xeb\x4c\xec\xc5\x8f\x11\x1c\x4c\x05\x05\x3f\x80\x36\x1e\x2a\x87\xe4\x41\x3a\xd8\x49\x85\x80\x77\x02\x4b\x0f\x52\x03\x51\x2c\x95\xe4\x5a\x20\xd4\xd2\xe7\x4d\x94\xce\x47\x3f\x82\xf7\x9d\x07\xb3\xa5\xc4\x34\x70\x82\x97\x74\xda\x3d\x85\x01\xf7\x44\xb6\xf9\xe4\xde\x97\x97\x49\x52\xd7\x75\x9f\x37\x4c\xfb\xc6\x66\x89\x6a\x71\x2e\x79\xbb\x98\x5f\x2d\xd7\x57\x31\xb1\xa5\x17\xef\xb4\x42\xe7\xc0\xe2\xa7\x4a\x5a\xca\x72\x7b\x00\x5e\x12\x17\xc1\xb7\xc4\x50\xf1\x1a\x8c\x05\x9e\x59\x24\x9b\x37\x81\x6b\x6d\xa5\x97\x3a\xeb\x81\x33\x3b\x5f\x73\x8b\x11\xa4\xd2\x79\x2b\xb7\x95\x3f\x2a\xd3\x23\x33\x4a\xf7\x29\x80\x0a\xc5\x35\xb0\xd9\x1a\x16\x6b\x06\x3f\xcf\xd6\x8b\x75\x2f\x82\x9b\xc5\xe6\xd7\xd5\xbb\x0d\xdc\xcc\xae\xaf\x67\xcb\xcd\xe2\x6a\x0d\xab\x6b\x98\xaf\x96\xaf\x17\x9b\xc5\x6a\x49\xa7\x5f\x60\xb6\x7c\x0f\xbf\x2d\x96\xaf\x7b\x80\x54\x24\x8a\x82\x77\xa5\x0d\xec\x89\xa2\x0c\x05\xc4\x94\xaa\xb5\x46\x3c\x0a\xbf\x33\x2d\x1d\x57\xa2\x90\x3b\x29\x28\x29\x9d\x55\x3c\x43\xc8\xcc\x1e\xad\xa6\x5c\xa0\x44\x5b\x48\x17\x9a\xe8\x88\x5c\x1a\x81\x92\x85\xf4\xcd\x08\xb8\xaf\x33\xea\x47\x71\x3c\x8d\xa2\xfb\xfb\x14\x77\x52\x53\xbb\xf1\xce\x5b\x1e\xe7\xc8\x53\xf6\xf0\xd0\x74\x61\xec\x84\x95\xd4\x5b\x67\xc5\x84\xb9\xe0\x49\x24\x1f\x5d\xc2\x4b\xd9\xff\xe8\xd8\x74\x9c\xb4\xf6\x29\x39\x41\x9d\xd2\xa3\x3f\x9c\x09\xa3\x3d\x6a\x1f\x3c\x8d\xf3\xe1\x74\x2d\x8b\x4a\x35\x4c\xc6\x09\x1d\x5b\xef\xa9\xdc\x83\x50\xdc\xb9\x09\xb3\xa6\x66\xd3\xae\xf3\xc7\x16\x4a\xbc\x88\x33\x6b\xaa\x92\x06\x46\xc5\xc3\xd1\x13\x5c\x83\xdd\x49\x54\xa9\x43\x7f\x7c\xdd\x98\x14\x66\xc4\x6b\xba\xd0\x65\xe5\xdd\x38\xe9\x8e\x5f\xe3\x3c\xa5\x4e\x23\xc0\x8f\x62\x86\x0c\xac\x51\x0c\x64\x3a\x61\x32\xb8\x60\x40\x34\xdd\x64\x38\x80\x52\x71\x81\xb9\x51\x54\xd2\xc9\xb3\x33\x78\x23\xf7\x34\xba\x54\x6e\xe7\xc3\x40\x17\x48\x63\x22\xa8\xe4\xae\xe9\x8a\x35\x74\x91\x63\xe5\x42\xa7\x8d\xf5\xa1\x97\x05\x0d\xfa\x19\xcc\x94\xa2\x83\x52\xa6\x0e\x40\x15\x0a\x57\x4b\xba\xab\x8d\xbd\x75\x97\x51\x14\x76\xe0\x43\x18\x6a\x74\xde\x7d\xf0\xc6\x73\x75\x1f\x7c\x19\x62\x54\x1a\xe7\x59\x4f\x98\x14\x27\x6c\x34\x18\xb0\x07\x18\x0e\x46\xaf\x60\x78\xfe\xc3\xcb\xc1\xc5\xc5\xf9\xc5\xf9\x60\x30\xf8\x06\x07\xa7\x9e\xb4\x86\x33\xb8\xda\x63\xb3\xe4\x05\x35\xb6\x61\x4b\x0a\x40\xc9\x05\x6d\xb0\x90\x74\x59\x13\xee\xba\xa2\x6d\x04\x41\xfb\xc1\x95\x33\xb0\xa5\x49\x26\x7d\xa2\xa5\x09\x43\xec\xd0\x4a\xb2\x06\x81\x30\x95\x87\x3d\x57\x15\x5e\xfe\x3b\xa2\xcf\x68\xf2\x1e\x5b\x76\xa2\x9b\x5b\x9b\x9c\xb8\x6d\x3a\x08\xfe\x50\x92\x97\xf0\x98\xfd\x79\xb3\x69\x1d\x77\xf2\x8e\x1d\x75\x99\xbd\xb1\xbc\xcc\xa5\x47\x68\xad\xbd\x66\x95\x68\xda\x1b\x09\x37\xd4\x39\xb9\x03\x2c\x4a\x7f\x60\xa7\xa2\xff\x67\x4e\x82\x74\x81\xd6\xe9\x98\xd3\xbc\xb9\xec\xaa\xdb\x32\xca\x1e\x69\x6e\x95\x11\xb7\xdf\x42\x8a\xf4\xcd\x93\xba\xb5\xac\xda\xc3\x17\x5e\x5b\xaf\x81\xfe\x62\x87\x44\x2e\xe5\xf6\xc0\x28\x75\xa2\x26\x6e\x49\x1f\xc2\x8a\xdf\x90\xac\xe2\xf3\x17\xec\x71\xe1\xb1\x11\x5a\x1c\x27\xad\xa3\xff\x3b\xdc\x26\x64\x1e\xc2\x35\x3f\xc0\x86\xc1\x3b\x1d\x6b\x9c\x9c\xd6\x89\x7f\x20\x1f\xab\xca\xff\x8d\x7e\x04\xb9\x0a\x4d\x32\x2d\x32\x28\x23\x5d\xfd\x05\x10\xad\x35\x36\x2e\x5c\x76\x12\x7a\x8a\xeb\x13\x58\xf7\xf3\x51\x73\x3f\x03\x50\xbd\x02\x6c\x5d\x08\x00\x00")
 
 func templatesSimulationHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -125,7 +125,7 @@ func templatesSimulationHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "templates/simulation.html", size: 1637, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "templates/simulation.html", size: 2141, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -310,7 +310,7 @@ func staticFontsFontawesomeWebfontWoff2() (*asset, error) {
 	return a, nil
 }
 
-var _staticJsApiJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x55\x71\x73\xda\xc6\x13\xfd\x9f\x4f\xb1\xd1\x2f\x33\x48\x18\x24\xe2\x5f\x3a\x9d\x0a\x63\x4a\x49\x3a\xa5\x75\xf0\x8c\x45\x9a\x49\x0d\xf5\x9c\xa5\x45\xba\xf4\x74\xa7\xde\x9d\x0c\xd4\xe1\xbb\x77\xee\x24\x40\x4a\x52\xfe\x90\xe5\xd3\xdb\xb7\x6f\xdf\xee\x4a\x41\xaf\xd7\x81\x1e\xcc\x44\xb1\x97\x34\xcd\x34\x5c\x0e\x5f\x7d\x0f\xcb\x8c\x3e\x92\x92\x69\x98\x65\x44\x13\x9a\x72\x94\x70\xa5\xeb\x43\x3f\x3e\x1d\xfe\x98\xe6\x84\x32\x3f\x16\xf9\xf5\x97\x2c\x97\xaf\x07\x97\xc3\xcb\xef\x60\x81\x7a\x26\x49\xfc\x17\x4a\x58\x62\x9c\x71\xc1\x44\xba\x87\x99\x90\x85\x90\x44\x53\xc1\x3b\x60\x15\xdc\xd0\x18\xb9\xc2\x04\x4a\x9e\xa0\x04\x9d\x21\x4c\x0b\x12\x67\x78\x7c\xd2\x87\xdf\x51\x2a\x2a\x38\x5c\xfa\x43\x70\x0d\xc0\xa9\x1f\x39\xde\xc8\x50\xec\x45\x09\x39\xd9\x03\x17\x1a\x4a\x85\xa0\x33\xaa\x60\x43\x19\x02\xee\x62\x2c\x34\x50\x0e\xb1\xc8\x0b\x46\x09\x8f\x11\xb6\x54\x67\x36\x4f\xcd\xe2\x1b\x8e\x8f\x35\x87\x78\xd4\x84\x72\x20\x10\x8b\x62\x0f\x62\xd3\x04\x02\xd1\xb5\x68\xfb\xcb\xb4\x2e\xc2\x20\xd8\x6e\xb7\x3e\xb1\x8a\x7d\x21\xd3\x80\x55\x58\x15\xdc\xcc\x67\x6f\x17\xd1\xdb\xc1\xa5\x3f\xac\xa3\xde\x73\x86\x4a\x81\xc4\xbf\x4b\x2a\x31\x81\xc7\x3d\x90\xa2\x60\x34\x26\x8f\x0c\x81\x91\x2d\x08\x09\x24\x95\x88\x09\x68\x61\x54\x6f\x25\xd5\x94\xa7\x7d\x50\x62\xa3\xb7\x44\xa2\xa1\x49\xa8\xd2\x92\x3e\x96\xba\x65\xda\x51\x23\x55\x2d\x80\xe0\x40\x38\x38\xd3\x08\xe6\x91\x03\x3f\x4d\xa3\x79\xd4\x37\x24\x1f\xe6\xcb\x5f\x6e\xdf\x2f\xe1\xc3\xf4\xee\x6e\xba\x58\xce\xdf\x46\x70\x7b\x07\xb3\xdb\xc5\x9b\xf9\x72\x7e\xbb\x88\xe0\xf6\x67\x98\x2e\x3e\xc2\x6f\xf3\xc5\x9b\x3e\x20\xd5\x19\x4a\xc0\x5d\x21\x4d\x05\x42\x02\x35\x76\x62\x62\xbd\x8b\x10\x5b\x12\x36\xa2\x92\xa4\x0a\x8c\xe9\x86\xc6\xc0\x08\x4f\x4b\x92\x22\xa4\xe2\x09\x25\xa7\x3c\x85\x02\x65\x4e\x95\x69\xab\x02\xc2\x13\x43\xc3\x68\x4e\xb5\x1d\x0d\xf5\x75\x5d\x26\x51\xd0\xe9\xc4\x82\x2b\x0d\x12\x53\xdc\x15\xef\x50\x4b\x1a\xc3\x18\x02\xf7\x9e\x0c\xfe\x99\x0e\xfe\x78\x08\xd7\xf5\xdd\x70\xf0\xc3\x43\xb8\xee\x79\xee\x24\x7c\x76\xfd\x9e\x77\xf0\x26\x2b\x75\xe1\xba\x93\x70\x95\xf4\x56\xbe\x37\x59\x25\x17\xee\x24\xc4\x55\x72\xe1\x4d\x0c\xca\x3c\x35\xff\x78\x93\x60\xd4\xca\x72\x43\x1e\x91\xa9\x56\x96\x66\x92\x75\xcf\x5b\xa9\xc9\x78\xa5\x26\x8e\x7b\xff\xa7\xb3\x5a\xad\x56\xeb\x9e\xe1\x5b\xf9\xa7\x7f\xbd\x9e\xe7\x04\x69\x3e\xea\x74\x36\x25\x8f\x4d\x81\x50\x10\xa9\xb0\xa2\x76\x73\x5b\xc7\x82\xe4\xd8\x07\x49\xb6\xd5\x69\xa4\xa5\x07\xcf\x1d\x33\x67\x0c\x35\xb0\xa3\x8a\x67\xe7\xe1\x81\x93\x1c\x1f\x1e\x9c\x10\xce\x91\x87\x91\x85\xd2\x0d\xb8\x4d\x0a\x78\x31\x1e\x5b\x2f\x37\x94\x63\x72\x24\x34\xbf\xa0\x87\x8a\x51\xae\x81\x8b\x41\x2c\x78\x32\x20\x4a\xd1\x94\x87\xe0\x6c\x89\xe4\x4e\x2f\x38\x21\xb7\x99\xd9\x24\x37\x27\x3a\xce\x60\xdc\x72\xc5\xc7\x1d\xc6\xad\x84\x5e\x33\x87\x15\x6f\x9f\xdc\xdb\xe8\xfb\x57\xeb\x35\x8c\xa1\xba\xbf\x5c\x8f\x4e\xc0\x43\xe7\x7c\x95\xa8\x4b\xc9\xeb\xb8\x51\xe7\xf0\xa5\x69\x11\xc9\x0b\x86\xae\xde\xe9\x1b\xca\xb1\x0f\x09\x6e\xcc\xcb\x69\x49\x73\x54\x9a\xe4\x45\xd4\xb4\xad\x2d\xba\x1a\x98\x4a\x74\x1d\xef\x9d\x7d\xab\xb0\x2f\xc6\xc0\x4b\xc6\x9a\x65\xb4\xfc\x6f\x35\xae\x2e\xaa\x7f\x2a\xc9\x1b\xb5\xa2\xf4\x29\x62\xce\x75\x0d\x7f\xbd\xf6\xe0\xf3\xe7\xaf\x65\x9f\x23\x6b\x07\x9e\x9d\xaa\xbd\x4e\x58\x67\xef\x83\xf3\x44\x58\x89\x4e\x08\xf7\x5a\x1d\x93\xfe\x7f\xbd\xae\x9b\xdf\xf2\xcf\x14\xd1\x76\x2f\x23\x3c\x61\x18\xd1\xbc\x64\x76\xc9\xee\x50\x95\x4c\xbb\xd2\xfe\x69\x9a\xf6\x49\x09\x2e\xd1\x48\xff\x35\xba\x5d\xf8\x56\xff\x11\x36\xea\x9c\x60\x99\xce\x19\x8c\xc1\xb9\x4a\xd8\xb5\x53\x29\x78\xe9\x23\x89\x33\xb7\x26\xe8\xc3\x29\xb7\x6b\x5e\x64\x28\xab\x19\xaf\xee\xdf\xa9\xb4\x69\xb2\x65\xbb\x18\x43\xf7\x2a\xd1\xd7\x5d\xb8\x80\x73\x04\x5c\x40\xf7\x2a\x30\xc7\xa3\x6f\xc1\x93\xeb\xab\x42\x22\xc4\x8c\x28\x35\x76\x08\x43\xa9\xc1\x5e\x07\xcc\x7c\x92\x9c\x06\xdb\x3b\x95\x56\x64\x85\xc4\xeb\xab\x20\x49\x8e\x94\x87\xba\x71\x47\x5a\xe7\x2a\x68\x54\xe5\x3a\xff\x13\xa5\x2e\x4a\xad\x1c\xcf\x37\x10\xd7\x5c\x3c\x6b\x6f\x73\x8f\x4a\x5e\x2a\x4c\x06\x4f\x44\xaa\xc6\x1e\x9d\x4c\x50\xc6\xfa\x0f\x46\x88\xdb\xb4\x5b\xef\x34\x8c\x6d\x12\xca\x8b\x52\x3b\x9e\xff\x44\x98\x5b\x0b\xb2\xb3\x47\xb9\xed\x86\xde\x69\x5f\x15\x8c\x6a\x37\x58\xf1\xa0\x01\xe0\x62\x1b\x19\x0a\x9f\x8b\xad\xeb\x41\x00\xaf\x86\xc3\x61\xa3\x53\xca\xae\x8c\xa1\xb8\x5f\xb7\x1a\x65\x99\x9b\x6d\xa2\x7d\x9b\xed\xcb\xe9\xaf\x08\x8e\xb3\x5c\x6f\xe0\x4b\x5f\x4b\x9a\x5b\x0e\xaf\x6f\x35\x34\xa6\xdf\x2c\x54\x1d\xf5\x8d\x8d\x32\xbf\x5a\x94\x5f\x94\x2a\xab\xa1\xde\x57\x6f\x04\xef\x28\x97\x7c\x22\x3b\xf7\x4c\x50\x4a\x16\x42\xd7\x36\xb5\xdb\x3f\x9d\xea\x7d\x81\x21\x74\x0b\xa1\x74\xe3\x34\x21\x9a\x84\xd5\x28\x9b\xcf\x22\x4f\xe9\x66\x5f\x27\x54\xde\x19\x96\x21\x49\xd0\x34\xee\xd9\x99\x09\xae\x91\xeb\xc1\x72\x5f\x98\x65\xeb\xd6\x1f\x69\x63\x51\x60\x66\xbb\x7b\x38\x87\xa9\x32\x8e\x51\xa9\xf0\x3f\xd6\xeb\x54\xc6\xa1\xd3\xf9\x37\x00\x00\xff\xff\xd7\xb3\x2f\x8f\x73\x09\x00\x00")
+var _staticJsApiJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa5\x58\xff\x73\xe2\xb8\x15\xff\x3d\x7f\x85\xce\xed\x0c\x36\x0b\x76\x36\xf7\xa5\x53\x02\xa4\x69\x76\x3b\xc7\x75\x97\xdc\x04\xb6\x3b\xd7\x84\x66\x14\x5b\x80\x52\x63\xbb\x92\x1c\x42\x73\xfc\xef\xf7\x9e\x24\x63\x99\x90\x66\xbb\xc7\x4c\x40\x5f\xde\xfb\xbc\xaf\x7a\x7a\x4a\xd4\x6e\x1f\x91\x36\xb9\xc8\x8b\x8d\xe0\x8b\xa5\x22\x27\xc7\x6f\xff\x44\xa6\x4b\x7e\x47\xcb\x54\x91\x8b\x25\x55\x94\x2f\x32\x26\x48\x5f\xd9\xc5\x30\xde\x2d\xfe\x65\xb1\xa2\x3c\x0d\xe3\x7c\x35\xdc\x47\x39\xf9\xae\x0b\x5f\x3f\x90\x31\x53\x17\x82\xc6\xff\x06\x84\x29\x8b\x97\x59\x9e\xe6\x8b\x0d\x50\x8a\x22\x17\x54\xf1\x3c\x03\x46\xe4\xfd\xc0\x63\x96\x49\x96\x90\x32\x4b\x80\x56\x2d\x19\x39\x2f\x68\x0c\x3f\x76\xa7\x43\xfe\xc1\x84\x04\x06\x72\x12\x1e\x13\x1f\x09\x3c\xbb\xe5\x05\xa7\x08\xb1\xc9\x4b\xb2\xa2\x1b\x92\xe5\x8a\x94\x92\x01\x06\x97\x64\xce\x53\x46\xd8\x63\xcc\x0a\x45\x78\x46\x40\xd5\x22\xe5\x34\x8b\x19\x59\x73\xb5\xd4\x72\x2c\x4a\x88\x18\xbf\x58\x8c\xfc\x0e\x4c\xcc\x08\x05\x86\x02\x66\x73\x97\x90\x50\x65\x95\xd6\x9f\xa5\x52\x45\x2f\x8a\xd6\xeb\x75\x48\xb5\xc6\x61\x2e\x16\x51\x6a\x68\x65\xf4\x61\x74\xf1\x7e\x3c\x79\xdf\x05\xad\x2d\xd7\xa7\x2c\x65\x52\x12\xc1\xfe\x53\x72\x01\x16\xdf\x6d\x08\x2d\x40\xab\x98\xde\x81\xae\x29\x5d\x93\x5c\x10\xba\x10\x0c\xf6\x54\x8e\x5a\xaf\x05\x57\x3c\x5b\x74\x88\xcc\xe7\x6a\x4d\x05\x43\x98\x84\x4b\x25\xf8\x5d\xa9\x1a\x4e\xab\x74\x04\xd3\x5d\x02\x70\x1b\xcd\x88\x77\x3e\x21\xa3\x89\x47\xfe\x7a\x3e\x19\x4d\x3a\x08\xf2\x79\x34\xfd\xf1\xf2\xd3\x94\x7c\x3e\xbf\xba\x3a\x1f\x4f\x47\xef\x27\xe4\xf2\x8a\x5c\x5c\x8e\xdf\x8d\xa6\xa3\xcb\x31\xcc\xfe\x46\xce\xc7\xbf\x90\xbf\x8f\xc6\xef\x3a\x84\x81\xcb\x40\x0e\x7b\x2c\x04\x5a\x00\x6a\x72\x74\x27\x4b\xb4\xef\x26\x8c\x35\x54\x98\xe7\x46\x25\x59\xb0\x98\xcf\x79\x0c\xa6\x65\x8b\x92\x2e\x18\x59\xe4\x0f\x4c\x64\x60\x11\x29\x98\x58\x71\x89\x61\x95\xa0\x60\x82\x30\x29\x5f\x71\xa5\x53\x43\x3e\xb7\x0b\x05\x45\x47\x47\x31\x6c\x2a\xf0\xe0\x02\x54\xf9\xc8\xc0\xca\x98\x0c\x48\xe4\x5f\xd3\xee\x7f\xcf\xbb\xff\xbc\xed\xcd\xec\xe8\xb8\xfb\x67\x98\xb4\x03\xff\xac\xf7\xe4\x87\xed\x60\x1b\x9c\xdd\xc8\x37\x3e\x4c\x6f\x92\xf6\x4d\x08\xb3\xe4\x0d\x4c\x18\xfc\x04\x67\x48\x85\xbb\x38\x09\xce\xa2\xd3\x86\x94\x0f\xf4\x8e\xa5\xb2\x21\xc5\x15\x02\x32\x6e\xe4\xd9\x00\xfe\x3c\xff\xfa\x5f\xde\x0d\x7c\x66\x6d\xc4\xbb\x09\x77\xd3\xa0\x1d\x78\xd1\x62\x75\x7a\x74\x34\x2f\xb3\x18\x0d\x24\x05\x15\x92\x19\x68\x7f\xa5\xed\x18\xd3\x15\xa4\xba\xa0\x6b\xb3\x3a\x51\x22\x20\x4f\x47\x98\x67\x29\x53\xe0\x41\xab\xc5\x93\x77\x7b\x9b\x01\xe9\xed\xad\xd7\x23\x35\xe7\xf6\x54\x93\xf2\x39\xf1\x5d\x08\xf2\xcd\x60\xa0\x7d\x39\xe7\x19\x4b\x2a\x40\xfc\x44\x6d\x26\x53\x9e\x29\x38\x34\x5d\x30\x37\xe9\x52\x08\xc6\x22\xeb\x11\x0f\xd2\x2c\xf3\xc0\xd7\x15\xe5\x7a\x89\x27\xc9\x5f\x51\x15\x2f\x41\x01\xd7\x2b\x21\x7b\x64\x71\x43\x60\xe0\xca\xd0\xca\xeb\x9d\x6b\xcd\x7d\xfd\x76\x36\x03\x04\x33\x3e\x99\x9d\xee\x08\xb7\x47\xf5\xb7\x60\xaa\x14\x99\xe5\x3b\x3d\xda\xee\x3b\x6d\x42\x21\xf3\x98\xaf\x1e\xd5\x07\xb0\xa9\x43\xc0\x36\x2c\x4e\x53\xbe\x62\x52\xc1\xde\xc4\x75\x5b\x53\x69\x93\x30\x46\x69\xcb\x1f\xd4\x7e\x33\xb4\xdf\x0c\x48\x56\xa6\xa9\x6b\x46\xc3\xff\x8d\xc0\x59\xa3\x3a\x3b\x93\x82\xd3\x06\x97\xda\x71\x8c\x32\x65\xc9\xbf\x9b\x05\xe4\xd7\x5f\x9f\xab\x5d\x73\x5a\x0f\x3c\x79\x26\xbc\x10\x67\x23\xbd\x43\xbc\x07\x9a\x96\x0c\x16\xae\x95\xac\x84\x7e\x3b\x9b\xd9\xe0\x37\xfc\x87\x46\x34\xbd\xb7\x84\x63\x96\xb2\x09\x5f\x95\xa9\x3e\x64\x57\x4c\x82\x02\xbe\xd0\x3f\xae\xd3\xee\x65\x9e\xc1\x2a\xa8\xfe\xd3\xe4\x72\x1c\x6a\xfd\x2b\x32\xc8\xe1\x8a\x6c\xa9\x56\x29\xd0\x78\xfd\x24\x1d\x7a\x46\x83\x3f\x86\x0c\xea\xa0\x6f\x01\x3a\x64\x27\xdb\xc7\x42\xc6\x84\xc9\x71\x33\xfe\x28\x17\xae\x93\x35\xda\x9b\x01\x69\xf5\x13\x35\x6c\x91\x37\xa4\xe6\x80\x49\xab\x1f\xe1\xf2\xe9\x21\xf2\x64\xd8\x87\xaa\x44\xe2\x14\x32\x78\xe0\xd1\x94\x09\x45\xf4\x77\x37\xc5\x2b\xc9\x73\xd0\x40\xa6\x01\x03\x86\x21\x40\x26\x15\xe4\xd6\x06\xae\x82\xf5\x60\xb3\xb6\xca\xf7\xfe\x90\x97\xaa\x28\x95\xf4\x82\x10\x49\x7c\xfc\x0a\x9a\xee\x65\x32\xa6\x05\xfb\x11\x77\x65\x7d\x76\x6d\x34\x00\xa2\x9f\xf0\x87\x21\xf0\x2b\xf6\xa8\x34\x85\x41\x0a\x0e\x05\x69\x0a\x17\x27\xb3\xf1\x51\x38\x96\x6e\x7c\x2a\xc7\x37\x9d\x6e\xe8\x5c\x9f\xf3\x0e\xd1\x8b\x87\xdd\xbc\xfc\x5e\x3b\xc6\x51\x5b\x47\x1b\x6f\x8e\x6c\xc1\xe7\x1b\x03\x18\x9a\xd4\x83\x63\xad\xfd\x86\x4c\x87\x82\xa0\xf4\x0d\x66\x43\x60\x26\xfa\xbb\x2b\x57\xde\x10\x1a\x08\x46\x21\x4a\x4a\xbc\xc0\xbc\x1c\x5e\x95\x29\xeb\x47\x30\xc0\xc9\x47\xcc\x6b\x96\xec\xe6\x53\x06\x27\x9e\xaa\x9a\xe0\x22\xcf\xd0\x8b\xbb\xf9\xcf\x54\x2d\xf5\xe4\x20\x7c\x04\x72\x71\xd7\xe8\x70\x97\x27\x1b\x97\xce\xf5\x5e\x28\x40\x8d\x86\x0b\xef\xa1\x22\xc3\xda\xb3\xa2\x56\x95\x16\xb8\x60\x07\x9a\x22\xac\xa6\x67\xc4\xdb\x30\xe9\x91\x9e\xeb\x59\xdf\x90\x70\x69\xa8\x24\x16\x80\xeb\x59\x10\xde\xe7\x3c\xf3\xbd\x9b\xcc\x0b\x9c\xc2\x51\x09\x28\xc0\xaa\x0a\x5d\x8f\xcf\x5c\xc8\xdd\x72\x00\xa2\xaa\x54\xa8\x3e\xfa\x1a\x40\x82\x44\xe4\x45\xd1\x2c\xfc\xd5\xc7\xc2\xb7\xfa\x6c\x35\xb4\x64\xfd\x08\xc6\xad\x26\xd4\x96\x40\xf8\x59\x8d\xc8\x84\xc8\xc5\xff\xc4\x93\x05\x34\x1c\x55\x2a\x40\x98\xba\x09\xdc\xfe\x4c\x78\xfb\xe9\xe6\xc2\xe9\xe4\x42\xc6\xd7\xc4\x0b\x76\xcf\x62\xf5\xba\x45\x15\x5d\x8f\x1c\x92\x5a\xa3\x68\xc1\xaf\x5b\x2d\x58\x41\xb1\x65\x7b\x51\x2c\x66\x1a\x41\xc1\x7e\x45\xfa\x92\x64\xb3\x6b\x43\x0f\x25\xdd\x1c\xad\xe0\xa0\x16\x8d\x99\x73\x60\x20\xa1\x55\xa2\xfd\x69\x40\xe1\x0b\xc6\x46\x02\x5c\xe6\xd0\x32\x96\x0c\xd2\xa5\x45\xfc\x6a\x16\xb4\x20\x4d\x5a\x2d\x6b\xb0\x4a\xf6\x45\x39\xe0\xa6\x9e\x6a\xf4\x2a\xa9\x9d\x9a\xf9\x1a\x6b\x9c\x27\xec\x60\xa4\x95\x3d\xc5\x98\xfb\x9e\x67\x15\xd1\xd4\x5f\xae\x4f\x6d\x21\xe4\x55\xf3\x3c\xec\x15\x2f\x97\xb0\xa3\x2f\xc3\x0e\x39\x09\xcc\x59\x09\xbe\xca\x1e\x13\xe6\xa6\xd2\xba\xb6\x38\xec\xdb\xe0\x70\x01\xd2\x45\x07\x7e\xb1\x24\x1e\x2e\x52\xc5\xf0\x9d\x69\x08\x8c\x1c\x6d\xac\xa9\x49\xb6\x51\xb8\xc5\xf5\x5b\x78\x2e\x30\xe8\xe6\xb0\xcc\xb4\xaa\xb1\x8e\x2c\x3e\x7d\x76\x0b\xda\xc0\x10\x2c\x74\x65\x61\x2e\x1b\xc4\x83\x47\xd8\xb9\x50\xf9\xc3\xa1\xcb\xf4\x85\x53\xdc\x80\x34\x17\x35\xdc\x73\xae\x4f\x0e\x59\xfb\xea\x7d\xfd\x4c\x02\x9a\x2f\xdd\x82\x59\x07\x71\xef\x0e\xff\xb2\xeb\xda\x5c\xb4\xef\x51\x6f\xff\x71\xb9\x73\x07\x32\x6b\x63\xba\x2b\xb9\xa8\xd8\xff\x5f\x9f\x00\x1e\x1c\x74\x59\xc0\x2b\x82\x4d\x21\x01\x5d\xc7\x04\x07\x5a\xda\x51\x06\xca\xfa\xee\x15\x0f\xdd\x29\x54\x32\x54\x86\xe3\x1e\x28\x02\x9d\x9f\x6f\x0d\xd4\xfd\x28\xf4\xae\xd8\xa1\x01\x61\x28\xe1\x25\xa6\xfc\xe8\x26\x8b\x1c\x82\x2c\x5f\x4f\x10\x22\x84\x01\x40\x47\xe4\xed\xf1\xf1\xb1\xd3\xbd\x49\xdd\x46\x23\xc4\xf5\xac\xe6\x92\x4c\xf0\xc6\xa2\xbd\x1e\xb5\xb8\xbd\xde\x02\xd7\x1a\x6d\x32\xcc\xb5\x44\x38\x84\x2b\xcd\x11\x34\xb3\xcf\x10\x0c\xb0\x73\xc1\x22\x80\x53\x38\xb1\x54\x28\xf9\x19\x9e\x97\x60\xab\xf7\xec\xf5\x60\xda\xa7\x43\xc9\x54\xdb\x50\xb5\xd8\xf6\x61\x90\xea\x57\x01\x9a\xbf\x27\xde\x52\x1f\x68\xf0\xf1\x63\xfd\x11\x16\xa5\x5c\x5a\x52\x87\xdf\xde\x07\x7b\x2c\xda\x59\x86\x63\xcf\xdc\x6d\x23\x23\x77\x0d\xbd\x15\x02\x0d\xbc\x1d\x41\xfd\x37\x28\xb8\xa4\x07\x5b\x9d\x1f\xee\xeb\xac\xcc\x4a\xc9\x92\xee\x03\xd8\xe8\xbc\xce\x76\xa1\x90\xd8\xd0\x7f\xc6\xf6\xb6\x91\x41\x75\x7c\xdd\x14\x0b\xed\x72\x1d\x71\x38\x40\x73\xfe\x68\x93\xcd\x4c\x9a\xd9\xb6\x7f\x22\xa0\x8c\xab\xcd\x6e\x33\xa4\xf7\xf4\xd1\xaf\xfd\x52\x8a\xb4\x57\x61\x42\x8d\xd2\x6d\xf7\xd9\x42\xd0\x62\x09\x83\xaa\x96\x75\x0d\xc1\x40\x1f\x9b\x0c\xcb\xe9\xa7\xab\xd1\x45\xbe\x82\x03\xc3\xe0\x9d\x64\x76\xb1\x56\x1b\xfe\x56\x67\x07\xaf\x36\x05\x83\xe5\x22\x97\xca\x59\x4d\xa8\xa2\x3d\xb2\x77\x03\x58\x4b\x83\x9a\x0c\x3b\x40\x86\x4e\x7c\xf2\x74\x07\x99\xa9\xee\x14\xf0\xc0\xf5\x2d\xfb\x6f\x18\x74\x68\x84\xaf\x97\xd6\xb6\x66\x93\x65\x0c\x8d\x35\xb0\x1d\x7e\x40\xd5\x84\xda\x4b\x3d\xb7\xb2\xec\xb2\xe0\x2b\x42\xaa\xdf\x00\x8d\x90\xea\x52\xb0\x17\x50\xfb\x7c\xc5\x49\xf8\x6a\x28\x0d\x59\x9c\x72\x30\xdd\x92\x99\xc9\xef\x8e\x78\x4b\x17\xe9\xaf\x0b\x94\xd6\xea\xf7\x87\x09\xb1\xa7\x46\xaa\xde\x7a\x31\x80\xce\xe3\xea\x4b\x63\xf7\x1b\x5f\x62\xea\xc2\x2d\x15\x00\x00")
 
 func staticJsApiJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -325,7 +325,7 @@ func staticJsApiJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "static/js/api.js", size: 2419, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "static/js/api.js", size: 5421, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1,6 +1,6 @@
 /**
  * Copyright 2017 Thibault Chataigner <thibault.chataigner@gmail.com>
- * Copyright 2024-2025 NetCracker Technology Corporation
+ * Copyright 2024-2026 NetCracker Technology Corporation
  *
  * Licensed under the Apache License, Version 2.0 (the "License");
  * you may not use this file except in compliance with the License.
@@ -51,25 +51,100 @@ function handleSimulationResult(result) {
     $("#outputs").html(html);
 }
 
-/*eslint no-unused-vars: "warn"*/
-function simulWrite() {
+function escapeHtml(str) {
+    return $("<div>").text(str).html();
+}
+
+function handleTraceResult(traces) {
+    let html = "";
+    $.each(traces, function (i, trace) {
+        html += '<h5>' + escapeHtml(JSON.stringify(trace.labels)) + '</h5>';
+        html += '<table class="table table-sm"><thead><tr>';
+        html += '<th>Rule</th><th>Matched</th><th>Template</th><th>Context</th><th>Path</th>';
+        html += '</tr></thead><tbody>';
+        $.each(trace.rules, function (j, rule) {
+            let matched = rule.matched ? "yes" : escapeHtml((rule.mismatches || []).join("\n"));
+            let path = rule.path ? escapeHtml(rule.path) : "";
+            if (rule.dropped) {
+                path = '<em>dropped</em>';
+            } else if (rule.error) {
+                path = '<span class="text-danger">' + escapeHtml(rule.error) + '</span>';
+            } else if (rule.rejected) {
+                path = '<em>rejected: ' + escapeHtml(rule.rejected) + '</em>';
+            } else if (rule.repaired) {
+                path += ' <em>(repaired: ' + escapeHtml(rule.repaired.join(", ")) + ')</em>';
+            }
+            html += '<tr><td>' + rule.rule + (rule.continue ? ' (continue)' : '') + '</td>';
+            html += '<td><pre>' + matched + '</pre></td>';
+            html += '<td><code>' + escapeHtml(rule.template || "") + '</code></td>';
+            html += '<td><pre>' + (rule.context ? escapeHtml(JSON.stringify(rule.context, null, 2)) : "") + '</pre></td>';
+            html += '<td><code>' + path + '</code></td></tr>';
+        });
+        html += '</tbody></table>';
+        html += '<p>Default path ' + (trace.default_path_appended ? 'appended' : 'not appended') + '.</p>';
+        if (trace.error) {
+            html += '<div class="alert alert-danger">' + escapeHtml(trace.error) + '</div>';
+        }
+        html += '<pre class="alert alert-light">' + escapeHtml(trace.paths.join("\n")) + '</pre>';
+    });
+    $("#outputs").html(html);
+}
+
+function handleError(xhr) {
+    $("#error-msg").html('<div class="alert alert-danger">' + escapeHtml(xhr.responseText) + '</div>');
+}
+
+function parseInput() {
     let txt = $("#input").val();
     let lines = txt.split(/\n/);
     let nowS = $.now() / 1000;
 
     let samples = [];
+    let series = [];
     $.each(lines, function (i, line) {
-        let sample = parseSample($.trim(line), nowS);
+        line = $.trim(line);
+        if (line === "" || line.startsWith("#")) {
+            return;
+        }
+        let sample = parseSample(line, nowS);
         if (sample != null) {
             samples.push(sample);
+        } else {
+            series.push(line);
         }
     });
+    return {"samples": samples, "series": series};
+}
+
+/*eslint no-unused-vars: "warn"*/
+function simulWrite() {
+    let samples = parseInput().samples;
+    let prefix = $("#prefix").val();
+    $("#error-msg").empty();
     $.ajax({
-        url: 'write',
+        url: prefix ? 'write?graphite.default-prefix=' + encodeURIComponent(prefix) : 'write',
         type: 'post',
         data: JSON.stringify(samples),
         headers: {"Content-Type": 'application/json'},
-        success: handleSimulationResult
+        success: handleSimulationResult,
+        error: handleError
+    });
+}
+
+/*eslint no-unused-vars: "warn"*/
+function simulTrace() {
+    let input = parseInput();
+    input.prefix = $("#prefix").val();
+    input.client = $("#client").val();
+    $("#error-msg").empty();
+    $.ajax({
+        url: 'trace',
+        type: 'post',
+        data: JSON.stringify(input),
+        headers: {"Content-Type": 'application/json'},
+        dataType: 'json',
+        success: handleTraceResult,
+        error: handleError
     });
 }
 
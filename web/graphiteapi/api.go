@@ -0,0 +1,403 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package graphiteapi serves the Graphite render and find APIs from a
+// Prometheus remote read endpoint, mapping series to Graphite paths the same
+// way they are written by the adapter.
+package graphiteapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Reader reads series from a Prometheus remote read endpoint.
+type Reader interface {
+	Read(ctx context.Context, query *prompb.Query) (*prompb.QueryResult, error)
+}
+
+// API serves /render and /metrics/find.
+type API struct {
+	reader Reader
+	step   time.Duration
+	cfg    *graphiteCfg.Config
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewAPI returns a new API. Series are read with reader and consolidated
+// with the given step. cfg selects the prefix and tag escaping of the paths.
+func NewAPI(reader Reader, step time.Duration, cfg *graphiteCfg.Config, logger *slog.Logger) *API {
+	if step < time.Second {
+		step = time.Minute
+	}
+	return &API{reader: reader, step: step, cfg: cfg, logger: logger, now: time.Now}
+}
+
+// evalContext holds the parameters of the evaluation of a render request.
+type evalContext struct {
+	ctx         context.Context
+	reader      Reader
+	from, until int64
+	step        int64
+	prefix      string
+	format      paths.Format
+}
+
+// eval returns the series of an expression.
+func (ec *evalContext) eval(e *expr) ([]*series, error) {
+	switch e.typ {
+	case exprPath:
+		return ec.fetchPath(e.str)
+	case exprCall:
+		if e.str == "seriesByTag" {
+			return ec.fetchTagged(e)
+		}
+		fn, ok := functions[e.str]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", e.str)
+		}
+		return fn(ec, e)
+	default:
+		return nil, fmt.Errorf("expected a series list, got %s", e.raw)
+	}
+}
+
+func (ec *evalContext) read(matchers []*prompb.LabelMatcher) ([]*prompb.TimeSeries, error) {
+	result, err := ec.reader.Read(ec.ctx, &prompb.Query{
+		StartTimestampMs: ec.from * 1000,
+		EndTimestampMs:   ec.until * 1000,
+		Matchers:         matchers,
+		Hints: &prompb.ReadHints{
+			StepMs:  ec.step * 1000,
+			StartMs: ec.from * 1000,
+			EndMs:   ec.until * 1000,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Timeseries, nil
+}
+
+func (ec *evalContext) fetchPath(glob string) ([]*series, error) {
+	re, err := regexp.Compile("^" + globToRegexp(glob) + "$")
+	if err != nil {
+		return nil, err
+	}
+	matchers, err := pathMatchers(glob, ec.prefix)
+	if err != nil {
+		return nil, err
+	}
+	timeseries, err := ec.read(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*series
+	for _, ts := range timeseries {
+		path := paths.DefaultPath(toMetric(ts.Labels), paths.FormatCarbon, ec.prefix, nil, nil)
+		if !re.MatchString(path) {
+			continue
+		}
+		list = append(list, ec.newSeries(path, map[string]string{"name": path}, ts.Samples))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list, nil
+}
+
+func (ec *evalContext) fetchTagged(e *expr) ([]*series, error) {
+	var exprs []*tagExpr
+	for _, arg := range e.args {
+		if arg.typ != exprString {
+			return nil, fmt.Errorf("seriesByTag expects tag expressions, got %s", arg.raw)
+		}
+		te, err := parseTagExpr(arg.str)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, te)
+	}
+	if len(exprs) == 0 {
+		return nil, fmt.Errorf("seriesByTag expects at least one tag expression")
+	}
+
+	timeseries, err := ec.read(tagMatchers(exprs, ec.prefix, ec.format))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*series
+	for _, ts := range timeseries {
+		m := toMetric(ts.Labels)
+		tags := seriesTags(m, ec.prefix, ec.format)
+		match := true
+		for _, te := range exprs {
+			match = match && te.matches(tags)
+		}
+		if !match {
+			continue
+		}
+		path := paths.DefaultPath(m, ec.format, ec.prefix, nil, nil)
+		list = append(list, ec.newSeries(path, tags, ts.Samples))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list, nil
+}
+
+// newSeries consolidates samples on the steps of the request, keeping the
+// last sample of each step.
+func (ec *evalContext) newSeries(name string, tags map[string]string, samples []prompb.Sample) *series {
+	start := ec.from - ec.from%ec.step
+	n := (ec.until-start)/ec.step + 1
+	s := &series{name: name, tags: tags, start: start, step: ec.step, values: make([]float64, n)}
+	for i := range s.values {
+		s.values[i] = math.NaN()
+	}
+	for _, sample := range samples {
+		i := (sample.Timestamp/1000 - start) / ec.step
+		if i >= 0 && i < n {
+			s.values[i] = sample.Value
+		}
+	}
+	return s
+}
+
+type renderResponse struct {
+	Target     string            `json:"target"`
+	Tags       map[string]string `json:"tags"`
+	Datapoints [][2]interface{}  `json:"datapoints"`
+}
+
+// Render serves /render in the json format.
+func (api *API) Render(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format := r.Form.Get("format"); format != "" && format != "json" {
+		http.Error(w, fmt.Sprintf("unsupported format %q, only json is supported", format), http.StatusBadRequest)
+		return
+	}
+	ec, err := api.evalContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := []renderResponse{}
+	for _, target := range r.Form["target"] {
+		e, err := parseTarget(target)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		list, err := ec.eval(e)
+		if err != nil {
+			api.logger.Warn("Error evaluating target", "target", target, "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, s := range list {
+			datapoints := make([][2]interface{}, len(s.values))
+			for i, v := range s.values {
+				ts := s.start + int64(i)*s.step
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					datapoints[i] = [2]interface{}{nil, ts}
+				} else {
+					datapoints[i] = [2]interface{}{v, ts}
+				}
+			}
+			resp = append(resp, renderResponse{Target: s.name, Tags: s.tags, Datapoints: datapoints})
+		}
+	}
+	api.respond(w, resp)
+}
+
+type findNode struct {
+	Text          string `json:"text"`
+	ID            string `json:"id"`
+	Leaf          int    `json:"leaf"`
+	Expandable    int    `json:"expandable"`
+	AllowChildren int    `json:"allowChildren"`
+}
+
+// Find serves /metrics/find in the treejson format. The series are listed
+// from the remote read endpoint over the time range of the request, the
+// last day by default.
+func (api *API) Find(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.Form.Get("query")
+	if query == "" {
+		http.Error(w, "missing parameter \"query\"", http.StatusBadRequest)
+		return
+	}
+	ec, err := api.evalContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	re, err := regexp.Compile("^" + globToRegexp(query) + "$")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matchers, err := pathMatchers(query, ec.prefix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	timeseries, err := ec.read(matchers)
+	if err != nil {
+		api.logger.Warn("Error finding metrics", "query", query, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	depth := len(splitNodes(query))
+	nodes := make(map[string]*findNode)
+	for _, ts := range timeseries {
+		pathNodes := strings.Split(paths.DefaultPath(toMetric(ts.Labels), paths.FormatCarbon, ec.prefix, nil, nil), ".")
+		if len(pathNodes) < depth {
+			continue
+		}
+		id := strings.Join(pathNodes[:depth], ".")
+		if !re.MatchString(id) {
+			continue
+		}
+		node, ok := nodes[id]
+		if !ok {
+			node = &findNode{Text: pathNodes[depth-1], ID: id}
+			nodes[id] = node
+		}
+		if len(pathNodes) == depth {
+			node.Leaf = 1
+		} else {
+			node.Expandable = 1
+			node.AllowChildren = 1
+		}
+	}
+
+	resp := make([]*findNode, 0, len(nodes))
+	for _, node := range nodes {
+		resp = append(resp, node)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].ID < resp[j].ID })
+	api.respond(w, resp)
+}
+
+func (api *API) evalContext(r *http.Request) (*evalContext, error) {
+	now := api.now()
+	from, err := parseTime(r.Form.Get("from"), now, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	until, err := parseTime(r.Form.Get("until"), now, now)
+	if err != nil {
+		return nil, err
+	}
+	if until < from {
+		return nil, fmt.Errorf("until must not be before from")
+	}
+
+	prefix := api.cfg.StoragePrefixFromRequest(r)
+	format := paths.FormatCarbonTags
+	if api.cfg.TagEscapingForPrefix(prefix) == graphiteCfg.TagEscapingPercent {
+		format = paths.FormatCarbonTagsPercent
+	}
+	return &evalContext{
+		ctx:    r.Context(),
+		reader: api.reader,
+		from:   from,
+		until:  until,
+		step:   int64(api.step / time.Second),
+		prefix: prefix,
+		format: format,
+	}, nil
+}
+
+func (api *API) respond(w http.ResponseWriter, resp interface{}) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(b); err != nil {
+		api.logger.Error("Error writing response", "err", err)
+	}
+}
+
+var relativeUnits = []struct {
+	suffix  string
+	seconds int64
+}{
+	// Longer suffixes first, "min" and "mon" must not be read as "m".
+	{"months", 30 * 86400}, {"month", 30 * 86400}, {"mon", 30 * 86400},
+	{"minutes", 60}, {"minute", 60}, {"min", 60},
+	{"seconds", 1}, {"second", 1}, {"sec", 1}, {"s", 1},
+	{"hours", 3600}, {"hour", 3600}, {"h", 3600},
+	{"days", 86400}, {"day", 86400}, {"d", 86400},
+	{"weeks", 7 * 86400}, {"week", 7 * 86400}, {"w", 7 * 86400},
+	{"years", 365 * 86400}, {"year", 365 * 86400}, {"y", 365 * 86400},
+}
+
+// parseTime parses the from and until parameters: "now", unix timestamps
+// and relative times like "-1h".
+func parseTime(s string, now time.Time, defaultTime time.Time) (int64, error) {
+	switch {
+	case s == "":
+		return defaultTime.Unix(), nil
+	case s == "now":
+		return now.Unix(), nil
+	case strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+"):
+		for _, unit := range relativeUnits {
+			if !strings.HasSuffix(s, unit.suffix) {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSuffix(s[1:], unit.suffix), 10, 64)
+			if err != nil {
+				break
+			}
+			if s[0] == '-' {
+				n = -n
+			}
+			return now.Unix() + n*unit.seconds, nil
+		}
+	default:
+		if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return ts, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot parse time %q", s)
+}
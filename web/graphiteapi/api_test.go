@@ -0,0 +1,229 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphiteapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReader struct {
+	series  []*prompb.TimeSeries
+	queries []*prompb.Query
+}
+
+func (r *fakeReader) Read(ctx context.Context, query *prompb.Query) (*prompb.QueryResult, error) {
+	r.queries = append(r.queries, query)
+	matchers, err := remote.FromLabelMatchers(query.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	result := &prompb.QueryResult{}
+	for _, ts := range r.series {
+		match := true
+		for _, m := range matchers {
+			match = match && m.Matches(labelValue(ts.Labels, m.Name))
+		}
+		if match {
+			result.Timeseries = append(result.Timeseries, ts)
+		}
+	}
+	return result, nil
+}
+
+func labelValue(labels []prompb.Label, name string) string {
+	for _, l := range labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+func newTestAPI(reader Reader) *API {
+	cfg := graphiteCfg.DefaultConfig
+	cfg.DefaultPrefix = "prom."
+	api := NewAPI(reader, time.Minute, &cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	api.now = func() time.Time { return time.Unix(600, 0) }
+	return api
+}
+
+func newFakeReader() *fakeReader {
+	return &fakeReader{series: []*prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "host", Value: "a"}},
+			Samples: []prompb.Sample{{Timestamp: 0, Value: 0}, {Timestamp: 60000, Value: 60}, {Timestamp: 120000, Value: 180}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "requests"}, {Name: "host", Value: "b"}},
+			Samples: []prompb.Sample{{Timestamp: 0, Value: 10}, {Timestamp: 120000, Value: 20}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "errors"}, {Name: "host", Value: "a"}},
+			Samples: []prompb.Sample{{Timestamp: 60000, Value: 1}},
+		},
+	}}
+}
+
+func render(t *testing.T, api *API, targets ...string) []renderResponse {
+	form := url.Values{"target": targets, "from": {"0"}, "until": {"120"}, "format": {"json"}}
+	w := httptest.NewRecorder()
+	api.Render(w, httptest.NewRequest(http.MethodGet, "/render?"+form.Encode(), nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp []renderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestRenderPaths(t *testing.T) {
+	reader := newFakeReader()
+	resp := render(t, newTestAPI(reader), "prom.requests.host.*")
+
+	require.Len(t, resp, 2)
+	assert.Equal(t, "prom.requests.host.a", resp[0].Target)
+	assert.Equal(t, map[string]string{"name": "prom.requests.host.a"}, resp[0].Tags)
+	assert.Equal(t, [][2]interface{}{{0.0, 0.0}, {60.0, 60.0}, {180.0, 120.0}}, resp[0].Datapoints)
+	assert.Equal(t, [][2]interface{}{{10.0, 0.0}, {nil, 60.0}, {20.0, 120.0}}, resp[1].Datapoints)
+
+	require.Len(t, reader.queries, 1)
+	assert.Equal(t, []*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "requests"},
+		{Type: prompb.LabelMatcher_RE, Name: "host", Value: "[^.]*"},
+	}, reader.queries[0].Matchers)
+	assert.Equal(t, int64(0), reader.queries[0].StartTimestampMs)
+	assert.Equal(t, int64(120000), reader.queries[0].EndTimestampMs)
+}
+
+func TestRenderFunctions(t *testing.T) {
+	api := newTestAPI(newFakeReader())
+
+	resp := render(t, api, "sumSeries(prom.requests.host.*)")
+	require.Len(t, resp, 1)
+	assert.Equal(t, "sumSeries(prom.requests.host.*)", resp[0].Target)
+	assert.Equal(t, [][2]interface{}{{10.0, 0.0}, {60.0, 60.0}, {200.0, 120.0}}, resp[0].Datapoints)
+
+	resp = render(t, api, "alias(scale(perSecond(prom.requests.host.a), 60), 'rpm')")
+	require.Len(t, resp, 1)
+	assert.Equal(t, "rpm", resp[0].Target)
+	assert.Equal(t, [][2]interface{}{{nil, 0.0}, {60.0, 60.0}, {120.0, 120.0}}, resp[0].Datapoints)
+
+	resp = render(t, api, "prom.errors.host.a", "prom.requests.host.b")
+	require.Len(t, resp, 2)
+	assert.Equal(t, "prom.errors.host.a", resp[0].Target)
+	assert.Equal(t, "prom.requests.host.b", resp[1].Target)
+}
+
+func TestRenderSeriesByTag(t *testing.T) {
+	reader := newFakeReader()
+	resp := render(t, newTestAPI(reader), "seriesByTag('name=prom.requests', 'host=~b')")
+
+	require.Len(t, resp, 1)
+	assert.Equal(t, "prom.requests;host=b", resp[0].Target)
+	assert.Equal(t, map[string]string{"name": "prom.requests", "host": "b"}, resp[0].Tags)
+	assert.Equal(t, []*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "requests"},
+		{Type: prompb.LabelMatcher_NEQ, Name: "host", Value: ""},
+	}, reader.queries[0].Matchers)
+}
+
+func TestRenderErrors(t *testing.T) {
+	api := newTestAPI(newFakeReader())
+	for _, query := range []string{
+		"target=unknown(foo)",
+		"target=sum(foo",
+		"target=foo&format=png",
+		"target=foo&from=yesterday",
+		"target=seriesByTag(foo)",
+	} {
+		w := httptest.NewRecorder()
+		api.Render(w, httptest.NewRequest(http.MethodGet, "/render?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestFind(t *testing.T) {
+	api := newTestAPI(newFakeReader())
+	find := func(query string) []findNode {
+		w := httptest.NewRecorder()
+		api.Find(w, httptest.NewRequest(http.MethodGet, "/metrics/find?"+url.Values{"query": {query}}.Encode(), nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp []findNode
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.Equal(t, []findNode{
+		{Text: "requests", ID: "prom.requests", Expandable: 1, AllowChildren: 1},
+	}, find("prom.requests"))
+	assert.Equal(t, []findNode{
+		{Text: "host", ID: "prom.requests.host", Expandable: 1, AllowChildren: 1},
+	}, find("prom.requests.*"))
+	assert.Equal(t, []findNode{
+		{Text: "a", ID: "prom.requests.host.a", Leaf: 1},
+		{Text: "b", ID: "prom.requests.host.b", Leaf: 1},
+	}, find("prom.requests.host.*"))
+}
+
+func TestGlobsWithoutMetricName(t *testing.T) {
+	reader := newFakeReader()
+	api := newTestAPI(reader)
+	for _, glob := range []string{"*", "prom.*", "prom.req*.host.a", "prom.{errors,requests}", "other.requests"} {
+		w := httptest.NewRecorder()
+		api.Find(w, httptest.NewRequest(http.MethodGet, "/metrics/find?"+url.Values{"query": {glob}}.Encode(), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, glob)
+
+		w = httptest.NewRecorder()
+		api.Render(w, httptest.NewRequest(http.MethodGet, "/render?"+url.Values{"target": {"sum(" + glob + ")"}}.Encode(), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, glob)
+	}
+	assert.Empty(t, reader.queries)
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Unix(100000, 0)
+	for s, expected := range map[string]int64{
+		"":       42,
+		"now":    100000,
+		"12345":  12345,
+		"-1h":    100000 - 3600,
+		"-5min":  100000 - 300,
+		"-2d":    100000 - 2*86400,
+		"-1mon":  100000 - 30*86400,
+		"+30s":   100030,
+		"-1week": 100000 - 7*86400,
+	} {
+		ts, err := parseTime(s, now, time.Unix(42, 0))
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, ts, s)
+	}
+	_, err := parseTime("-1fortnight", now, now)
+	assert.Error(t, err)
+}
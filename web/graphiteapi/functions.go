@@ -0,0 +1,164 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphiteapi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// series is a Graphite series: values at a fixed step, NaN for missing points.
+type series struct {
+	name   string
+	tags   map[string]string
+	start  int64
+	step   int64
+	values []float64
+}
+
+func (s *series) withName(name string) *series {
+	return &series{name: name, tags: map[string]string{"name": name}, start: s.start, step: s.step, values: s.values}
+}
+
+type function func(ec *evalContext, e *expr) ([]*series, error)
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"alias":     alias,
+		"perSecond": perSecond,
+		"scale":     scale,
+		"sum":       sumSeries,
+		"sumSeries": sumSeries,
+	}
+}
+
+// sumSeries adds series together, ignoring missing points.
+func sumSeries(ec *evalContext, e *expr) ([]*series, error) {
+	var list []*series
+	raws := make([]string, 0, len(e.args))
+	for _, arg := range e.args {
+		l, err := ec.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l...)
+		raws = append(raws, arg.raw)
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	name := fmt.Sprintf("sumSeries(%s)", strings.Join(raws, ","))
+	sum := list[0].withName(name)
+	sum.values = make([]float64, len(list[0].values))
+	for i := range sum.values {
+		sum.values[i] = math.NaN()
+		for _, s := range list {
+			if i >= len(s.values) || math.IsNaN(s.values[i]) {
+				continue
+			}
+			if math.IsNaN(sum.values[i]) {
+				sum.values[i] = 0
+			}
+			sum.values[i] += s.values[i]
+		}
+	}
+	return []*series{sum}, nil
+}
+
+// scale multiplies each point by a factor.
+func scale(ec *evalContext, e *expr) ([]*series, error) {
+	if len(e.args) != 2 || e.args[1].typ != exprNumber {
+		return nil, fmt.Errorf("scale expects a series list and a factor")
+	}
+	list, err := ec.eval(e.args[0])
+	if err != nil {
+		return nil, err
+	}
+	factor := e.args[1].num
+
+	result := make([]*series, 0, len(list))
+	for _, s := range list {
+		scaled := s.withName(fmt.Sprintf("scale(%s,%s)", s.name, strconv.FormatFloat(factor, 'g', -1, 64)))
+		scaled.values = make([]float64, len(s.values))
+		for i, v := range s.values {
+			scaled.values[i] = v * factor
+		}
+		result = append(result, scaled)
+	}
+	return result, nil
+}
+
+// alias renames the series.
+func alias(ec *evalContext, e *expr) ([]*series, error) {
+	if len(e.args) != 2 || e.args[1].typ != exprString {
+		return nil, fmt.Errorf("alias expects a series list and a name")
+	}
+	list, err := ec.eval(e.args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*series, 0, len(list))
+	for _, s := range list {
+		result = append(result, s.withName(e.args[1].str))
+	}
+	return result, nil
+}
+
+// perSecond returns the per second rate of counters. With a maxValue,
+// counters wrapping around are accounted for, other decreases are missing points.
+func perSecond(ec *evalContext, e *expr) ([]*series, error) {
+	if len(e.args) < 1 || len(e.args) > 2 || (len(e.args) == 2 && e.args[1].typ != exprNumber) {
+		return nil, fmt.Errorf("perSecond expects a series list and an optional maxValue")
+	}
+	list, err := ec.eval(e.args[0])
+	if err != nil {
+		return nil, err
+	}
+	maxValue := math.NaN()
+	if len(e.args) == 2 {
+		maxValue = e.args[1].num
+	}
+
+	result := make([]*series, 0, len(list))
+	for _, s := range list {
+		rate := s.withName(fmt.Sprintf("perSecond(%s)", s.name))
+		rate.values = make([]float64, len(s.values))
+		prev := math.NaN()
+		step := float64(s.step)
+		for i, v := range s.values {
+			diff := v - prev
+			switch {
+			case math.IsNaN(diff):
+				rate.values[i] = math.NaN()
+			case diff >= 0:
+				rate.values[i] = diff / step
+			case !math.IsNaN(maxValue) && maxValue >= v:
+				rate.values[i] = (maxValue - prev + v + 1) / step
+			default:
+				rate.values[i] = math.NaN()
+			}
+			prev = v
+		}
+		result = append(result, rate)
+	}
+	return result, nil
+}
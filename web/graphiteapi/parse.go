@@ -0,0 +1,174 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphiteapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type exprType int
+
+const (
+	exprPath exprType = iota
+	exprCall
+	exprString
+	exprNumber
+)
+
+// expr is a parsed Graphite target.
+type expr struct {
+	typ exprType
+	// str is the path, the function name or the string value.
+	str  string
+	num  float64
+	args []*expr
+	// raw is the text of the expression, used to name series.
+	raw string
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseTarget parses a Graphite target such as
+// alias(sumSeries(foo.*.bar), 'total').
+func parseTarget(target string) (*expr, error) {
+	p := &parser{input: target}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d of %q", p.input[p.pos:], p.pos, target)
+	}
+	return e, nil
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) parseExpr() (*expr, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of target %q", p.input)
+	}
+	start := p.pos
+
+	switch c := p.input[p.pos]; {
+	case c == '\'' || c == '"':
+		return p.parseString()
+	case c == '-' || c == '+' || (c >= '0' && c <= '9'):
+		// Paths may start with digits, only whole arguments are numbers.
+		end := p.argEnd()
+		if n, err := strconv.ParseFloat(strings.TrimSpace(p.input[start:end]), 64); err == nil {
+			p.pos = end
+			return &expr{typ: exprNumber, num: n, raw: strings.TrimSpace(p.input[start:end])}, nil
+		}
+	}
+
+	// A function name is followed by a parenthesis.
+	name := p.pos
+	for name < len(p.input) && isIdentChar(p.input[name]) {
+		name++
+	}
+	if name > p.pos && name < len(p.input) && p.input[name] == '(' {
+		return p.parseCall(p.input[p.pos:name])
+	}
+
+	end := p.argEnd()
+	path := strings.TrimSpace(p.input[start:end])
+	if path == "" {
+		return nil, fmt.Errorf("empty path at position %d of %q", start, p.input)
+	}
+	p.pos = end
+	return &expr{typ: exprPath, str: path, raw: path}, nil
+}
+
+func (p *parser) parseCall(name string) (*expr, error) {
+	start := p.pos
+	p.pos += len(name) + 1
+	e := &expr{typ: exprCall, str: name}
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.input) && p.input[p.pos] == ')' && len(e.args) == 0 {
+			p.pos++
+			break
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		e.args = append(e.args, arg)
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("missing ')' in %q", p.input)
+		}
+		if p.input[p.pos] == ')' {
+			p.pos++
+			break
+		}
+		if p.input[p.pos] != ',' {
+			return nil, fmt.Errorf("unexpected %q at position %d of %q", p.input[p.pos], p.pos, p.input)
+		}
+		p.pos++
+	}
+	e.raw = p.input[start:p.pos]
+	return e, nil
+}
+
+func (p *parser) parseString() (*expr, error) {
+	quote := p.input[p.pos]
+	end := strings.IndexByte(p.input[p.pos+1:], quote)
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated string in %q", p.input)
+	}
+	s := p.input[p.pos+1 : p.pos+1+end]
+	raw := p.input[p.pos : p.pos+end+2]
+	p.pos += end + 2
+	return &expr{typ: exprString, str: s, raw: raw}, nil
+}
+
+// argEnd returns the end of the argument at the current position: the next
+// ',' or ')' outside of braces and brackets.
+func (p *parser) argEnd() int {
+	depth := 0
+	for i := p.pos; i < len(p.input); i++ {
+		switch p.input[i] {
+		case '\\':
+			i++
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',', ')':
+			if depth <= 0 {
+				return i
+			}
+		}
+	}
+	return len(p.input)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,65 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphiteapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	e, err := parseTarget("alias(sumSeries(foo.{a,b}.bar, 1h.x), 'total')")
+	require.NoError(t, err)
+	assert.Equal(t, exprCall, e.typ)
+	assert.Equal(t, "alias", e.str)
+	require.Len(t, e.args, 2)
+
+	sum := e.args[0]
+	assert.Equal(t, "sumSeries", sum.str)
+	assert.Equal(t, "sumSeries(foo.{a,b}.bar, 1h.x)", sum.raw)
+	require.Len(t, sum.args, 2)
+	assert.Equal(t, &expr{typ: exprPath, str: "foo.{a,b}.bar", raw: "foo.{a,b}.bar"}, sum.args[0])
+	assert.Equal(t, &expr{typ: exprPath, str: "1h.x", raw: "1h.x"}, sum.args[1])
+
+	assert.Equal(t, &expr{typ: exprString, str: "total", raw: "'total'"}, e.args[1])
+
+	e, err = parseTarget("scale(foo, -0.5)")
+	require.NoError(t, err)
+	assert.Equal(t, &expr{typ: exprNumber, num: -0.5, raw: "-0.5"}, e.args[1])
+}
+
+func TestParseTargetErrors(t *testing.T) {
+	for _, target := range []string{"", "sum(foo", "alias(foo, 'bar", "foo) bar", "sum(foo bar"} {
+		_, err := parseTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	for glob, re := range map[string]string{
+		"foo.*.bar":   `foo\.[^.]*\.bar`,
+		"foo.{a,b}":   `foo\.(?:a|b)`,
+		"foo.b?r":     `foo\.b[^.]r`,
+		"foo.[!ab]x":  `foo\.[^ab]x`,
+		`foo\.bar.x`:  `foo\.bar\.x`,
+		"foo.[a-z]":   `foo\.[a-z]`,
+		"prefix.foo*": `prefix\.foo[^.]*`,
+	} {
+		assert.Equal(t, re, globToRegexp(glob), glob)
+	}
+}
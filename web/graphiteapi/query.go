@@ -0,0 +1,267 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package graphiteapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	graphite_tmpl "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/template"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// anyName selects every series, when no tag expression narrower can be sent
+// upstream.
+var anyName = &prompb.LabelMatcher{Type: prompb.LabelMatcher_RE, Name: model.MetricNameLabel, Value: ".+"}
+
+// splitNodes splits a path glob on the dots outside of braces and brackets.
+func splitNodes(glob string) []string {
+	var nodes []string
+	depth, start := 0, 0
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				nodes = append(nodes, glob[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(nodes, glob[start:])
+}
+
+// isGlob reports whether a node has wildcards.
+func isGlob(node string) bool {
+	for i := 0; i < len(node); i++ {
+		switch node[i] {
+		case '\\':
+			i++
+		case '*', '?', '[', '{':
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp translates a Graphite glob to a regular expression, without
+// anchors. Wildcards never match dots.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	inBraces := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '*':
+			sb.WriteString(`[^.]*`)
+		case c == '?':
+			sb.WriteString(`[^.]`)
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			inBraces = true
+			sb.WriteString("(?:")
+		case c == '}' && inBraces:
+			inBraces = false
+			sb.WriteString(")")
+		case c == ',' && inBraces:
+			sb.WriteString("|")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// nodeMatcher returns a matcher on a label for a path node, or nil if the
+// node can't be translated to the raw label value.
+func nodeMatcher(name, node string) *prompb.LabelMatcher {
+	if !isGlob(node) {
+		return &prompb.LabelMatcher{Type: prompb.LabelMatcher_EQ, Name: name, Value: graphite_tmpl.Unescape(node)}
+	}
+	// Escaped characters can't be matched against raw values.
+	if strings.ContainsAny(node, `%\`) {
+		return nil
+	}
+	return &prompb.LabelMatcher{Type: prompb.LabelMatcher_RE, Name: name, Value: globToRegexp(node)}
+}
+
+// pathMatchers returns the matchers of the series whose default path may
+// match the glob, or start with it. The glob must start with the prefix and
+// a metric name without wildcards, not to read every series upstream.
+func pathMatchers(glob, prefix string) ([]*prompb.LabelMatcher, error) {
+	if !strings.HasPrefix(glob, prefix) {
+		return nil, fmt.Errorf("path %q doesn't start with the prefix %q", glob, prefix)
+	}
+	nodes := splitNodes(strings.TrimPrefix(glob, prefix))
+	if isGlob(nodes[0]) || nodes[0] == "" {
+		return nil, fmt.Errorf("path %q doesn't have a metric name without wildcards after the prefix %q", glob, prefix)
+	}
+
+	matchers := []*prompb.LabelMatcher{nodeMatcher(model.MetricNameLabel, nodes[0])}
+	for i := 1; i < len(nodes); i += 2 {
+		if isGlob(nodes[i]) {
+			continue
+		}
+		name := graphite_tmpl.Unescape(nodes[i])
+		var m *prompb.LabelMatcher
+		if i+1 < len(nodes) {
+			m = nodeMatcher(name, nodes[i+1])
+		}
+		if m == nil || m.Value == "" {
+			// The label has to exist.
+			m = &prompb.LabelMatcher{Type: prompb.LabelMatcher_NEQ, Name: name, Value: ""}
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// tagExpr is a parsed argument of seriesByTag.
+type tagExpr struct {
+	tag   string
+	op    prompb.LabelMatcher_Type
+	value string
+	re    *regexp.Regexp
+}
+
+func parseTagExpr(s string) (*tagExpr, error) {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid tag expression %q", s)
+	}
+	te := &tagExpr{tag: s[:i], op: prompb.LabelMatcher_EQ, value: s[i+1:]}
+	if strings.HasSuffix(te.tag, "!") {
+		te.tag = strings.TrimSuffix(te.tag, "!")
+		te.op = prompb.LabelMatcher_NEQ
+	}
+	if strings.HasPrefix(te.value, "~") {
+		te.value = te.value[1:]
+		if te.op == prompb.LabelMatcher_EQ {
+			te.op = prompb.LabelMatcher_RE
+		} else {
+			te.op = prompb.LabelMatcher_NRE
+		}
+		// Graphite only anchors regular expressions at the start.
+		re, err := regexp.Compile("^(?:" + strings.TrimPrefix(te.value, "^") + ")")
+		if err != nil {
+			return nil, err
+		}
+		te.re = re
+	}
+	return te, nil
+}
+
+// matches evaluates the expression on the tags of a series, absent tags being empty.
+func (te *tagExpr) matches(tags map[string]string) bool {
+	v := tags[te.tag]
+	switch te.op {
+	case prompb.LabelMatcher_EQ:
+		return v == te.value
+	case prompb.LabelMatcher_NEQ:
+		return v != te.value
+	case prompb.LabelMatcher_RE:
+		return te.re.MatchString(v)
+	default:
+		return !te.re.MatchString(v)
+	}
+}
+
+// tagMatchers returns the matchers of the series which may match all the tag
+// expressions. Expressions which can't be translated exactly are left to
+// the filtering of the results.
+func tagMatchers(exprs []*tagExpr, prefix string, format paths.Format) []*prompb.LabelMatcher {
+	var matchers []*prompb.LabelMatcher
+	hasName := false
+	for _, te := range exprs {
+		name := model.MetricNameLabel
+		value := te.value
+		if te.tag == "name" {
+			if !strings.HasPrefix(value, prefix) {
+				continue
+			}
+			value = graphite_tmpl.Unescape(strings.TrimPrefix(value, prefix))
+			if string(graphite_tmpl.Escape(value)) != strings.TrimPrefix(te.value, prefix) {
+				continue
+			}
+		} else {
+			name = paths.UnescapeTagValue(te.tag, format)
+			if paths.EscapeTagValue(name, format) != te.tag {
+				continue
+			}
+			value = paths.UnescapeTagValue(value, format)
+			if paths.EscapeTagValue(value, format) != te.value {
+				value = ""
+			}
+		}
+
+		switch {
+		case te.op == prompb.LabelMatcher_EQ && value != "":
+			matchers = append(matchers, &prompb.LabelMatcher{Type: prompb.LabelMatcher_EQ, Name: name, Value: value})
+			hasName = hasName || name == model.MetricNameLabel
+		case te.op == prompb.LabelMatcher_EQ && te.value != "",
+			te.op == prompb.LabelMatcher_RE && !te.re.MatchString(""):
+			matchers = append(matchers, &prompb.LabelMatcher{Type: prompb.LabelMatcher_NEQ, Name: name, Value: ""})
+			hasName = hasName || name == model.MetricNameLabel
+		}
+	}
+	if !hasName {
+		matchers = append(matchers, anyName)
+	}
+	return matchers
+}
+
+// seriesTags returns the Graphite tags of a series.
+func seriesTags(m model.Metric, prefix string, format paths.Format) map[string]string {
+	tags := make(map[string]string, len(m))
+	for k, v := range m {
+		if k == model.MetricNameLabel {
+			tags["name"] = prefix + string(graphite_tmpl.Escape(string(v)))
+		} else {
+			tags[paths.EscapeTagValue(string(k), format)] = paths.EscapeTagValue(string(v), format)
+		}
+	}
+	return tags
+}
+
+func toMetric(labels []prompb.Label) model.Metric {
+	m := make(model.Metric, len(labels))
+	for _, l := range labels {
+		m[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return m
+}
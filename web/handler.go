@@ -26,9 +26,13 @@ import (
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/whisper"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/ui"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/utils/template"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/web/graphiteapi"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/web/promapi"
 	"github.com/davecgh/go-spew/spew"
 	assetfs "github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
@@ -81,8 +85,7 @@ type Handler struct {
 	router   *mux.Router
 	reloadCh chan chan error
 
-	writers []client.Writer
-	readers []client.Reader
+	clients
 
 	lock sync.RWMutex
 }
@@ -109,7 +112,11 @@ func New(logger *slog.Logger, cfg *config.Config) *Handler {
 		router:   router,
 		reloadCh: make(chan chan error),
 	}
-	h.buildClients()
+	if c, err := buildClients(cfg, logger); err != nil {
+		h.logger.Error("Error building clients", "err", err)
+	} else {
+		h.clients = *c
+	}
 
 	staticFs := http.FileServer(
 		&assetfs.AssetFS{Asset: ui.Asset, AssetDir: ui.AssetDir, AssetInfo: ui.AssetInfo, Prefix: ""})
@@ -125,9 +132,30 @@ func New(logger *slog.Logger, cfg *config.Config) *Handler {
 	router.Methods(http.MethodPost).Path("/-/reload").Handler(instrumentHandler("reload", h.reload))
 	router.Methods(http.MethodGet).Path("/").Handler(instrumentHandler("home", h.home))
 	router.Methods(http.MethodGet).Path("/simulation").Handler(instrumentHandler("home", h.simulation))
+	router.Methods(http.MethodGet, http.MethodPost).Path("/trace").Handler(instrumentHandler("trace", h.trace))
 
+	// InfluxDB 1.x clients always set the db parameter, which tells their
+	// writes apart from remote write requests.
+	router.Methods(http.MethodPost).Path("/write").Queries("db", "{db}").Handler(instrumentHandler("influx_write", h.influxWrite))
+	router.Methods(http.MethodPost).Path("/api/v2/write").Handler(instrumentHandler("influx_write", h.influxWrite))
 	router.Methods(http.MethodPost).Path("/write").Handler(instrumentHandler("write", h.write))
 	router.Methods(http.MethodPost).Path("/read").Handler(instrumentHandler("read", h.read))
+	router.Methods(http.MethodPost).Path("/v1/metrics").Handler(instrumentHandler("otlp_write", h.otlpWrite))
+
+	// Prometheus HTTP query API.
+	queryMethods := []string{http.MethodGet, http.MethodPost}
+	router.Methods(queryMethods...).Path("/api/v1/query").Handler(instrumentHandler("query", h.promQuery((*promapi.API).Query)))
+	router.Methods(queryMethods...).Path("/api/v1/query_range").Handler(instrumentHandler("query_range", h.promQuery((*promapi.API).QueryRange)))
+	router.Methods(queryMethods...).Path("/api/v1/series").Handler(instrumentHandler("series", h.promQuery((*promapi.API).Series)))
+	router.Methods(queryMethods...).Path("/api/v1/labels").Handler(instrumentHandler("labels", h.promQuery((*promapi.API).LabelNames)))
+	router.Methods(http.MethodGet).Path("/api/v1/label/{name}/values").Handler(instrumentHandler("label_values",
+		h.promQuery(func(api *promapi.API, w http.ResponseWriter, r *http.Request) {
+			api.LabelValues(w, r, mux.Vars(r)["name"])
+		})))
+
+	// Graphite render and find APIs.
+	router.Methods(queryMethods...).Path("/render").Handler(instrumentHandler("render", h.graphiteQuery((*graphiteapi.API).Render)))
+	router.Methods(queryMethods...).Path("/metrics/find").Handler(instrumentHandler("find", h.graphiteQuery((*graphiteapi.API).Find)))
 
 	return h
 }
@@ -137,33 +165,115 @@ func (h *Handler) Reload() <-chan chan error {
 	return h.reloadCh
 }
 
-// ApplyConfig updates the config field of the Handler struct
+// ApplyConfig updates the config field of the Handler struct. The clients
+// of cfg are built first, so that the current ones are kept if one fails.
+// The state the clients save on shutdown, like the series index, is only
+// loaded by the new clients on first use, once the current ones are shut down.
 func (h *Handler) ApplyConfig(cfg *config.Config) error {
+	c, err := buildClients(cfg, h.logger)
+	if err != nil {
+		return err
+	}
+
 	h.lock.Lock()
 	defer h.lock.Unlock()
+	h.clients.shutdown()
+	h.cfg = cfg
+	h.clients = *c
+	return nil
+}
 
-	for _, w := range h.writers {
+// clients are the clients built from a config, and the APIs reading from
+// them.
+type clients struct {
+	writers     []client.Writer
+	readers     []client.Reader
+	promAPI     *promapi.API
+	graphiteAPI *graphiteapi.API
+}
+
+func (c *clients) shutdown() {
+	for _, w := range c.writers {
 		w.Shutdown()
 	}
-	for _, r := range h.readers {
+	for _, r := range c.readers {
 		r.Shutdown()
 	}
+}
 
-	h.cfg = cfg
-	h.buildClients()
+// buildClients builds the clients of cfg. If one fails, the ones already
+// built are shut down.
+func buildClients(cfg *config.Config, logger *slog.Logger) (*clients, error) {
+	logger.Info("Building clients", "cfg", cfg)
+	c := &clients{}
+	if gc := graphite.NewClient(cfg, logger); gc != nil {
+		c.writers = append(c.writers, gc)
+		c.readers = append(c.readers, gc)
+	}
+	if wc := whisper.NewClient(cfg, logger); wc != nil {
+		if cfg.Graphite.Whisper.Writes() {
+			c.writers = append(c.writers, wc)
+		}
+		// Reads are served by a single reader, the whisper files replace
+		// graphite-web.
+		if cfg.Graphite.Whisper.Reads() {
+			c.readers = []client.Reader{wc}
+		}
+	}
+	for _, cc := range cfg.Clients {
+		w, r, err := client.New(cc, cfg, logger)
+		if err != nil {
+			c.shutdown()
+			return nil, err
+		}
+		if w != nil {
+			c.writers = append(c.writers, w)
+		}
+		if r != nil {
+			c.readers = append(c.readers, r)
+		}
+	}
+	if len(c.readers) == 1 {
+		c.promAPI = promapi.NewAPI(c.readers[0], promapi.Options{
+			Timeout:       cfg.Read.Timeout,
+			MaxSamples:    cfg.Read.MaxSamples,
+			LookbackDelta: cfg.Read.LookbackDelta,
+			// The samples more recent than the delay are not read.
+			SelectionRange: cfg.Read.Delay + cfg.Read.LookbackDelta,
+		}, logger)
+	}
+	if url := cfg.GraphiteAPI.RemoteReadURL; url != "" {
+		c.graphiteAPI = graphiteapi.NewAPI(remote.NewReadClient(url, cfg.GraphiteAPI.Timeout),
+			cfg.GraphiteAPI.Step, &cfg.Graphite, logger)
+	}
+	logger.Info("Built clients", "num_writers", len(c.writers), "num_readers", len(c.readers))
+	return c, nil
+}
 
-	return nil
+// promQuery serves an endpoint of the Prometheus HTTP query API.
+func (h *Handler) promQuery(serve func(*promapi.API, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.lock.RLock()
+		defer h.lock.RUnlock()
+		if h.promAPI == nil {
+			http.Error(w, fmt.Sprintf("expected exactly one reader, found %d readers", len(h.readers)), http.StatusServiceUnavailable)
+			return
+		}
+		serve(h.promAPI, w, r)
+	}
 }
 
-func (h *Handler) buildClients() {
-	h.logger.Info("Building clients", "cfg", h.cfg)
-	h.writers = nil
-	h.readers = nil
-	if c := graphite.NewClient(h.cfg, h.logger); c != nil {
-		h.writers = append(h.writers, c)
-		h.readers = append(h.readers, c)
+// graphiteQuery serves an endpoint of the Graphite API.
+func (h *Handler) graphiteQuery(serve func(*graphiteapi.API, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.lock.RLock()
+		defer h.lock.RUnlock()
+		if h.graphiteAPI == nil {
+			http.Error(w, "the Graphite API is disabled, graphite_api.remote_read_url is not set", http.StatusNotFound)
+			return
+		}
+		serve(h.graphiteAPI, w, r)
 	}
-	h.logger.Info("Built clients", "num_writers", len(h.writers), "num_readers", len(h.readers))
 }
 
 // Run serves the HTTP endpoints.
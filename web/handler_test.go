@@ -17,17 +17,24 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"log/slog"
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
 	"github.com/Netcracker/qubership-graphite-remote-adapter/config"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
@@ -35,6 +42,7 @@ import (
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 type fakeWriter struct {
@@ -160,6 +168,7 @@ func TestHandler_Simulation(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.NotEmpty(t, w.Body.String())
+	assert.Contains(t, w.Body.String(), "simulTrace()")
 }
 
 func TestHandler_ReloadSuccess(t *testing.T) {
@@ -212,6 +221,52 @@ func TestHandler_ApplyConfigShutsDownOldClients(t *testing.T) {
 	assert.Len(t, handler.readers, 1)
 }
 
+func TestHandler_ApplyConfigClients(t *testing.T) {
+	handler := testHandler()
+
+	cfg, err := config.Load(`
+clients:
+  - name: eu
+    type: graphite
+    selectors: ['{job="node"}']
+    settings:
+      default_prefix: eu.
+      write:
+        carbon_address: 127.0.0.1:2003
+  - name: us
+    type: graphite
+    settings:
+      write:
+        carbon_address: 127.0.0.1:2004
+      read:
+        url: http://127.0.0.1:8080
+`)
+	require.NoError(t, err)
+	require.NoError(t, handler.ApplyConfig(cfg))
+	require.Len(t, handler.writers, 2)
+	assert.Equal(t, "eu", handler.writers[0].Name())
+	assert.Equal(t, "us", handler.writers[1].Name())
+	require.Len(t, handler.readers, 1)
+	assert.Equal(t, "us", handler.readers[0].Name())
+	assert.NotNil(t, handler.promAPI)
+
+	selected := client.Select(handler.writers[0], model.Samples{
+		{Metric: model.Metric{"__name__": "up", "job": "node"}},
+		{Metric: model.Metric{"__name__": "up", "job": "api"}},
+	})
+	assert.Equal(t, model.Samples{{Metric: model.Metric{"__name__": "up", "job": "node"}}}, selected)
+
+	_, err = config.Load(`clients: [{name: a, type: unknown}]`)
+	assert.ErrorContains(t, err, `unknown type "unknown" of client "a"`)
+
+	// A client failing to build keeps the current ones.
+	cfg, err = config.Load(`clients: [{name: a, type: graphite, settings: {default_prefix: a.}}]`)
+	require.NoError(t, err)
+	assert.ErrorContains(t, handler.ApplyConfig(cfg), "a carbon address or a graphite-web URL is required")
+	require.Len(t, handler.writers, 2)
+	assert.Equal(t, "eu", handler.writers[0].Name())
+}
+
 func TestHandlerParseTestWriteRequest(t *testing.T) {
 	handler := testHandler()
 	payload, err := json.Marshal([]*model.Sample{
@@ -319,6 +374,100 @@ func TestHandlerWriteReturnsBadRequestForInvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandlerTrace(t *testing.T) {
+	handler := testHandler()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+rules:
+- match:
+    job: node
+  template: 'nodes.{{.labels.instance}}.{{.labels.__name__}}'
+  continue: true
+`), &handler.cfg.Graphite.Write))
+
+	payload, err := json.Marshal(traceRequest{
+		Prefix:  "prom.",
+		Samples: []*model.Sample{{Metric: model.Metric{model.MetricNameLabel: "up", "job": "node", "instance": "a"}, Value: 1}},
+		Series:  []string{`up{job="api"}`},
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/trace", bytes.NewReader(payload))
+	w := httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var traces []paths.Trace
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &traces))
+	require.Len(t, traces, 2)
+	assert.Equal(t, []string{"nodes.a.up", "prom.up.instance.a.job.node"}, traces[0].Paths)
+	assert.True(t, traces[0].Rules[0].Matched)
+	assert.Equal(t, "nodes.a.up", traces[0].Rules[0].Path)
+	assert.Equal(t, []string{"prom.up.job.api"}, traces[1].Paths)
+	assert.Equal(t, []string{`match: label job is "api", expected "node"`}, traces[1].Rules[0].Mismatches)
+
+	req = httptest.NewRequest(http.MethodGet, "/trace?series=up&graphite.default-prefix=tenant.", nil)
+	w = httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &traces))
+	require.Len(t, traces, 1)
+	assert.Equal(t, "tenant.", traces[0].Prefix)
+	assert.Equal(t, []string{"tenant.up"}, traces[0].Paths)
+
+	for _, selector := range []string{`up{job=~"a.*"}`, "up{"} {
+		w = httptest.NewRecorder()
+		handler.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trace?series="+url.QueryEscape(selector), nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, selector)
+	}
+}
+
+func TestHandlerTraceClient(t *testing.T) {
+	handler := testHandler()
+	cfg, err := config.Load(`
+graphite:
+  write:
+    rules:
+    - match: {job: node}
+      template: 'top.{{.labels.__name__}}'
+clients:
+  - name: eu
+    type: graphite
+    settings:
+      default_prefix: eu.
+      write:
+        carbon_address: 127.0.0.1:2003
+        rules:
+        - match: {job: node}
+          template: 'eu.{{.labels.__name__}}'
+`)
+	require.NoError(t, err)
+	// The types of the other packages aren't registered here.
+	cfg.Clients = append(cfg.Clients, &config.ClientConfig{Name: "mimir", Type: "remote_write"})
+	handler.cfg = cfg
+
+	for _, tc := range []struct {
+		client string
+		paths  []string
+	}{
+		{"", []string{"top.up"}},
+		{"graphite", []string{"top.up"}},
+		{"eu", []string{"eu.up"}},
+	} {
+		w := httptest.NewRecorder()
+		handler.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trace?series="+url.QueryEscape(`up{job="node"}`)+"&client="+tc.client, nil))
+		require.Equal(t, http.StatusOK, w.Code, tc.client)
+		var traces []paths.Trace
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &traces))
+		require.Len(t, traces, 1)
+		assert.Equal(t, tc.paths, traces[0].Paths, tc.client)
+	}
+
+	for _, name := range []string{"mimir", "unknown"} {
+		w := httptest.NewRecorder()
+		handler.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trace?series=up&client="+name, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+}
+
 func TestHandlerWriteRemoteWriteRequest(t *testing.T) {
 	handler := testHandler()
 	writer := &fakeWriter{name: "writer-a", target: "graphite://writer"}
@@ -348,6 +497,193 @@ func TestHandlerWriteRemoteWriteRequest(t *testing.T) {
 	assert.Greater(t, writer.lastReqLen, 0)
 }
 
+func TestHandlerWriteStatus(t *testing.T) {
+	reqPayload := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: string(model.MetricNameLabel), Value: "cpu_usage"}},
+			Samples: []prompb.Sample{{Value: 12.5, Timestamp: 1234}},
+		}},
+	}
+	for _, tc := range []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"failed", errors.New("carbon down"), http.StatusOK},
+		{"recoverable", remote.RecoverableError{Err: errors.New("queue full")}, http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := testHandler()
+			handler.writers = []client.Writer{
+				&fakeWriter{name: "writer-a", target: "graphite://writer"},
+				&fakeWriter{name: "writer-b", target: "remote://writer",
+					writeFn: func(model.Samples, int, *http.Request, bool) ([]byte, error) { return nil, tc.err }},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/write", bytes.NewReader(encodeWriteRequest(t, reqPayload)))
+			w := httptest.NewRecorder()
+			handler.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			var resp map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, map[string]string{"writer-a": "ok", "writer-b": tc.err.Error()}, resp)
+		})
+	}
+}
+
+func TestHandlerOTLPWrite(t *testing.T) {
+	handler := testHandler()
+	handler.cfg.OTLP.AddMetricSuffixes = true
+	writer := &fakeWriter{name: "writer-a", target: "graphite://writer"}
+	handler.writers = []client.Writer{writer}
+
+	body := `{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},` +
+		`"scopeMetrics":[{"metrics":[{"name":"requests","sum":{"isMonotonic":true,"aggregationTemporality":2,` +
+		`"dataPoints":[{"asInt":"3","timeUnixNano":"1700000000000000000"}]}}]}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{}`, w.Body.String())
+	require.Len(t, writer.lastSamples, 1)
+	assert.Equal(t, model.LabelValue("requests_total"), writer.lastSamples[0].Metric[model.MetricNameLabel])
+	assert.Equal(t, model.LabelValue("api"), writer.lastSamples[0].Metric["job"])
+}
+
+func TestHandlerOTLPWriteErrors(t *testing.T) {
+	handler := testHandler()
+	handler.writers = []client.Writer{&fakeWriter{
+		name:   "writer-a",
+		target: "graphite://writer",
+		writeFn: func(samples model.Samples, reqBufLen int, r *http.Request, dryRun bool) ([]byte, error) {
+			return nil, errors.New("write failed")
+		},
+	}}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader("not json"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestHandlerInfluxWrite(t *testing.T) {
+	for _, url := range []string{"/write?db=telegraf&precision=s", "/api/v2/write?org=o&bucket=b&precision=s"} {
+		handler := testHandler()
+		writer := &fakeWriter{name: "writer-a", target: "graphite://writer"}
+		handler.writers = []client.Writer{writer}
+
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("cpu,host=a idle=90,user=5i 1700000000\n"))
+		w := httptest.NewRecorder()
+
+		handler.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code, url)
+		require.Len(t, writer.lastSamples, 2, url)
+		assert.Equal(t, model.Time(1700000000000), writer.lastSamples[0].Timestamp, url)
+		assert.Equal(t, model.LabelValue("a"), writer.lastSamples[0].Metric["host"], url)
+	}
+}
+
+func TestHandlerInfluxWriteErrors(t *testing.T) {
+	handler := testHandler()
+	writer := &fakeWriter{name: "writer-a", target: "graphite://writer"}
+	handler.writers = []client.Writer{writer}
+
+	req := httptest.NewRequest(http.MethodPost, "/write?db=telegraf", strings.NewReader("cpu\n"))
+	w := httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, writer.lastSamples)
+
+	req = httptest.NewRequest(http.MethodPost, "/write?db=telegraf", strings.NewReader("cpu\ncpu idle=1\n"))
+	w = httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "partial write")
+	assert.Len(t, writer.lastSamples, 1)
+
+	writer.writeFn = func(samples model.Samples, reqBufLen int, r *http.Request, dryRun bool) ([]byte, error) {
+		return nil, errors.New("write failed")
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/write", strings.NewReader("cpu idle=1\n"))
+	w = httptest.NewRecorder()
+	handler.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestHandler_ApplyConfigKeepsSeriesIndex(t *testing.T) {
+	carbon, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = carbon.Close() }()
+	go func() {
+		for {
+			conn, err := carbon.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }()
+		}
+	}()
+
+	// The periodic flush never runs, the index is only saved on shutdown.
+	cfg, err := config.Load(`
+graphite:
+  default_prefix: prefix.
+  write:
+    carbon_address: ` + carbon.Addr().String() + `
+  read:
+    url: http://127.0.0.1:1
+  index:
+    enabled: true
+    path: ` + filepath.Join(t.TempDir(), "index.json") + `
+    flush_interval: 1h
+    exclusive: true
+`)
+	require.NoError(t, err)
+	handler := New(slog.New(slog.DiscardHandler), cfg)
+	defer handler.clients.shutdown()
+
+	samples := model.Samples{{Metric: model.Metric{model.MetricNameLabel: "up", "job": "node"}, Value: 1, Timestamp: model.Now()}}
+	require.NoError(t, handler.Append(context.Background(), samples))
+	require.NoError(t, handler.ApplyConfig(cfg))
+
+	// The series is read back from the index, graphite-web being down.
+	require.Len(t, handler.readers, 1)
+	reader := handler.readers[0].(client.LabelReader)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/label/job/values", nil)
+	values, err := reader.LabelValues(context.Background(), time.Now().UnixMilli(), "job",
+		[]*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "up"}}, r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node"}, values)
+}
+
+func TestHandlerAppend(t *testing.T) {
+	handler := testHandler()
+	writer := &fakeWriter{name: "writer-a", target: "graphite://writer"}
+	handler.writers = []client.Writer{writer}
+
+	samples := model.Samples{{Metric: model.Metric{model.MetricNameLabel: "up"}, Value: 1}}
+	require.NoError(t, handler.Append(context.Background(), samples))
+	assert.Equal(t, samples, writer.lastSamples)
+	assert.False(t, writer.lastDryRun)
+
+	writer.writeFn = func(samples model.Samples, reqBufLen int, r *http.Request, dryRun bool) ([]byte, error) {
+		return nil, errors.New("write failed")
+	}
+	assert.Error(t, handler.Append(context.Background(), samples))
+}
+
 func TestInstrumentedWriteSamplesReturnsError(t *testing.T) {
 	handler := testHandler()
 	writer := &fakeWriter{
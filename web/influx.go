@@ -0,0 +1,54 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/web/influx"
+)
+
+// influxWrite converts an InfluxDB line protocol write request to samples
+// and writes them like a remote write request.
+func (h *Handler) influxWrite(w http.ResponseWriter, r *http.Request) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	h.logger.Debug("Handling InfluxDB write request", "remote", r.RemoteAddr, "method", r.Method, "url", r.URL)
+
+	samples, reqBufLen, parseErr := influx.DecodeRequest(r, time.Now())
+	if parseErr != nil {
+		h.logger.Debug("Error parsing InfluxDB write request", "err", parseErr)
+		if len(samples) == 0 {
+			http.Error(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Telegraf retries on server errors, but drops the batch on bad requests.
+	if _, err := h.writeSamples(samples, reqBufLen, r, false); err != nil {
+		http.Error(w, fmt.Sprintf("error writing samples: %s", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Like InfluxDB, the valid lines of a partial write are written anyway.
+	if parseErr != nil {
+		http.Error(w, fmt.Sprintf("partial write: %s", parseErr), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
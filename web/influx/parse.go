@@ -0,0 +1,220 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package influx decodes InfluxDB line protocol write requests and converts
+// them to samples.
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Parse parses line protocol lines. Each numeric or boolean field of a line
+// becomes a sample named <measurement>_<field>, labelled with the tags of the
+// line. String fields are ignored. Timestamps are read in the given precision
+// and default to now. The samples of the valid lines are returned along with
+// the errors of the invalid ones.
+func Parse(data []byte, precision string, now time.Time) (model.Samples, error) {
+	unit, err := precisionUnit(precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples model.Samples
+	var errs []error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineSamples, err := parseLine(line, unit, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNo, err))
+			continue
+		}
+		samples = append(samples, lineSamples...)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return samples, errors.Join(errs...)
+}
+
+// precisionUnit returns the duration of a timestamp unit, nanoseconds if the
+// precision is empty. The InfluxDB 1.x n and u abbreviations are accepted.
+func precisionUnit(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns", "n":
+		return time.Nanosecond, nil
+	case "us", "u":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid precision %q", precision)
+	}
+}
+
+// parseLine parses a line: <measurement>[,<tag>=<value>...] <field>=<value>[,<field>=<value>...] [<timestamp>].
+func parseLine(line string, unit time.Duration, now time.Time) (model.Samples, error) {
+	series, rest := cut(line, ' ', false)
+	fieldSet, rest := cut(strings.TrimLeft(rest, " "), ' ', true)
+	rest = strings.TrimSpace(rest)
+	if fieldSet == "" {
+		return nil, errors.New("missing fields")
+	}
+
+	ts := model.TimeFromUnixNano(now.UnixNano())
+	if rest != "" {
+		n, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", rest)
+		}
+		ts = model.TimeFromUnixNano(n * int64(unit))
+	}
+
+	parts := split(series, ',', false)
+	measurement := unescape(parts[0])
+	if measurement == "" {
+		return nil, errors.New("missing measurement")
+	}
+	labels := make(model.Metric, len(parts))
+	for _, part := range parts[1:] {
+		k, v, ok := cutKeyValue(part)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid tag %q", part)
+		}
+		labels[labelName(k)] = model.LabelValue(v)
+	}
+
+	var samples model.Samples
+	for _, field := range split(fieldSet, ',', true) {
+		k, v, ok := cutKeyValue(field)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+		value, ok, err := parseFieldValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field %q: %w", k, err)
+		}
+		if !ok {
+			continue
+		}
+		metric := labels.Clone()
+		metric[model.MetricNameLabel] = model.LabelValue(
+			model.EscapeName(measurement+"_"+k, model.UnderscoreEscaping))
+		samples = append(samples, &model.Sample{Metric: metric, Value: value, Timestamp: ts})
+	}
+	return samples, nil
+}
+
+// parseFieldValue parses a field value. ok is false for string values, which
+// can't be turned into samples.
+func parseFieldValue(v string) (model.SampleValue, bool, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		if len(v) < 2 || !strings.HasSuffix(v, `"`) {
+			return 0, false, errors.New("unterminated string")
+		}
+		return 0, false, nil
+	case strings.HasSuffix(v, "i"):
+		i, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+		return model.SampleValue(i), err == nil, err
+	case strings.HasSuffix(v, "u"):
+		u, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+		return model.SampleValue(u), err == nil, err
+	}
+	switch v {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, true, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return model.SampleValue(f), err == nil, err
+}
+
+func labelName(k string) model.LabelName {
+	return model.LabelName(model.EscapeName(k, model.UnderscoreEscaping))
+}
+
+// cut slices s around the first unescaped sep, outside of double quotes if
+// quoted is set.
+func cut(s string, sep byte, quoted bool) (string, string) {
+	if i := index(s, sep, quoted); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// split slices s around each unescaped sep, outside of double quotes if
+// quoted is set.
+func split(s string, sep byte, quoted bool) []string {
+	var parts []string
+	for {
+		i := index(s, sep, quoted)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+1:]
+	}
+}
+
+func index(s string, sep byte, quoted bool) int {
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			return i
+		}
+	}
+	return -1
+}
+
+// cutKeyValue splits a key=value pair and unescapes its key, and its value
+// unless it's a quoted string.
+func cutKeyValue(s string) (string, string, bool) {
+	k, v := cut(s, '=', false)
+	if len(k) == len(s) {
+		return "", "", false
+	}
+	if !strings.HasPrefix(v, `"`) {
+		v = unescape(v)
+	}
+	return unescape(k), v, true
+}
+
+var unescaper = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\\`, `\`)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
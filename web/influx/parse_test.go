@@ -0,0 +1,109 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package influx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleStrings returns the samples as sorted "<metric> <value> @<timestamp>" strings.
+func sampleStrings(samples model.Samples) []string {
+	var strs []string
+	for _, s := range samples {
+		strs = append(strs, fmt.Sprintf("%s %s @%d", s.Metric, s.Value, s.Timestamp))
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+func TestParse(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	data := []byte(`# comment
+cpu,host=server\ 1,region=eu usage_idle=90.5,usage_user=2i,up=true 1700000001000000000
+
+disk\,io,path=/var\=x reads=3u,label="a \"b\", c=d",ok=F
+`)
+
+	samples, err := Parse(data, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`cpu_up{host="server 1", region="eu"} 1 @1700000001000`,
+		`cpu_usage_idle{host="server 1", region="eu"} 90.5 @1700000001000`,
+		`cpu_usage_user{host="server 1", region="eu"} 2 @1700000001000`,
+		`disk_io_ok{path="/var=x"} 0 @1700000000000`,
+		`disk_io_reads{path="/var=x"} 3 @1700000000000`,
+	}, sampleStrings(samples))
+}
+
+func TestParsePrecision(t *testing.T) {
+	for precision, ts := range map[string]string{
+		"ns": "1700000000000000000",
+		"us": "1700000000000000",
+		"ms": "1700000000000",
+		"s":  "1700000000",
+	} {
+		samples, err := Parse([]byte("m v=1 "+ts), precision, time.Now())
+		require.NoError(t, err, precision)
+		require.Len(t, samples, 1)
+		assert.Equal(t, model.Time(1700000000000), samples[0].Timestamp, precision)
+	}
+
+	_, err := Parse([]byte("m v=1"), "h", time.Now())
+	assert.Error(t, err)
+}
+
+func TestParseInvalidLines(t *testing.T) {
+	data := []byte(`m v=1 1
+m
+m,host v=1
+m v=abc
+m v=1 notatime
+m v="unterminated
+`)
+
+	samples, err := Parse(data, "s", time.Now())
+	require.Error(t, err)
+	for _, line := range []string{"line 2", "line 3", "line 4", "line 5", "line 6"} {
+		assert.Contains(t, err.Error(), line)
+	}
+	assert.Equal(t, []string{`m_v 1 @1000`}, sampleStrings(samples))
+}
+
+func TestDecodeRequest(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("m v=1 1700000000\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/write?org=o&bucket=b&precision=s", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	samples, size, err := DecodeRequest(req, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 17, size)
+	assert.Equal(t, []string{`m_v 1 @1700000000000`}, sampleStrings(samples))
+}
@@ -0,0 +1,49 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package influx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// DecodeRequest reads the optionally gzipped line protocol body of an
+// InfluxDB 1.x /write or 2.x /api/v2/write request, in the precision of its
+// precision parameter. It returns the size of the body and, like Parse, the
+// samples of the valid lines along with the errors of the invalid ones.
+func DecodeRequest(r *http.Request, now time.Time) (model.Samples, int, error) {
+	body := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error decoding gzip body: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading body: %w", err)
+	}
+
+	samples, err := Parse(data, r.URL.Query().Get("precision"), now)
+	return samples, len(data), err
+}
@@ -0,0 +1,64 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/web/otlp"
+)
+
+// otlpWrite converts an OTLP/HTTP metrics export request to samples and
+// writes them like a remote write request.
+func (h *Handler) otlpWrite(w http.ResponseWriter, r *http.Request) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	h.logger.Debug("Handling /v1/metrics request", "remote", r.RemoteAddr, "method", r.Method, "url", r.URL)
+
+	md, reqBufLen, err := otlp.DecodeRequest(r)
+	if err != nil {
+		h.logger.Error("Error decoding OTLP metrics request", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	converter := otlp.NewConverter(otlp.Options{
+		AddMetricSuffixes:            h.cfg.OTLP.AddMetricSuffixes,
+		PromoteResourceAttributes:    h.cfg.OTLP.PromoteResourceAttributes,
+		PromoteAllResourceAttributes: h.cfg.OTLP.PromoteAllResourceAttributes,
+		PromoteScopeMetadata:         h.cfg.OTLP.PromoteScopeMetadata,
+		UnderscoreNames:              h.cfg.Graphite.NameEscaping == graphiteCfg.NameEscapingUnderscores,
+	})
+	samples, rejected, convErr := converter.ToSamples(md)
+	if convErr != nil {
+		h.logger.Debug("Rejected OTLP data points", "num_rejected", rejected, "err", convErr)
+	}
+
+	// The exporter retries on 503, which is what we want if the data points
+	// couldn't be written to the remote storage.
+	if _, err := h.writeSamples(samples, reqBufLen, r, false); err != nil {
+		http.Error(w, fmt.Sprintf("error writing samples: %s", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	errMsg := ""
+	if convErr != nil {
+		errMsg = convErr.Error()
+	}
+	otlp.WriteResponse(w, r, rejected, errMsg)
+}
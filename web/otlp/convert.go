@@ -0,0 +1,520 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package otlp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// Options configures the translation of OTLP metrics to samples.
+type Options struct {
+	// AddMetricSuffixes appends the unit and the _total suffix of counters
+	// to metric names, as Prometheus does.
+	AddMetricSuffixes bool
+	// PromoteResourceAttributes are the resource attributes added to the
+	// labels of every sample of the resource.
+	PromoteResourceAttributes []string
+	// PromoteAllResourceAttributes promotes every resource attribute.
+	PromoteAllResourceAttributes bool
+	// PromoteScopeMetadata adds the otel_scope_name, otel_scope_version and
+	// otel_scope_<attribute> labels.
+	PromoteScopeMetadata bool
+	// UnderscoreNames replaces the characters of metric and label names
+	// which are not valid in Prometheus by underscores. Otherwise, the names
+	// are kept as is, like with the utf8 name_escaping of Graphite clients.
+	UnderscoreNames bool
+}
+
+var rejectedDataPoints = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "remote_adapter",
+		Subsystem: "otlp",
+		Name:      "rejected_data_points_total",
+		Help:      "Total number of OTLP data points which could not be converted to samples.",
+	},
+	[]string{"reason"},
+)
+
+// errDeltaTemporality is returned for sums and histograms of delta
+// temporality, which can't be written as Prometheus counters.
+var errDeltaTemporality = errors.New("delta temporality is not supported, convert it with the deltatocumulative processor of the collector")
+
+// noRecordedValue is the DataPointFlags bit of points without value.
+const noRecordedValue = uint32(metricspb.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK)
+
+// unitSuffixes translates the UCUM units of OTLP to Prometheus unit suffixes.
+var unitSuffixes = map[string]string{
+	"d":    "days",
+	"h":    "hours",
+	"min":  "minutes",
+	"s":    "seconds",
+	"ms":   "milliseconds",
+	"us":   "microseconds",
+	"ns":   "nanoseconds",
+	"By":   "bytes",
+	"KiBy": "kibibytes",
+	"MiBy": "mebibytes",
+	"GiBy": "gibibytes",
+	"TiBy": "tibibytes",
+	"KBy":  "kilobytes",
+	"MBy":  "megabytes",
+	"GBy":  "gigabytes",
+	"TBy":  "terabytes",
+	"m":    "meters",
+	"V":    "volts",
+	"A":    "amperes",
+	"J":    "joules",
+	"W":    "watts",
+	"g":    "grams",
+	"Cel":  "celsius",
+	"Hz":   "hertz",
+	"%":    "percent",
+}
+
+// perUnitSuffixes translates the denominators of OTLP units.
+var perUnitSuffixes = map[string]string{
+	"s":  "second",
+	"m":  "minute",
+	"h":  "hour",
+	"d":  "day",
+	"w":  "week",
+	"mo": "month",
+	"y":  "year",
+}
+
+// Converter translates OTLP metrics to samples.
+type Converter struct {
+	opts Options
+	now  func() time.Time
+}
+
+// NewConverter returns a new Converter.
+func NewConverter(opts Options) *Converter {
+	return &Converter{opts: opts, now: time.Now}
+}
+
+// ToSamples converts the data points of md to samples. It returns the number
+// of data points which could not be converted and the last conversion error.
+func (c *Converter) ToSamples(md *metricspb.MetricsData) (model.Samples, int64, error) {
+	var samples model.Samples
+	var rejected int64
+	var lastErr error
+	for _, rm := range md.GetResourceMetrics() {
+		resourceLabels := c.resourceLabels(rm.GetResource().GetAttributes())
+		for _, sm := range rm.GetScopeMetrics() {
+			scopeLabels := c.scopeLabels(sm.GetScope())
+			for _, m := range sm.GetMetrics() {
+				conv := &metricConverter{
+					Converter: c,
+					base:      mergeLabels(scopeLabels, resourceLabels),
+				}
+				n, err := conv.convert(m)
+				if err != nil {
+					reason := "invalid"
+					if errors.Is(err, errDeltaTemporality) {
+						reason = "delta_temporality"
+					}
+					rejectedDataPoints.WithLabelValues(reason).Add(float64(n))
+					rejected += n
+					lastErr = fmt.Errorf("metric %q: %w", m.GetName(), err)
+					continue
+				}
+				samples = append(samples, conv.samples...)
+			}
+		}
+	}
+	return samples, rejected, lastErr
+}
+
+// resourceLabels returns the job, instance and promoted labels of a resource.
+func (c *Converter) resourceLabels(attrs []*commonpb.KeyValue) model.Metric {
+	labels := make(model.Metric)
+	promoted := make(map[string]bool, len(c.opts.PromoteResourceAttributes))
+	for _, name := range c.opts.PromoteResourceAttributes {
+		promoted[name] = true
+	}
+
+	var serviceName, serviceNamespace string
+	for _, kv := range attrs {
+		value := anyValueString(kv.GetValue())
+		switch kv.GetKey() {
+		case "service.name":
+			serviceName = value
+		case "service.namespace":
+			serviceNamespace = value
+		case "service.instance.id":
+			labels["instance"] = model.LabelValue(value)
+		}
+		if c.opts.PromoteAllResourceAttributes || promoted[kv.GetKey()] {
+			c.addLabel(labels, kv.GetKey(), value)
+		}
+	}
+	if serviceName != "" {
+		if serviceNamespace != "" {
+			serviceName = serviceNamespace + "/" + serviceName
+		}
+		labels["job"] = model.LabelValue(serviceName)
+	}
+	return labels
+}
+
+// scopeLabels returns the labels of an instrumentation scope.
+func (c *Converter) scopeLabels(scope *commonpb.InstrumentationScope) model.Metric {
+	labels := make(model.Metric)
+	if !c.opts.PromoteScopeMetadata || scope == nil {
+		return labels
+	}
+	if scope.GetName() != "" {
+		labels["otel_scope_name"] = model.LabelValue(scope.GetName())
+	}
+	if scope.GetVersion() != "" {
+		labels["otel_scope_version"] = model.LabelValue(scope.GetVersion())
+	}
+	for _, kv := range scope.GetAttributes() {
+		c.addLabel(labels, "otel_scope_"+kv.GetKey(), anyValueString(kv.GetValue()))
+	}
+	return labels
+}
+
+// metricConverter converts the data points of a single metric.
+type metricConverter struct {
+	*Converter
+	base    model.Metric
+	samples model.Samples
+}
+
+// convert appends the samples of m. On error, it returns the number of data
+// points of m.
+func (mc *metricConverter) convert(m *metricspb.Metric) (int64, error) {
+	if m.GetName() == "" {
+		return dataPointCount(m), fmt.Errorf("empty metric name")
+	}
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		name := mc.metricName(m.GetName(), m.GetUnit(), false, true)
+		for _, p := range data.Gauge.GetDataPoints() {
+			mc.addNumber(name, p)
+		}
+		return 0, nil
+
+	case *metricspb.Metric_Sum:
+		if data.Sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			return dataPointCount(m), errDeltaTemporality
+		}
+		name := mc.metricName(m.GetName(), m.GetUnit(), data.Sum.GetIsMonotonic(), !data.Sum.GetIsMonotonic())
+		for _, p := range data.Sum.GetDataPoints() {
+			mc.addNumber(name, p)
+		}
+		return 0, nil
+
+	case *metricspb.Metric_Histogram:
+		if data.Histogram.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			return dataPointCount(m), errDeltaTemporality
+		}
+		name := mc.metricName(m.GetName(), m.GetUnit(), false, false)
+		for _, p := range data.Histogram.GetDataPoints() {
+			if err := mc.addHistogram(name, p); err != nil {
+				return dataPointCount(m), err
+			}
+		}
+		return 0, nil
+
+	case *metricspb.Metric_ExponentialHistogram:
+		if data.ExponentialHistogram.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+			return dataPointCount(m), errDeltaTemporality
+		}
+		name := mc.metricName(m.GetName(), m.GetUnit(), false, false)
+		for _, p := range data.ExponentialHistogram.GetDataPoints() {
+			mc.addExponentialHistogram(name, p)
+		}
+		return 0, nil
+
+	case *metricspb.Metric_Summary:
+		name := mc.metricName(m.GetName(), m.GetUnit(), false, false)
+		for _, p := range data.Summary.GetDataPoints() {
+			mc.addSummary(name, p)
+		}
+		return 0, nil
+
+	default:
+		return 1, fmt.Errorf("unsupported metric type %T", data)
+	}
+}
+
+// dataPointCount returns the number of data points of m, at least 1.
+func dataPointCount(m *metricspb.Metric) int64 {
+	var n int
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		n = len(data.Gauge.GetDataPoints())
+	case *metricspb.Metric_Sum:
+		n = len(data.Sum.GetDataPoints())
+	case *metricspb.Metric_Histogram:
+		n = len(data.Histogram.GetDataPoints())
+	case *metricspb.Metric_ExponentialHistogram:
+		n = len(data.ExponentialHistogram.GetDataPoints())
+	case *metricspb.Metric_Summary:
+		n = len(data.Summary.GetDataPoints())
+	}
+	return int64(max(n, 1))
+}
+
+func (mc *metricConverter) addNumber(name string, p *metricspb.NumberDataPoint) {
+	if p.GetFlags()&noRecordedValue != 0 {
+		return
+	}
+	value := p.GetAsDouble()
+	if v, ok := p.GetValue().(*metricspb.NumberDataPoint_AsInt); ok {
+		value = float64(v.AsInt)
+	}
+	metric := mc.metric(name, p.GetAttributes())
+	mc.add(metric, value, p.GetTimeUnixNano())
+}
+
+func (mc *metricConverter) addHistogram(name string, p *metricspb.HistogramDataPoint) error {
+	if p.GetFlags()&noRecordedValue != 0 {
+		return nil
+	}
+	counts, bounds := p.GetBucketCounts(), p.GetExplicitBounds()
+	if len(counts) > 0 && len(counts) != len(bounds)+1 {
+		return fmt.Errorf("%d bucket counts for %d explicit bounds", len(counts), len(bounds))
+	}
+
+	metric := mc.metric(name, p.GetAttributes())
+	ts := p.GetTimeUnixNano()
+	if len(counts) > 0 {
+		var cumulative uint64
+		for i, bound := range bounds {
+			cumulative += counts[i]
+			mc.add(withLabel(metric, "__name__", name+"_bucket", "le", formatFloat(bound)), float64(cumulative), ts)
+		}
+		mc.add(withLabel(metric, "__name__", name+"_bucket", "le", "+Inf"), float64(p.GetCount()), ts)
+	}
+	mc.addCountAndSum(metric, name, p.GetCount(), p.Sum, ts)
+	return nil
+}
+
+// addExponentialHistogram converts an exponential histogram to a classic
+// one: each populated bucket becomes a bucket with its upper boundary.
+func (mc *metricConverter) addExponentialHistogram(name string, p *metricspb.ExponentialHistogramDataPoint) {
+	if p.GetFlags()&noRecordedValue != 0 {
+		return
+	}
+	metric := mc.metric(name, p.GetAttributes())
+	ts := p.GetTimeUnixNano()
+	// The bucket of index i is (base^i, base^(i+1)], with base = 2^(2^-scale).
+	boundary := func(index int32) float64 {
+		return math.Exp2(float64(index) * math.Exp2(-float64(p.GetScale())))
+	}
+
+	var cumulative uint64
+	addBucket := func(le float64, count uint64) {
+		cumulative += count
+		mc.add(withLabel(metric, "__name__", name+"_bucket", "le", formatFloat(le)), float64(cumulative), ts)
+	}
+	negative := p.GetNegative()
+	for i := len(negative.GetBucketCounts()) - 1; i >= 0; i-- {
+		if count := negative.GetBucketCounts()[i]; count > 0 {
+			addBucket(-boundary(negative.GetOffset()+int32(i)), count)
+		}
+	}
+	if p.GetZeroCount() > 0 {
+		addBucket(p.GetZeroThreshold(), p.GetZeroCount())
+	}
+	positive := p.GetPositive()
+	for i, count := range positive.GetBucketCounts() {
+		if count > 0 {
+			addBucket(boundary(positive.GetOffset()+int32(i)+1), count)
+		}
+	}
+	mc.add(withLabel(metric, "__name__", name+"_bucket", "le", "+Inf"), float64(p.GetCount()), ts)
+	mc.addCountAndSum(metric, name, p.GetCount(), p.Sum, ts)
+}
+
+func (mc *metricConverter) addSummary(name string, p *metricspb.SummaryDataPoint) {
+	if p.GetFlags()&noRecordedValue != 0 {
+		return
+	}
+	metric := mc.metric(name, p.GetAttributes())
+	ts := p.GetTimeUnixNano()
+	for _, q := range p.GetQuantileValues() {
+		mc.add(withLabel(metric, "quantile", formatFloat(q.GetQuantile())), q.GetValue(), ts)
+	}
+	sum := p.GetSum()
+	mc.addCountAndSum(metric, name, p.GetCount(), &sum, ts)
+}
+
+func (mc *metricConverter) addCountAndSum(metric model.Metric, name string, count uint64, sum *float64, ts uint64) {
+	mc.add(withLabel(metric, "__name__", name+"_count"), float64(count), ts)
+	if sum != nil {
+		mc.add(withLabel(metric, "__name__", name+"_sum"), *sum, ts)
+	}
+}
+
+func (mc *metricConverter) add(metric model.Metric, value float64, timeUnixNano uint64) {
+	ts := model.TimeFromUnixNano(int64(timeUnixNano))
+	if timeUnixNano == 0 {
+		ts = model.TimeFromUnixNano(mc.now().UnixNano())
+	}
+	mc.samples = append(mc.samples, &model.Sample{Metric: metric, Value: model.SampleValue(value), Timestamp: ts})
+}
+
+// metric returns the labels of a data point. Its attributes take precedence
+// over the resource and scope labels.
+func (mc *metricConverter) metric(name string, attrs []*commonpb.KeyValue) model.Metric {
+	metric := make(model.Metric, len(attrs)+len(mc.base)+1)
+	for _, kv := range attrs {
+		mc.addLabel(metric, kv.GetKey(), anyValueString(kv.GetValue()))
+	}
+	for ln, lv := range mc.base {
+		if _, ok := metric[ln]; !ok {
+			metric[ln] = lv
+		}
+	}
+	metric[model.MetricNameLabel] = model.LabelValue(name)
+	return metric
+}
+
+// metricName translates an OTLP metric name to a Prometheus one, adding the
+// unit and the _total suffix of counters if enabled.
+func (c *Converter) metricName(name, unit string, counter, gauge bool) string {
+	name = c.escapeName(name)
+	if !c.opts.AddMetricSuffixes {
+		return name
+	}
+
+	name = strings.TrimSuffix(name, "_total")
+	if suffix := c.escapeName(unitSuffix(unit)); suffix != "" && !strings.HasSuffix(name, "_"+suffix) {
+		name += "_" + suffix
+	}
+	if unit == "1" && gauge && !strings.HasSuffix(name, "_ratio") {
+		name += "_ratio"
+	}
+	if counter {
+		name += "_total"
+	}
+	return name
+}
+
+// unitSuffix returns the Prometheus suffix of a UCUM unit, ignoring the
+// annotations in curly braces.
+func unitSuffix(unit string) string {
+	if i := strings.Index(unit, "{"); i != -1 {
+		unit = unit[:i]
+	}
+	num, per, _ := strings.Cut(unit, "/")
+	suffix := unitSuffixes[num]
+	if suffix == "" && num != "" && num != "1" {
+		suffix = num
+	}
+	if per != "" {
+		perSuffix := perUnitSuffixes[per]
+		if perSuffix == "" {
+			perSuffix = per
+		}
+		if suffix == "" {
+			suffix = "per_" + perSuffix
+		} else {
+			suffix += "_per_" + perSuffix
+		}
+	}
+	return suffix
+}
+
+// escapeName escapes a metric or label name if UnderscoreNames is set.
+func (c *Converter) escapeName(name string) string {
+	if !c.opts.UnderscoreNames {
+		return name
+	}
+	return model.EscapeName(name, model.UnderscoreEscaping)
+}
+
+// addLabel adds a label with an escaped name. Values of attributes whose
+// names collide once escaped are joined with ';'.
+func (c *Converter) addLabel(metric model.Metric, name, value string) {
+	ln := model.LabelName(c.escapeName(name))
+	if prev, ok := metric[ln]; ok && prev != "" {
+		value = string(prev) + ";" + value
+	}
+	metric[ln] = model.LabelValue(value)
+}
+
+// anyValueString returns the string representation of an attribute value.
+func anyValueString(v *commonpb.AnyValue) string {
+	switch value := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return value.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(value.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(value.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return formatFloat(value.DoubleValue)
+	case *commonpb.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(value.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]string, 0, len(value.ArrayValue.GetValues()))
+		for _, v := range value.ArrayValue.GetValues() {
+			values = append(values, strconv.Quote(anyValueString(v)))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	case *commonpb.AnyValue_KvlistValue:
+		values := make([]string, 0, len(value.KvlistValue.GetValues()))
+		for _, kv := range value.KvlistValue.GetValues() {
+			values = append(values, strconv.Quote(kv.GetKey())+":"+strconv.Quote(anyValueString(kv.GetValue())))
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	default:
+		return ""
+	}
+}
+
+// mergeLabels returns the union of the label sets, the first ones taking
+// precedence.
+func mergeLabels(sets ...model.Metric) model.Metric {
+	merged := make(model.Metric)
+	for i := len(sets) - 1; i >= 0; i-- {
+		for ln, lv := range sets[i] {
+			merged[ln] = lv
+		}
+	}
+	return merged
+}
+
+// withLabel returns a copy of metric with the given label name and value pairs.
+func withLabel(metric model.Metric, nameValues ...string) model.Metric {
+	m := metric.Clone()
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		m[model.LabelName(nameValues[i])] = model.LabelValue(nameValues[i+1])
+	}
+	return m
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
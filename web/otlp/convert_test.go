@@ -0,0 +1,246 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package otlp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const testTime = uint64(1700000000000000000)
+
+func stringAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func testMetricsData(metrics ...*metricspb.Metric) *metricspb.MetricsData {
+	return &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			stringAttr("service.name", "api"),
+			stringAttr("service.namespace", "shop"),
+			stringAttr("service.instance.id", "pod-1"),
+			stringAttr("k8s.namespace.name", "prod"),
+		}},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope:   &commonpb.InstrumentationScope{Name: "meter", Version: "1.0"},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// sampleStrings returns the samples as sorted "<metric> <value>" strings.
+func sampleStrings(samples model.Samples) []string {
+	var strs []string
+	for _, s := range samples {
+		strs = append(strs, s.Metric.String()+" "+s.Value.String())
+	}
+	sort.Strings(strs)
+	return strs
+}
+
+func TestToSamplesNumbers(t *testing.T) {
+	md := testMetricsData(
+		&metricspb.Metric{Name: "http.server.duration", Unit: "ms", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: []*metricspb.NumberDataPoint{{
+				Attributes:   []*commonpb.KeyValue{stringAttr("http.method", "GET")},
+				TimeUnixNano: testTime,
+				Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: 1.5},
+			}},
+		}}},
+		&metricspb.Metric{Name: "requests", Unit: "{request}", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			IsMonotonic:            true,
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			DataPoints: []*metricspb.NumberDataPoint{
+				{TimeUnixNano: testTime, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 42}},
+				{TimeUnixNano: testTime, Flags: noRecordedValue},
+			},
+		}}},
+		&metricspb.Metric{Name: "sent", Unit: "By", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			DataPoints:             []*metricspb.NumberDataPoint{{TimeUnixNano: testTime, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 7}}},
+		}}},
+		&metricspb.Metric{Name: "cpu.utilization", Unit: "1", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: []*metricspb.NumberDataPoint{{TimeUnixNano: testTime, Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 0.5}}},
+		}}},
+	)
+
+	samples, rejected, err := NewConverter(Options{
+		AddMetricSuffixes:         true,
+		PromoteResourceAttributes: []string{"k8s.namespace.name"},
+		UnderscoreNames:           true,
+	}).ToSamples(md)
+	require.NoError(t, err)
+	assert.Zero(t, rejected)
+	assert.Equal(t, []string{
+		`cpu_utilization_ratio{instance="pod-1", job="shop/api", k8s_namespace_name="prod"} 0.5`,
+		`http_server_duration_milliseconds{http_method="GET", instance="pod-1", job="shop/api", k8s_namespace_name="prod"} 1.5`,
+		`requests_total{instance="pod-1", job="shop/api", k8s_namespace_name="prod"} 42`,
+		`sent_bytes{instance="pod-1", job="shop/api", k8s_namespace_name="prod"} 7`,
+	}, sampleStrings(samples))
+	assert.Equal(t, model.Time(1700000000000), samples[0].Timestamp)
+}
+
+func TestToSamplesNameEscaping(t *testing.T) {
+	md := testMetricsData(&metricspb.Metric{Name: "http.server.requests", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{{
+			Attributes: []*commonpb.KeyValue{stringAttr("http.method", "GET")},
+			Value:      &metricspb.NumberDataPoint_AsInt{AsInt: 1},
+		}},
+	}}})
+
+	samples, _, err := NewConverter(Options{PromoteResourceAttributes: []string{"k8s.namespace.name"}}).ToSamples(md)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, model.LabelValue("http.server.requests"), samples[0].Metric[model.MetricNameLabel])
+	assert.Equal(t, model.LabelValue("GET"), samples[0].Metric["http.method"])
+	assert.Equal(t, model.LabelValue("prod"), samples[0].Metric["k8s.namespace.name"])
+
+	samples, _, err = NewConverter(Options{UnderscoreNames: true}).ToSamples(md)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, model.LabelValue("http_server_requests"), samples[0].Metric[model.MetricNameLabel])
+	assert.Equal(t, model.LabelValue("GET"), samples[0].Metric["http_method"])
+}
+
+func TestToSamplesDeltaTemporality(t *testing.T) {
+	delta := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	point := &metricspb.NumberDataPoint{TimeUnixNano: testTime, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 7}}
+	md := testMetricsData(
+		&metricspb.Metric{Name: "sent", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			IsMonotonic:            true,
+			AggregationTemporality: delta,
+			DataPoints:             []*metricspb.NumberDataPoint{point, point},
+		}}},
+		&metricspb.Metric{Name: "latency", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			AggregationTemporality: delta,
+			DataPoints:             []*metricspb.HistogramDataPoint{{TimeUnixNano: testTime, Count: 1}},
+		}}},
+		&metricspb.Metric{Name: "queued", Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			DataPoints:             []*metricspb.NumberDataPoint{point},
+		}}},
+	)
+
+	before := testutil.ToFloat64(rejectedDataPoints.WithLabelValues("delta_temporality"))
+	samples, rejected, err := NewConverter(Options{}).ToSamples(md)
+	require.ErrorIs(t, err, errDeltaTemporality)
+	assert.Equal(t, int64(3), rejected)
+	assert.Equal(t, before+3, testutil.ToFloat64(rejectedDataPoints.WithLabelValues("delta_temporality")))
+	assert.Equal(t, []string{`queued{instance="pod-1", job="shop/api"} 7`}, sampleStrings(samples))
+}
+
+func TestToSamplesHistograms(t *testing.T) {
+	sum := 10.0
+	md := testMetricsData(
+		&metricspb.Metric{Name: "latency", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			DataPoints: []*metricspb.HistogramDataPoint{{
+				TimeUnixNano:   testTime,
+				Count:          6,
+				Sum:            &sum,
+				ExplicitBounds: []float64{1, 5},
+				BucketCounts:   []uint64{1, 2, 3},
+			}},
+		}}},
+		&metricspb.Metric{Name: "size", Data: &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+			DataPoints: []*metricspb.ExponentialHistogramDataPoint{{
+				TimeUnixNano: testTime,
+				Count:        4,
+				Scale:        0,
+				ZeroCount:    1,
+				Positive:     &metricspb.ExponentialHistogramDataPoint_Buckets{Offset: 1, BucketCounts: []uint64{2, 0, 1}},
+			}},
+		}}},
+		&metricspb.Metric{Name: "rpc", Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+			DataPoints: []*metricspb.SummaryDataPoint{{
+				TimeUnixNano:   testTime,
+				Count:          3,
+				Sum:            9,
+				QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{{Quantile: 0.5, Value: 2}},
+			}},
+		}}},
+		&metricspb.Metric{Name: "broken", Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			DataPoints: []*metricspb.HistogramDataPoint{{ExplicitBounds: []float64{1}, BucketCounts: []uint64{1}}},
+		}}},
+	)
+
+	samples, rejected, err := NewConverter(Options{PromoteScopeMetadata: true}).ToSamples(md)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+	assert.Equal(t, int64(1), rejected)
+
+	assert.Equal(t, model.LabelValue("meter"), samples[0].Metric["otel_scope_name"])
+	for _, s := range samples {
+		for _, ln := range []model.LabelName{"instance", "job", "otel_scope_name", "otel_scope_version"} {
+			delete(s.Metric, ln)
+		}
+	}
+	assert.Equal(t, []string{
+		`latency_bucket{le="+Inf"} 6`,
+		`latency_bucket{le="1"} 1`,
+		`latency_bucket{le="5"} 3`,
+		`latency_count 6`,
+		`latency_sum 10`,
+		`rpc_count 3`,
+		`rpc_sum 9`,
+		`rpc{quantile="0.5"} 2`,
+		`size_bucket{le="+Inf"} 4`,
+		`size_bucket{le="0"} 1`,
+		`size_bucket{le="16"} 4`,
+		`size_bucket{le="4"} 3`,
+		`size_count 4`,
+	}, sampleStrings(samples))
+}
+
+func TestDecodeRequestAndResponse(t *testing.T) {
+	md := testMetricsData(&metricspb.Metric{Name: "up", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+		DataPoints: []*metricspb.NumberDataPoint{{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 1}}},
+	}}})
+	data, err := proto.Marshal(md)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/metrics", bytes.NewReader(data))
+	req.Header.Set("Content-Type", contentTypeProtobuf)
+	decoded, size, err := DecodeRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), size)
+	assert.True(t, proto.Equal(md, decoded))
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(
+		`{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{"name":"up","gauge":{"dataPoints":[{"asInt":"1","timeUnixNano":"1700000000000000000"}]}}]}]}]}`))
+	req.Header.Set("Content-Type", contentTypeJSON)
+	decoded, _, err = DecodeRequest(req)
+	require.NoError(t, err)
+	samples, _, err := NewConverter(Options{}).ToSamples(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"up 1"}, sampleStrings(samples))
+
+	w := httptest.NewRecorder()
+	WriteResponse(w, req, 2, "bad")
+	assert.JSONEq(t, `{"partialSuccess":{"rejectedDataPoints":"2","errorMessage":"bad"}}`, w.Body.String())
+}
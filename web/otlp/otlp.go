@@ -0,0 +1,108 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package otlp decodes OTLP/HTTP metrics export requests and converts them
+// to samples, following the OTLP translation of Prometheus.
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+// DecodeRequest reads an ExportMetricsServiceRequest, encoded in protobuf or
+// JSON and optionally gzipped. It is decoded as MetricsData, which has the
+// same wire format.
+func DecodeRequest(r *http.Request) (*metricspb.MetricsData, int, error) {
+	body := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error decoding gzip body: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		body = gz
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading body: %w", err)
+	}
+
+	md := &metricspb.MetricsData{}
+	if isJSON(r) {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, md)
+	} else {
+		err = proto.Unmarshal(data, md)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error decoding metrics: %w", err)
+	}
+	return md, len(data), nil
+}
+
+// WriteResponse writes an ExportMetricsServiceResponse, in the encoding of
+// the request, with the partial success of the export if some data points
+// were rejected.
+func WriteResponse(w http.ResponseWriter, r *http.Request, rejected int64, errMsg string) {
+	if isJSON(r) {
+		resp := map[string]interface{}{}
+		if rejected > 0 || errMsg != "" {
+			resp["partialSuccess"] = map[string]string{
+				"rejectedDataPoints": strconv.FormatInt(rejected, 10),
+				"errorMessage":       errMsg,
+			}
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	// ExportMetricsServiceResponse { ExportMetricsPartialSuccess partial_success = 1; }
+	// ExportMetricsPartialSuccess { int64 rejected_data_points = 1; string error_message = 2; }
+	var resp []byte
+	if rejected > 0 || errMsg != "" {
+		var partial []byte
+		partial = protowire.AppendTag(partial, 1, protowire.VarintType)
+		partial = protowire.AppendVarint(partial, uint64(rejected))
+		partial = protowire.AppendTag(partial, 2, protowire.BytesType)
+		partial = protowire.AppendString(partial, errMsg)
+		resp = protowire.AppendTag(resp, 1, protowire.BytesType)
+		resp = protowire.AppendBytes(resp, partial)
+	}
+	w.Header().Set("Content-Type", contentTypeProtobuf)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
+
+func isJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == contentTypeJSON
+}
@@ -0,0 +1,433 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package promapi serves a subset of the Prometheus HTTP query API, evaluating
+// PromQL over the series of a client.Reader.
+package promapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"log/slog"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+)
+
+const (
+	statusSuccess = "success"
+	statusError   = "error"
+
+	errorBadData  = "bad_data"
+	errorExec     = "execution"
+	errorTimeout  = "timeout"
+	errorCanceled = "canceled"
+	errorInternal = "internal"
+
+	// maxPoints is the maximum number of points per series of a range query.
+	maxPoints = 11000
+)
+
+var (
+	minTime = time.Unix(math.MinInt64/1000+62135596801, 0).UTC()
+	maxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999).UTC()
+)
+
+// Options are the limits of the queries.
+type Options struct {
+	Timeout       time.Duration
+	MaxSamples    int
+	LookbackDelta time.Duration
+	// SelectionRange is how far back the series and labels endpoints look
+	// without a start, instead of the beginning of time, unless zero.
+	SelectionRange time.Duration
+}
+
+// API evaluates queries of the Prometheus HTTP API over a client.Reader.
+type API struct {
+	reader         client.Reader
+	engine         *promql.Engine
+	selectionRange time.Duration
+	logger         *slog.Logger
+}
+
+// NewAPI returns a new API.
+func NewAPI(reader client.Reader, opts Options, logger *slog.Logger) *API {
+	engine := promql.NewEngine(promql.EngineOpts{
+		MaxSamples:    opts.MaxSamples,
+		Timeout:       opts.Timeout,
+		LookbackDelta: opts.LookbackDelta,
+		NoStepSubqueryIntervalFn: func(int64) int64 {
+			return time.Minute.Milliseconds()
+		},
+	})
+	return &API{reader: reader, engine: engine, selectionRange: opts.SelectionRange, logger: logger}
+}
+
+type apiError struct {
+	typ string
+	err error
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.typ, e.err)
+}
+
+type response struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Warnings  []string    `json:"warnings,omitempty"`
+}
+
+type queryData struct {
+	ResultType parser.ValueType `json:"resultType"`
+	Result     parser.Value     `json:"result"`
+}
+
+// Query serves /api/v1/query.
+func (api *API) Query(w http.ResponseWriter, r *http.Request) {
+	ts, err := parseTimeParam(r, "time", time.Now())
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	ctx, cancel, err := contextWithTimeout(r)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	defer cancel()
+
+	qry, err := api.engine.NewInstantQuery(api.queryable(r), r.FormValue("query"), ts)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	api.exec(ctx, w, qry)
+}
+
+// QueryRange serves /api/v1/query_range.
+func (api *API) QueryRange(w http.ResponseWriter, r *http.Request) {
+	start, err := parseTime(r.FormValue("start"))
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, fmt.Errorf("invalid parameter \"start\": %w", err)})
+		return
+	}
+	end, err := parseTime(r.FormValue("end"))
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, fmt.Errorf("invalid parameter \"end\": %w", err)})
+		return
+	}
+	if end.Before(start) {
+		api.respondError(w, &apiError{errorBadData, errors.New("end timestamp must not be before start time")})
+		return
+	}
+	step, err := parseDuration(r.FormValue("step"))
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, fmt.Errorf("invalid parameter \"step\": %w", err)})
+		return
+	}
+	if step <= 0 {
+		api.respondError(w, &apiError{errorBadData, errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer")})
+		return
+	}
+	if end.Sub(start)/step > maxPoints {
+		api.respondError(w, &apiError{errorBadData, fmt.Errorf("exceeded maximum resolution of %d points per timeseries. Try decreasing the query resolution (?step=XX)", maxPoints)})
+		return
+	}
+	ctx, cancel, err := contextWithTimeout(r)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	defer cancel()
+
+	qry, err := api.engine.NewRangeQuery(api.queryable(r), r.FormValue("query"), start, end, step)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	api.exec(ctx, w, qry)
+}
+
+func (api *API) exec(ctx context.Context, w http.ResponseWriter, qry promql.Query) {
+	defer qry.Close()
+	res := qry.Exec(ctx)
+	if res.Err != nil {
+		api.respondError(w, queryError(res.Err))
+		return
+	}
+	api.respond(w, &queryData{ResultType: res.Value.Type(), Result: res.Value}, res.Warnings)
+}
+
+// Series serves /api/v1/series.
+func (api *API) Series(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	if len(r.Form["match[]"]) == 0 {
+		api.respondError(w, &apiError{errorBadData, errors.New("no match[] parameter provided")})
+		return
+	}
+	start, end, matcherSets, err := api.parseSelection(r)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+
+	q, err := api.queryable(r).Querier(r.Context(), timestamp(start), timestamp(end))
+	if err != nil {
+		api.respondError(w, &apiError{errorExec, err})
+		return
+	}
+	defer func() { _ = q.Close() }()
+
+	hints := &storage.SelectHints{Start: timestamp(start), End: timestamp(end), Func: "series"}
+	sets := make([]storage.SeriesSet, 0, len(matcherSets))
+	for _, matchers := range matcherSets {
+		sets = append(sets, q.Select(true, hints, matchers...))
+	}
+	set := storage.NewMergeSeriesSet(sets, storage.ChainedSeriesMerge)
+
+	metrics := []labels.Labels{}
+	for set.Next() {
+		metrics = append(metrics, set.At().Labels())
+	}
+	if set.Err() != nil {
+		api.respondError(w, &apiError{errorExec, set.Err()})
+		return
+	}
+	api.respond(w, metrics, set.Warnings())
+}
+
+// LabelNames serves /api/v1/labels.
+func (api *API) LabelNames(w http.ResponseWriter, r *http.Request) {
+	api.labels(w, r, func(q storage.Querier, matchers []*labels.Matcher) ([]string, storage.Warnings, error) {
+		return q.LabelNames(matchers...)
+	})
+}
+
+// LabelValues serves /api/v1/label/<name>/values.
+func (api *API) LabelValues(w http.ResponseWriter, r *http.Request, name string) {
+	if !model.LabelName(name).IsValid() {
+		api.respondError(w, &apiError{errorBadData, fmt.Errorf("invalid label name: %q", name)})
+		return
+	}
+	api.labels(w, r, func(q storage.Querier, matchers []*labels.Matcher) ([]string, storage.Warnings, error) {
+		return q.LabelValues(name, matchers...)
+	})
+}
+
+func (api *API) labels(w http.ResponseWriter, r *http.Request, list func(storage.Querier, []*labels.Matcher) ([]string, storage.Warnings, error)) {
+	if err := r.ParseForm(); err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	start, end, matcherSets, err := api.parseSelection(r)
+	if err != nil {
+		api.respondError(w, &apiError{errorBadData, err})
+		return
+	}
+	if len(matcherSets) == 0 {
+		matcherSets = [][]*labels.Matcher{nil}
+	}
+
+	q, err := api.queryable(r).Querier(r.Context(), timestamp(start), timestamp(end))
+	if err != nil {
+		api.respondError(w, &apiError{errorExec, err})
+		return
+	}
+	defer func() { _ = q.Close() }()
+
+	set := make(map[string]struct{})
+	var warnings storage.Warnings
+	for _, matchers := range matcherSets {
+		values, ws, err := list(q, matchers)
+		if err != nil {
+			api.respondError(w, &apiError{errorExec, err})
+			return
+		}
+		warnings = append(warnings, ws...)
+		for _, v := range values {
+			set[v] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for v := range set {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	api.respond(w, result, warnings)
+}
+
+func (api *API) queryable(r *http.Request) storage.Queryable {
+	return &Queryable{Reader: api.reader, Request: r}
+}
+
+func (api *API) respond(w http.ResponseWriter, data interface{}, warnings storage.Warnings) {
+	resp := &response{Status: statusSuccess, Data: data}
+	for _, warning := range warnings {
+		resp.Warnings = append(resp.Warnings, warning.Error())
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		api.logger.Error("Error marshaling API response", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		api.logger.Error("Error writing API response", "err", err)
+	}
+}
+
+func (api *API) respondError(w http.ResponseWriter, apiErr *apiError) {
+	b, err := json.Marshal(&response{Status: statusError, ErrorType: apiErr.typ, Error: apiErr.err.Error()})
+	if err != nil {
+		api.logger.Error("Error marshaling API error", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var code int
+	switch apiErr.typ {
+	case errorBadData:
+		code = http.StatusBadRequest
+	case errorExec:
+		code = http.StatusUnprocessableEntity
+	case errorCanceled, errorTimeout:
+		code = http.StatusServiceUnavailable
+	default:
+		code = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err := w.Write(b); err != nil {
+		api.logger.Error("Error writing API error", "err", err)
+	}
+}
+
+func queryError(err error) *apiError {
+	switch err.(type) {
+	case promql.ErrQueryCanceled:
+		return &apiError{errorCanceled, err}
+	case promql.ErrQueryTimeout:
+		return &apiError{errorTimeout, err}
+	case promql.ErrStorage:
+		return &apiError{errorInternal, err}
+	}
+	return &apiError{errorExec, err}
+}
+
+// parseSelection parses the start, end and match[] parameters of the
+// metadata endpoints. The start defaults to the selection range before now,
+// so that Graphite isn't asked for its whole history.
+func (api *API) parseSelection(r *http.Request) (time.Time, time.Time, [][]*labels.Matcher, error) {
+	defaultStart := minTime
+	if api.selectionRange > 0 {
+		defaultStart = time.Now().Add(-api.selectionRange)
+	}
+	start, err := parseTimeParam(r, "start", defaultStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	}
+	end, err := parseTimeParam(r, "end", maxTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	}
+
+	var matcherSets [][]*labels.Matcher
+	for _, s := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, err
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+	return start, end, matcherSets, nil
+}
+
+func contextWithTimeout(r *http.Request) (context.Context, context.CancelFunc, error) {
+	ctx := r.Context()
+	if to := r.FormValue("timeout"); to != "" {
+		timeout, err := parseDuration(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid parameter \"timeout\": %w", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, cancel, nil
+}
+
+func parseTimeParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	val := r.FormValue(name)
+	if val == "" {
+		return defaultValue, nil
+	}
+	t, err := parseTime(val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid parameter %q: %w", name, err)
+	}
+	return t, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		s, ns := math.Modf(t)
+		ns = math.Round(ns*1000) / 1000
+		return time.Unix(int64(s), int64(ns*float64(time.Second))).UTC(), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration. It overflows int64", s)
+		}
+		return time.Duration(ts), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
+
+func timestamp(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
@@ -0,0 +1,197 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package promapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReader struct {
+	series   []*prompb.TimeSeries
+	matchers []*prompb.LabelMatcher
+	start    int64
+}
+
+func (r *fakeReader) Name() string   { return "fake" }
+func (r *fakeReader) Target() string { return "fake" }
+func (r *fakeReader) String() string { return "fake" }
+func (r *fakeReader) Shutdown()      {}
+
+func (r *fakeReader) Read(req *prompb.ReadRequest, httpReq *http.Request) (*prompb.ReadResponse, error) {
+	r.matchers = req.Queries[0].Matchers
+	r.start = req.Queries[0].StartTimestampMs
+	matchers, err := remote.FromLabelMatchers(r.matchers)
+	if err != nil {
+		return nil, err
+	}
+	result := &prompb.QueryResult{}
+	for _, ts := range r.series {
+		match := true
+		for _, m := range matchers {
+			match = match && m.Matches(labelValue(ts.Labels, m.Name))
+		}
+		if match {
+			result.Timeseries = append(result.Timeseries, ts)
+		}
+	}
+	return &prompb.ReadResponse{Results: []*prompb.QueryResult{result}}, nil
+}
+
+func labelValue(labels []prompb.Label, name string) string {
+	for _, l := range labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
+}
+
+func (r *fakeReader) LabelNames(ctx context.Context, start int64, matchers []*prompb.LabelMatcher, httpReq *http.Request) ([]string, error) {
+	r.matchers = matchers
+	r.start = start
+	return []string{model.MetricNameLabel, "owner"}, nil
+}
+
+func (r *fakeReader) LabelValues(ctx context.Context, start int64, name string, matchers []*prompb.LabelMatcher, httpReq *http.Request) ([]string, error) {
+	r.matchers = matchers
+	r.start = start
+	return []string{"team-X", "team-Y"}, nil
+}
+
+func newTestAPI() (*API, *fakeReader) {
+	reader := &fakeReader{series: []*prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{{Name: model.MetricNameLabel, Value: "test"}, {Name: "owner", Value: "team-X"}},
+			Samples: []prompb.Sample{
+				{Timestamp: 0, Value: 1},
+				{Timestamp: 60000, Value: 2},
+				{Timestamp: 120000, Value: 3},
+			},
+		},
+		{
+			Labels:  []prompb.Label{{Name: model.MetricNameLabel, Value: "test"}, {Name: "owner", Value: "team-Y"}},
+			Samples: []prompb.Sample{{Timestamp: 0, Value: 10}},
+		},
+	}}
+	api := NewAPI(reader, Options{Timeout: time.Minute, MaxSamples: 1000, LookbackDelta: 5 * time.Minute}, slog.New(slog.DiscardHandler))
+	return api, reader
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestQueryRange(t *testing.T) {
+	api, reader := newTestAPI()
+	rec := httptest.NewRecorder()
+	api.QueryRange(rec, httptest.NewRequest(http.MethodGet, `/api/v1/query_range?query=sum(test{owner="team-X"})&start=0&end=120&step=60`, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: "owner", Value: "team-X"},
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "test"},
+	}, reader.matchers)
+
+	body := decode(t, rec)
+	assert.Equal(t, "success", body["status"])
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, "matrix", data["resultType"])
+	result := data["result"].([]interface{})
+	require.Len(t, result, 1)
+	assert.Equal(t, []interface{}{
+		[]interface{}{float64(0), "1"},
+		[]interface{}{float64(60), "2"},
+		[]interface{}{float64(120), "3"},
+	}, result[0].(map[string]interface{})["values"])
+}
+
+func TestQueryBadData(t *testing.T) {
+	api, _ := newTestAPI()
+	rec := httptest.NewRecorder()
+	api.Query(rec, httptest.NewRequest(http.MethodGet, `/api/v1/query?query=sum(`, nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "bad_data", decode(t, rec)["errorType"])
+
+	rec = httptest.NewRecorder()
+	api.QueryRange(rec, httptest.NewRequest(http.MethodGet, `/api/v1/query_range?query=test&start=0&end=120&step=0`, nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSeries(t *testing.T) {
+	api, _ := newTestAPI()
+	rec := httptest.NewRecorder()
+	api.Series(rec, httptest.NewRequest(http.MethodGet, `/api/v1/series?match[]=test&start=0&end=120`, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{model.MetricNameLabel: "test", "owner": "team-X"},
+		map[string]interface{}{model.MetricNameLabel: "test", "owner": "team-Y"},
+	}, decode(t, rec)["data"])
+}
+
+func TestLabels(t *testing.T) {
+	api, reader := newTestAPI()
+	rec := httptest.NewRecorder()
+	api.LabelNames(rec, httptest.NewRequest(http.MethodGet, `/api/v1/labels`, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []interface{}{model.MetricNameLabel, "owner"}, decode(t, rec)["data"])
+
+	rec = httptest.NewRecorder()
+	api.LabelValues(rec, httptest.NewRequest(http.MethodGet, `/api/v1/label/owner/values?match[]=test`, nil), "owner")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []interface{}{"team-X", "team-Y"}, decode(t, rec)["data"])
+	assert.Equal(t, []*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: model.MetricNameLabel, Value: "test"},
+	}, reader.matchers)
+}
+
+func TestSelectionRange(t *testing.T) {
+	reader := &fakeReader{}
+	api := NewAPI(reader, Options{Timeout: time.Minute, MaxSamples: 1000, SelectionRange: time.Hour}, slog.New(slog.DiscardHandler))
+	expected := time.Now().Add(-time.Hour).UnixMilli()
+
+	rec := httptest.NewRecorder()
+	api.LabelNames(rec, httptest.NewRequest(http.MethodGet, `/api/v1/labels`, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.InDelta(t, expected, reader.start, float64(time.Minute.Milliseconds()))
+
+	rec = httptest.NewRecorder()
+	api.Series(rec, httptest.NewRequest(http.MethodGet, `/api/v1/series?match[]=test`, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.InDelta(t, expected, reader.start, float64(time.Minute.Milliseconds()))
+
+	// An explicit start is kept.
+	rec = httptest.NewRecorder()
+	api.LabelValues(rec, httptest.NewRequest(http.MethodGet, `/api/v1/label/owner/values?start=0`, nil), "owner")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, int64(0), reader.start)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package promapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// errNoLabelReader is returned for label discovery on a reader not implementing it.
+var errNoLabelReader = errors.New("label discovery is not supported by the reader")
+
+// Queryable is a storage.Queryable reading series with a client.Reader.
+// Request is passed to the reader, which selects the storage prefix from it.
+type Queryable struct {
+	Reader  client.Reader
+	Request *http.Request
+}
+
+// Querier implements the storage.Queryable interface.
+func (q *Queryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+	return &querier{ctx: ctx, mint: mint, maxt: maxt, reader: q.Reader, request: q.Request}, nil
+}
+
+type querier struct {
+	ctx        context.Context
+	mint, maxt int64
+	reader     client.Reader
+	request    *http.Request
+}
+
+// Select implements the storage.Querier interface.
+func (q *querier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	query, err := remote.ToQuery(q.mint, q.maxt, matchers, hints)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	resp, err := q.reader.Read(&prompb.ReadRequest{Queries: []*prompb.Query{query}}, q.request.WithContext(q.ctx))
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	if resp == nil || len(resp.Results) == 0 {
+		return storage.EmptySeriesSet()
+	}
+	return remote.FromQueryResult(sortSeries, resp.Results[0])
+}
+
+// LabelValues implements the storage.Querier interface.
+func (q *querier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	lr, ok := q.reader.(client.LabelReader)
+	if !ok {
+		return nil, nil, errNoLabelReader
+	}
+	values, err := lr.LabelValues(q.ctx, q.mint, name, toLabelMatchers(matchers), q.request)
+	return values, nil, err
+}
+
+// LabelNames implements the storage.Querier interface.
+func (q *querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	lr, ok := q.reader.(client.LabelReader)
+	if !ok {
+		return nil, nil, errNoLabelReader
+	}
+	names, err := lr.LabelNames(q.ctx, q.mint, toLabelMatchers(matchers), q.request)
+	return names, nil, err
+}
+
+// Close implements the storage.Querier interface.
+func (q *querier) Close() error {
+	return nil
+}
+
+func toLabelMatchers(matchers []*labels.Matcher) []*prompb.LabelMatcher {
+	result := make([]*prompb.LabelMatcher, 0, len(matchers))
+	for _, m := range matchers {
+		var mType prompb.LabelMatcher_Type
+		switch m.Type {
+		case labels.MatchEqual:
+			mType = prompb.LabelMatcher_EQ
+		case labels.MatchNotEqual:
+			mType = prompb.LabelMatcher_NEQ
+		case labels.MatchRegexp:
+			mType = prompb.LabelMatcher_RE
+		case labels.MatchNotRegexp:
+			mType = prompb.LabelMatcher_NRE
+		}
+		result = append(result, &prompb.LabelMatcher{Type: mType, Name: m.Name, Value: m.Value})
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+// Copyright 2024-2026 NetCracker Technology Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	graphiteCfg "github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/config"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/graphite/paths"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// traceRequest lists the metrics to trace, as samples like for the
+// simulated writes, or as series selectors with equality matchers only.
+// Client names the graphite or whisper entry of the clients list whose
+// rules are traced, the graphite block if empty.
+type traceRequest struct {
+	Client  string          `json:"client"`
+	Prefix  string          `json:"prefix"`
+	Samples []*model.Sample `json:"samples"`
+	Series  []string        `json:"series"`
+}
+
+// trace explains how the write rules render the paths of metrics. The
+// metrics are given in a JSON body on POST, or as series query parameters.
+func (h *Handler) trace(w http.ResponseWriter, r *http.Request) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var req traceRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Client = r.URL.Query().Get("client")
+		req.Prefix = r.URL.Query().Get("prefix")
+		req.Series = r.URL.Query()["series"]
+	}
+
+	metrics := make([]model.Metric, 0, len(req.Samples)+len(req.Series))
+	for _, s := range req.Samples {
+		metrics = append(metrics, s.Metric)
+	}
+	for _, selector := range req.Series {
+		m, err := seriesFromSelector(selector)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		metrics = append(metrics, m)
+	}
+
+	cfg, err := h.traceConfig(req.Client)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prefix := req.Prefix
+	if prefix == "" {
+		prefix = cfg.StoragePrefixFromRequest(r)
+	}
+	format := paths.FormatForPrefix(cfg, prefix)
+	traces := make([]*paths.Trace, 0, len(metrics))
+	for _, m := range metrics {
+		if cfg.NameEscaping == graphiteCfg.NameEscapingUnderscores {
+			m = paths.UnderscoreNames(m)
+		}
+		traces = append(traces, paths.TraceMetric(m, format, prefix, cfg.Write.Rules, cfg.Write.TemplateData))
+	}
+
+	data, err := json.Marshal(traces)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// traceConfig returns the graphite block of the named entry of the clients
+// list, or the top-level one if name is empty or "graphite".
+func (h *Handler) traceConfig(name string) (*graphiteCfg.Config, error) {
+	if name == "" || name == "graphite" {
+		return &h.cfg.Graphite, nil
+	}
+	for _, cc := range h.cfg.Clients {
+		if cc.Name != name {
+			continue
+		}
+		if cc.Type != "graphite" && cc.Type != "whisper" {
+			return nil, fmt.Errorf("client %q of type %q has no write rules", name, cc.Type)
+		}
+		cfg := graphiteCfg.DefaultConfig
+		if err := cc.Settings.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("invalid settings of client %q: %w", name, err)
+		}
+		return &cfg, nil
+	}
+	return nil, fmt.Errorf("unknown client %q", name)
+}
+
+// seriesFromSelector returns the metric described by a series selector like
+// up{job="node"}.
+func seriesFromSelector(selector string) (model.Metric, error) {
+	matchers, err := parser.ParseMetricSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	m := make(model.Metric, len(matchers))
+	for _, matcher := range matchers {
+		if matcher.Type != labels.MatchEqual {
+			return nil, fmt.Errorf("series %s: only equality matchers describe a series, found %s", selector, matcher)
+		}
+		if matcher.Value != "" {
+			m[model.LabelName(matcher.Name)] = model.LabelValue(matcher.Value)
+		}
+	}
+	return m, nil
+}
@@ -17,17 +17,21 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/Netcracker/qubership-graphite-remote-adapter/client"
+	"github.com/Netcracker/qubership-graphite-remote-adapter/client/remote"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
-	"github.com/prometheus/prometheus/storage/remote"
+	promremote "github.com/prometheus/prometheus/storage/remote"
 )
 
 var (
@@ -45,7 +49,7 @@ var (
 			Name:      "sent_samples_total",
 			Help:      "Total number of processed samples sent to remote storage.",
 		},
-		[]string{"prefix", "remote"},
+		[]string{"prefix", "remote", "client"},
 	)
 	failedSamples = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -53,7 +57,7 @@ var (
 			Name:      "failed_samples_total",
 			Help:      "Total number of processed samples which failed on send to remote storage.",
 		},
-		[]string{"prefix", "remote"},
+		[]string{"prefix", "remote", "client"},
 	)
 	sentBatchDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -62,7 +66,7 @@ var (
 			Help:      "Duration of sample batch send calls to the remote storage.",
 			Buckets:   prometheus.DefBuckets,
 		},
-		[]string{"remote"},
+		[]string{"remote", "client"},
 	)
 )
 
@@ -92,37 +96,69 @@ func (h *Handler) write(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeResponse, writeErr := h.writeSamples(samples, reqBufLen, r, dryRun)
+
+	// Write response body.
+	data, err := json.Marshal(writeResponse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The failed writes are only reported in the body, unless a writer asks
+	// for the request to be retried.
+	if remote.IsRecoverable(writeErr) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(data)
+}
+
+// writeSamples writes samples with every writer. It returns the response
+// of each writer by name and the errors of the writers which failed.
+func (h *Handler) writeSamples(samples model.Samples, reqBufLen int, r *http.Request, dryRun bool) (map[string]string, error) {
 	prefix := h.cfg.Graphite.StoragePrefixFromRequest(r)
 
 	receivedSamples.WithLabelValues(prefix).Add(float64(len(samples)))
 
 	// Execute write on each writer clients.
 	var wg sync.WaitGroup
+	var lock sync.Mutex
 	writeResponse := make(map[string]string)
-	var msgBytes []byte
+	var errs []error
 	for _, writer := range h.writers {
 		wg.Add(1)
-		go func(client client.Writer) {
-			msgBytes, err = h.instrumentedWriteSamples(client, samples, reqBufLen, r, dryRun)
+		go func(w client.Writer) {
+			defer wg.Done()
+			// Writers of the clients list may only be given some samples.
+			selected := client.Select(w, samples)
+			msgBytes, err := h.instrumentedWriteSamples(w, selected, reqBufLen, r, dryRun)
+			lock.Lock()
+			defer lock.Unlock()
 			if err != nil {
-				failedSamples.WithLabelValues(prefix, client.Target()).Add(float64(len(samples)))
-				writeResponse[client.Name()] = err.Error()
+				failedSamples.WithLabelValues(prefix, w.Target(), w.Name()).Add(float64(len(selected)))
+				writeResponse[w.Name()] = err.Error()
+				errs = append(errs, fmt.Errorf("%s: %w", w.Name(), err))
 			} else {
-				sentSamples.WithLabelValues(prefix, client.Target()).Add(float64(len(samples)))
-				writeResponse[client.Name()] = string(msgBytes)
+				sentSamples.WithLabelValues(prefix, w.Target(), w.Name()).Add(float64(len(selected)))
+				writeResponse[w.Name()] = string(msgBytes)
 			}
-			wg.Done()
 		}(writer)
 	}
 	wg.Wait()
+	return writeResponse, errors.Join(errs...)
+}
 
-	// Write response body.
-	data, err := json.Marshal(writeResponse)
+// Append writes samples which weren't received in a request, like the
+// scraped ones, with every writer.
+func (h *Handler) Append(ctx context.Context, samples model.Samples) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/write", nil)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return err
 	}
-	_, _ = w.Write(data)
+	_, err = h.writeSamples(samples, 0, r, false)
+	return err
 }
 
 func (h *Handler) parseTestWriteRequest(w http.ResponseWriter, r *http.Request) (model.Samples, error) {
@@ -136,7 +172,7 @@ func (h *Handler) parseTestWriteRequest(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) parseWriteRequest(w http.ResponseWriter, r *http.Request) (model.Samples, int, error) {
-	req, err := remote.DecodeWriteRequest(r.Body)
+	req, err := promremote.DecodeWriteRequest(r.Body)
 	if err != nil {
 		h.logger.Error("Error decoding remote write request", "err", err.Error())
 		return nil, 0, err
@@ -176,6 +212,6 @@ func (h *Handler) instrumentedWriteSamples(
 		h.logger.Warn("Error sending samples to remote storage", "num_samples", len(samples), "storage", w.Name(), "err", err)
 		return nil, err
 	}
-	sentBatchDuration.WithLabelValues(w.Target()).Observe(duration)
+	sentBatchDuration.WithLabelValues(w.Target(), w.Name()).Observe(duration)
 	return msgBytes, nil
 }